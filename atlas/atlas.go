@@ -0,0 +1,223 @@
+// Package atlas packs many small images into a few large atlas pages. Atlases can be built offline, serialised
+// and loaded at runtime, where core creates one texture per page and remaps mesh texture coordinates to the
+// packed regions. It has no dependencies outside the standard library.
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"sort"
+)
+
+// Options control how images are packed.
+type Options struct {
+	// MaxWidth and MaxHeight bound the size of each page, images which do not fit start a new page.
+	MaxWidth  int
+	MaxHeight int
+
+	// Padding is the number of texels around each image. It is filled by extending the image's edges so
+	// filtering and mipmapping do not bleed neighbouring images in.
+	Padding int
+
+	// PowerOfTwo rounds page sizes up to powers of two.
+	PowerOfTwo bool
+}
+
+// DefaultOptions packs into pages of up to 2048x2048 with 2 texels of padding.
+var DefaultOptions = Options{MaxWidth: 2048, MaxHeight: 2048, Padding: 2}
+
+// Region is the location of an image in the atlas. UV holds the normalized u0, v0, u1, v1 rectangle, with v
+// increasing downwards from the top row of the page like texel rows.
+type Region struct {
+	Page   int        `json:"page"`
+	X      int        `json:"x"`
+	Y      int        `json:"y"`
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	UV     [4]float32 `json:"uv"`
+}
+
+// Remap maps texture coordinates from the source image's 0-1 range into the region. Coordinates are stored
+// with the given number of components per vertex, only the first two are changed. Coordinates outside 0-1,
+// as used for tiling, cannot be represented in an atlas.
+func (r Region) Remap(coords []float32, components int) {
+	for i := 0; i+1 < len(coords); i += components {
+		coords[i] = r.UV[0] + coords[i]*(r.UV[2]-r.UV[0])
+		coords[i+1] = r.UV[1] + coords[i+1]*(r.UV[3]-r.UV[1])
+	}
+}
+
+// Page is a single atlas image.
+type Page struct {
+	Image *image.RGBA
+}
+
+// Atlas is a set of pages and the regions of the images packed into them.
+type Atlas struct {
+	Pages   []*Page
+	Regions map[string]Region
+}
+
+// Build packs the given images into as few pages as possible. Images are placed tallest first, ties broken
+// by name, so the same input always produces the same atlas.
+func Build(images map[string]image.Image, opts Options) (*Atlas, error) {
+	if opts.MaxWidth <= 0 || opts.MaxHeight <= 0 || opts.Padding < 0 {
+		return nil, fmt.Errorf("invalid atlas options %+v", opts)
+	}
+
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		hi, hj := images[names[i]].Bounds().Dy(), images[names[j]].Bounds().Dy()
+		if hi != hj {
+			return hi > hj
+		}
+		return names[i] < names[j]
+	})
+
+	// place every image, opening pages as needed
+	var packers []*skyline
+	regions := make(map[string]Region, len(images))
+	for _, name := range names {
+		size := images[name].Bounds().Size()
+		w, h := size.X+2*opts.Padding, size.Y+2*opts.Padding
+		if w > opts.MaxWidth || h > opts.MaxHeight {
+			return nil, fmt.Errorf("image %s (%dx%d) does not fit in a %dx%d page", name, size.X, size.Y, opts.MaxWidth, opts.MaxHeight)
+		}
+
+		placed := false
+		for page, p := range packers {
+			if x, y, ok := p.insert(w, h); ok {
+				regions[name] = Region{Page: page, X: x + opts.Padding, Y: y + opts.Padding, Width: size.X, Height: size.Y}
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			p := newSkyline(opts.MaxWidth, opts.MaxHeight)
+			x, y, _ := p.insert(w, h)
+			regions[name] = Region{Page: len(packers), X: x + opts.Padding, Y: y + opts.Padding, Width: size.X, Height: size.Y}
+			packers = append(packers, p)
+		}
+	}
+
+	// shrink pages to their contents and blit
+	a := &Atlas{Pages: make([]*Page, len(packers)), Regions: regions}
+	for i, p := range packers {
+		w, h := p.usedWidth(), p.usedHeight()
+		if opts.PowerOfTwo {
+			w, h = nextPowerOfTwo(w), nextPowerOfTwo(h)
+		}
+		a.Pages[i] = &Page{image.NewRGBA(image.Rect(0, 0, w, h))}
+	}
+	for _, name := range names {
+		r := regions[name]
+		blit(a.Pages[r.Page].Image, images[name], r, opts.Padding)
+	}
+	a.updateUVs()
+
+	return a, nil
+}
+
+// updateUVs computes normalized rectangles from texel regions
+func (a *Atlas) updateUVs() {
+	for name, r := range a.Regions {
+		bounds := a.Pages[r.Page].Image.Bounds()
+		w, h := float32(bounds.Dx()), float32(bounds.Dy())
+		r.UV = [4]float32{float32(r.X) / w, float32(r.Y) / h, float32(r.X+r.Width) / w, float32(r.Y+r.Height) / h}
+		a.Regions[name] = r
+	}
+}
+
+// blit copies an image into its region and extends its edges into the padding
+func blit(dst *image.RGBA, src image.Image, r Region, padding int) {
+	rect := image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+	draw.Draw(dst, rect, src, src.Bounds().Min, draw.Src)
+
+	if padding == 0 || r.Width == 0 || r.Height == 0 {
+		return
+	}
+
+	clamp := func(v, low, high int) int {
+		if v < low {
+			return low
+		}
+		if v > high {
+			return high
+		}
+		return v
+	}
+	for y := r.Y - padding; y < r.Y+r.Height+padding; y++ {
+		for x := r.X - padding; x < r.X+r.Width+padding; x++ {
+			if image.Pt(x, y).In(rect) {
+				continue
+			}
+			sx, sy := clamp(x, r.X, r.X+r.Width-1), clamp(y, r.Y, r.Y+r.Height-1)
+			dst.SetRGBA(x, y, dst.RGBAAt(sx, sy))
+		}
+	}
+}
+
+func nextPowerOfTwo(v int) int {
+	p := 1
+	for p < v {
+		p <<= 1
+	}
+	return p
+}
+
+// serialised form, pages are png encoded
+type atlasFile struct {
+	Pages   [][]byte          `json:"pages"`
+	Regions map[string]Region `json:"regions"`
+}
+
+// Marshal serialises the atlas as json with png encoded pages.
+func (a *Atlas) Marshal() ([]byte, error) {
+	f := atlasFile{Pages: make([][]byte, len(a.Pages)), Regions: a.Regions}
+	for i, p := range a.Pages {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, p.Image); err != nil {
+			return nil, err
+		}
+		f.Pages[i] = buf.Bytes()
+	}
+	return json.Marshal(f)
+}
+
+// Unmarshal reads an atlas serialised with Marshal.
+func Unmarshal(data []byte) (*Atlas, error) {
+	var f atlasFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	a := &Atlas{Pages: make([]*Page, len(f.Pages)), Regions: f.Regions}
+	for i, data := range f.Pages {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i, err)
+		}
+		rgba, ok := img.(*image.RGBA)
+		if !ok {
+			rgba = image.NewRGBA(img.Bounds())
+			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		}
+		a.Pages[i] = &Page{rgba}
+	}
+
+	for name, r := range a.Regions {
+		if r.Page < 0 || r.Page >= len(a.Pages) {
+			return nil, errors.New("region " + name + " references a missing page")
+		}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,110 @@
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidImage(w, h int, c color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func testImages() map[string]image.Image {
+	images := make(map[string]image.Image)
+	for i := 0; i < 40; i++ {
+		images[fmt.Sprintf("img%02d", i)] = solidImage(5+i%7*3, 4+i%5*4, color.RGBA{uint8(i * 6), 0, 0, 255})
+	}
+	return images
+}
+
+func TestBuild(t *testing.T) {
+	images := testImages()
+	a, err := Build(images, Options{MaxWidth: 64, MaxHeight: 64, Padding: 1, PowerOfTwo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) < 2 {
+		t.Errorf("expected several pages, got %d", len(a.Pages))
+	}
+
+	for i, p := range a.Pages {
+		b := p.Image.Bounds()
+		if b.Dx() > 64 || b.Dy() > 64 || b.Dx()&(b.Dx()-1) != 0 || b.Dy()&(b.Dy()-1) != 0 {
+			t.Errorf("page %d has invalid size %v", i, b.Size())
+		}
+	}
+
+	// padded regions must not overlap and must hold their image, edges included
+	for name, r := range a.Regions {
+		padded := image.Rect(r.X-1, r.Y-1, r.X+r.Width+1, r.Y+r.Height+1)
+		if !padded.In(a.Pages[r.Page].Image.Bounds()) {
+			t.Errorf("%s is outside its page", name)
+		}
+		for other, o := range a.Regions {
+			if other != name && o.Page == r.Page && padded.Overlaps(image.Rect(o.X-1, o.Y-1, o.X+o.Width+1, o.Y+o.Height+1)) {
+				t.Errorf("%s overlaps %s", name, other)
+			}
+		}
+
+		want := images[name].At(0, 0)
+		page := a.Pages[r.Page].Image
+		if page.At(r.X, r.Y) != want || page.At(r.X-1, r.Y-1) != want || page.At(r.X+r.Width, r.Y+r.Height) != want {
+			t.Errorf("%s texels or padding do not match", name)
+		}
+	}
+
+	if _, err := Build(map[string]image.Image{"big": solidImage(65, 1, color.RGBA{})}, Options{MaxWidth: 64, MaxHeight: 64}); err == nil {
+		t.Error("expected error for oversized image")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	a, err := Build(testImages(), DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := a.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.Pages) != len(a.Pages) || len(b.Regions) != len(a.Regions) {
+		t.Fatalf("got %d pages and %d regions, expected %d and %d", len(b.Pages), len(b.Regions), len(a.Pages), len(a.Regions))
+	}
+	for name, r := range a.Regions {
+		if b.Regions[name] != r {
+			t.Errorf("region %s: got %+v, expected %+v", name, b.Regions[name], r)
+		}
+	}
+	for i := range a.Pages {
+		if string(a.Pages[i].Image.Pix) != string(b.Pages[i].Image.Pix) {
+			t.Errorf("page %d texels differ", i)
+		}
+	}
+}
+
+func TestRemap(t *testing.T) {
+	r := Region{UV: [4]float32{0.5, 0.25, 1.0, 0.5}}
+	coords := []float32{0, 0, 7, 1, 1, 7, 0.5, 0.5, 7}
+	r.Remap(coords, 3)
+
+	want := []float32{0.5, 0.25, 7, 1.0, 0.5, 7, 0.75, 0.375, 7}
+	for i := range want {
+		if coords[i] != want[i] {
+			t.Fatalf("got %v, expected %v", coords, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package atlas
+
+// skylineNode is a horizontal segment of the skyline, everything below it is used
+type skylineNode struct {
+	x, y, width int
+}
+
+// skyline is a bottom-left skyline rectangle packer. Rectangles are placed where they leave the lowest top edge,
+// preferring narrower segments on ties.
+type skyline struct {
+	width, height int
+	nodes         []skylineNode
+}
+
+func newSkyline(width, height int) *skyline {
+	return &skyline{width, height, []skylineNode{{0, 0, width}}}
+}
+
+// insert places a w x h rectangle and returns its top left corner, or false if it does not fit
+func (s *skyline) insert(w, h int) (int, int, bool) {
+	bestIndex, bestX, bestY, bestTop, bestWidth := -1, 0, 0, s.height+1, s.width+1
+	for i := range s.nodes {
+		y, ok := s.fit(i, w, h)
+		if !ok {
+			continue
+		}
+		if y+h < bestTop || (y+h == bestTop && s.nodes[i].width < bestWidth) {
+			bestIndex, bestX, bestY, bestTop, bestWidth = i, s.nodes[i].x, y, y+h, s.nodes[i].width
+		}
+	}
+	if bestIndex < 0 {
+		return 0, 0, false
+	}
+
+	// add the new segment and cut the ones it covers
+	node := skylineNode{bestX, bestY + h, w}
+	s.nodes = append(s.nodes[:bestIndex], append([]skylineNode{node}, s.nodes[bestIndex:]...)...)
+	for i := bestIndex + 1; i < len(s.nodes); i++ {
+		prev := s.nodes[i-1]
+		if s.nodes[i].x >= prev.x+prev.width {
+			break
+		}
+		shrink := prev.x + prev.width - s.nodes[i].x
+		s.nodes[i].x += shrink
+		s.nodes[i].width -= shrink
+		if s.nodes[i].width > 0 {
+			break
+		}
+		s.nodes = append(s.nodes[:i], s.nodes[i+1:]...)
+		i--
+	}
+
+	// merge neighbours at the same height
+	for i := 0; i+1 < len(s.nodes); i++ {
+		if s.nodes[i].y == s.nodes[i+1].y {
+			s.nodes[i].width += s.nodes[i+1].width
+			s.nodes = append(s.nodes[:i+1], s.nodes[i+2:]...)
+			i--
+		}
+	}
+
+	return bestX, bestY, true
+}
+
+// fit returns the y a w x h rectangle would rest at when placed at node i
+func (s *skyline) fit(i, w, h int) (int, bool) {
+	if s.nodes[i].x+w > s.width {
+		return 0, false
+	}
+
+	y := 0
+	for left := w; left > 0; i++ {
+		if i >= len(s.nodes) {
+			return 0, false
+		}
+		if s.nodes[i].y > y {
+			y = s.nodes[i].y
+		}
+		if y+h > s.height {
+			return 0, false
+		}
+		left -= s.nodes[i].width
+	}
+	return y, true
+}
+
+// usedWidth returns the right edge of the used area
+func (s *skyline) usedWidth() int {
+	used := 0
+	for _, n := range s.nodes {
+		if n.y > 0 {
+			used = n.x + n.width
+		}
+	}
+	return used
+}
+
+// usedHeight returns the bottom edge of the used area
+func (s *skyline) usedHeight() int {
+	used := 0
+	for _, n := range s.nodes {
+		if n.y > used {
+			used = n.y
+		}
+	}
+	return used
+}
@@ -0,0 +1,12 @@
+#version 410 core
+
+// drawn in place of assets which failed to load, a magenta and black checkerboard
+in vec3 tcoords0;
+
+layout (location = 0) out vec4 color;
+
+void main() {
+    vec2 cell = floor(tcoords0.xy * 8.0);
+    float checker = mod(cell.x + cell.y, 2.0);
+    color = mix(vec4(1.0, 0.0, 1.0, 1.0), vec4(0.0, 0.0, 0.0, 1.0), checker);
+}
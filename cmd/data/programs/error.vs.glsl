@@ -0,0 +1,18 @@
+#version 410 core
+
+#include "gosg/camera.glsl"
+
+// this is the same for all our models
+layout (location = 0) in vec3 position_in;
+layout (location = 1) in vec3 normal_in;
+layout (location = 2) in vec3 tangent_in;
+layout (location = 3) in vec3 bitangent_in;
+layout (location = 4) in vec3 tcoords0_in;
+layout (location = 5) in mat4 mMatrix;
+
+out vec3 tcoords0;
+
+void main() {
+    gl_Position = vpMatrix * mMatrix * vec4(position_in, 1.0);
+    tcoords0 = tcoords0_in;
+}
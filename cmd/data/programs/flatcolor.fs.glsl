@@ -2,7 +2,7 @@
 
 layout (location = 0) out vec4 color;
 
-uniform vec4 flatColor;
+flat in vec4 flatColor;
 
 void main() {
     color = flatColor;
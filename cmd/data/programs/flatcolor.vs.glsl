@@ -1,19 +1,6 @@
 #version 410 core
 
-// global uniforms
-struct light {
-    mat4 vpMatrix;
-    vec4 position;
-    vec4 color;
-};
-
-layout (std140) uniform cameraConstants {
-    mat4 vMatrix;
-    mat4 pMatrix;
-    mat4 vpMatrix;
-    vec4 lightCount;
-    light lights[16];
-};
+#include "gosg/camera.glsl"
 
 // this is the same for all our models
 layout (location = 0) in vec3 position_in;
@@ -23,6 +10,14 @@ layout (location = 3) in vec3 bitangent_in;
 layout (location = 4) in vec3 tcoords0_in;
 layout (location = 5) in mat4 mMatrix;
 
+// per node uniforms, indexed by instance
+layout (std140) uniform nodeBlock {
+    vec4 flatColors[64];
+};
+
+flat out vec4 flatColor;
+
 void main() {
     gl_Position = vpMatrix * mMatrix * vec4(position_in, 1.0);
+    flatColor = flatColors[gl_InstanceID];
 }
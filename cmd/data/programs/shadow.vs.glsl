@@ -1,19 +1,6 @@
 #version 410 core
 
-// global uniforms
-struct light {
-    mat4 vpMatrix;
-    vec4 position;
-    vec4 color;
-};
-
-layout (std140) uniform cameraConstants {
-    mat4 vMatrix;
-    mat4 pMatrix;
-    mat4 vpMatrix;
-    vec4 lightCount;
-    light lights[16];
-};
+#include "gosg/camera.glsl"
 
 // this is the same for all our models
 layout (location = 0) in vec3 position_in;
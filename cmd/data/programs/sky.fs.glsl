@@ -0,0 +1,72 @@
+#version 410 core
+
+#define SKY_CUBEMAP 0
+#define SKY_EQUIRECTANGULAR 1
+#define SKY_GRADIENT 2
+#define SKY_ANALYTIC 3
+
+// sunDirection.w is cos of the sun disk radius
+#include "gosg/sky.glsl"
+
+in vec3 direction;
+
+layout (location = 0) out vec4 color;
+
+uniform samplerCube environmentCubeTex;
+uniform sampler2D environmentTex;
+
+const float PI = 3.14159265;
+
+vec2 equirectangular(vec3 d) {
+    return vec2(atan(d.z, d.x) / (2.0 * PI) + 0.5, acos(clamp(d.y, -1.0, 1.0)) / PI);
+}
+
+vec3 gradient(vec3 d) {
+    if (d.y < 0.0) {
+        return mix(horizonColor.rgb, groundColor.rgb, pow(-d.y, 0.5));
+    }
+    return mix(horizonColor.rgb, zenithColor.rgb, pow(d.y, 0.5));
+}
+
+vec3 analytic(vec3 d) {
+    vec3 sun = normalize(sunDirection.xyz);
+    float sunHeight = sun.y;
+
+    // rayleigh like falloff from horizon to zenith, reddened when the sun is low
+    vec3 zenith = vec3(0.20, 0.40, 0.85);
+    vec3 horizon = mix(vec3(0.95, 0.55, 0.30), vec3(0.70, 0.82, 0.95), clamp(sunHeight * 4.0, 0.0, 1.0));
+    float t = pow(1.0 - max(d.y, 0.0), 4.0);
+    vec3 sky = mix(zenith, horizon, t);
+
+    // daylight fades out as the sun sets
+    sky *= clamp(sunHeight * 3.0 + 0.3, 0.02, 1.0);
+
+    // mie halo and sun disk
+    float cosTheta = dot(d, sun);
+    sky += vec3(1.0, 0.9, 0.7) * pow(max(cosTheta, 0.0), 64.0) * 0.5;
+    sky += vec3(1.0, 0.95, 0.85) * smoothstep(sunDirection.w - 0.0002, sunDirection.w, cosTheta) * 10.0;
+
+    // ground
+    if (d.y < 0.0) {
+        sky = mix(sky, vec3(0.15, 0.13, 0.12) * clamp(sunHeight + 0.2, 0.05, 1.0), clamp(-d.y * 8.0, 0.0, 1.0));
+    }
+
+    return sky;
+}
+
+void main() {
+    vec3 d = normalize(direction);
+
+    int m = int(mode.x);
+    if (m == SKY_CUBEMAP) {
+        color.rgb = texture(environmentCubeTex, d).rgb;
+    } else if (m == SKY_EQUIRECTANGULAR) {
+        color.rgb = texture(environmentTex, equirectangular(d)).rgb;
+    } else if (m == SKY_GRADIENT) {
+        color.rgb = gradient(d);
+    } else {
+        color.rgb = analytic(d);
+    }
+
+    color.a = 1.0;
+}
\ No newline at end of file
@@ -0,0 +1,22 @@
+#version 410 core
+
+#include "gosg/camera.glsl"
+
+// this is the same for all our models
+layout (location = 0) in vec3 position_in;
+layout (location = 1) in vec3 normal_in;
+layout (location = 2) in vec3 tangent_in;
+layout (location = 3) in vec3 bitangent_in;
+layout (location = 4) in vec3 tcoords0_in;
+layout (location = 5) in mat4 mMatrix;
+
+out vec3 direction;
+
+void main() {
+    // fullscreen triangle on the far plane
+    gl_Position = vec4(position_in.xy, 1.0, 1.0);
+
+    // world space view direction, rotation only
+    vec4 world = inverse(pMatrix * mat4(mat3(vMatrix))) * gl_Position;
+    direction = world.xyz / world.w;
+}
\ No newline at end of file
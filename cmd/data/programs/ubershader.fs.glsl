@@ -1,22 +1,6 @@
 #version 410 core
 
-#define MAX_CASCADES 10
-
-// global uniforms
-struct light {
-    mat4 vpMatrix[MAX_CASCADES];
-    vec4 zCuts[MAX_CASCADES];
-    vec4 position;
-    vec4 color;
-};
-
-layout (std140) uniform cameraConstants {
-    mat4 vMatrix;
-    mat4 pMatrix;
-    mat4 vpMatrix;
-    vec4 lightCount;
-    light lights[16];
-};
+#include "gosg/lights.glsl"
 
 in vec3 position;
 in vec3 cameraPosition;
@@ -26,7 +10,9 @@ in mat3 tbn;
 layout (location = 0) out vec4 color;
 
 uniform sampler2D albedoTex;
+#ifdef NORMAL_MAP
 uniform sampler2D normalTex;
+#endif
 uniform sampler2D roughTex;
 uniform sampler2D metalTex;
 
@@ -35,6 +21,10 @@ uniform sampler2D shadowTex1;
 uniform sampler2D shadowTex2;
 uniform sampler2D shadowTex3;
 
+// sky environment, only one is bound, the other samples black
+uniform samplerCube environmentCubeTex;
+uniform sampler2D environmentTex;
+
 float linstep(float low, float high, float v) {
     return clamp((v - low) / (high - low), 0.0, 1.0);
 }
@@ -50,28 +40,24 @@ float varianceShadowMap(sampler2D shadowTex, vec2 coords, float compare) {
     return min(max(p, pMax), 1.0);
 }
 
-float shadow(vec4 coords, int lightIndex) {
-    const int numCascades = 3;
-
-    vec3 shadowMapCoords[numCascades];
-
-    for (int i=0; i<numCascades; i++) {
-        vec4 ccoords = lights[lightIndex].vpMatrix[i] * coords;
-        shadowMapCoords[i] = ccoords.xyz/ccoords.w;
-    }
+float cascadeShadow(sampler2D shadowTex, vec4 coords) {
+    vec3 shadowMapCoords = coords.xyz/coords.w;
+    return varianceShadowMap(shadowTex, shadowMapCoords.xy, shadowMapCoords.z);
+}
 
+float shadow(vec4 coords, int light) {
     float fragZV = length(cameraPosition-position);
 
-    if (fragZV < lights[lightIndex].zCuts[0].x) {
-        return varianceShadowMap(shadowTex0, shadowMapCoords[0].xy, shadowMapCoords[0].z);
+    if (fragZV < lightZCuts(light, 0).x) {
+        return cascadeShadow(shadowTex0, lightVpMatrix(light, 0) * coords);
     }
 
-    if (fragZV < lights[lightIndex].zCuts[1].x) {
-        return varianceShadowMap(shadowTex1, shadowMapCoords[1].xy, shadowMapCoords[1].z);
+    if (fragZV < lightZCuts(light, 1).x) {
+        return cascadeShadow(shadowTex1, lightVpMatrix(light, 1) * coords);
     }
 
-    if (fragZV < lights[lightIndex].zCuts[2].x) {
-        return varianceShadowMap(shadowTex2, shadowMapCoords[2].xy, shadowMapCoords[2].z);
+    if (fragZV < lightZCuts(light, 2).x) {
+        return cascadeShadow(shadowTex2, lightVpMatrix(light, 2) * coords);
     }
 
     return 1.0;
@@ -96,6 +82,11 @@ vec3 tonemapUncharted2(vec3 color) {
     return curr * whiteScale;
 }
 
+vec3 environment(vec3 d) {
+    vec2 equirect = vec2(atan(d.z, d.x) / (2.0 * 3.14159265) + 0.5, acos(clamp(d.y, -1.0, 1.0)) / 3.14159265);
+    return texture(environmentCubeTex, d).rgb + texture(environmentTex, equirect).rgb;
+}
+
 // beckmann
 float distribution(vec3 n, vec3 h, float roughness) {
     float m_Sq = roughness * roughness;
@@ -138,17 +129,25 @@ void main() {
     float f0 = 0.118 + metalness * 0.7; //max 0.818
 
     // normal, eye/view
+#ifdef NORMAL_MAP
     vec3 N = normalize(tbn * (texture(normalTex, tcoords0.st).rgb * 2.0 - 1.0));
+#else
+    vec3 N = normalize(tbn[2]);
+#endif
     vec3 V = normalize(cameraPosition - position);
 
     // shared products
     float NdotV = dot(N, V);
     float NdotV_clamped = max(NdotV, 0.0000000001);
 
-    int lc = int(lightCount[0]);
-    for (int i=0; i<lc; i++) {
+    // only the lights reaching this fragment's cluster
+    ivec2 cluster = lightCluster(position);
+    for (int c=0; c<cluster.y; c++) {
+        int i = clusterLight(cluster.x + c);
+
         // lightdir, halfvec
-        vec3 L = normalize(lights[i].position.xyz - position);
+        vec3 toLight = lightPosition(i).xyz - position;
+        vec3 L = normalize(toLight);
         vec3 H = normalize(L + V);
 
         float NdotL = dot(N, L);
@@ -160,11 +159,16 @@ void main() {
 
         float brdf_spec = (0.25 * fres * geom * ndf) / (NdotL_clamped * NdotV_clamped);
 
-        vec3 color_spec = NdotL_clamped * brdf_spec * lights[i].color.rgb;
-        vec3 color_diff = NdotL_clamped * diffuse_energy_ratio(f0, N, L) * albedo.rgb * lights[i].color.rgb;
+        vec3 radiance = lightColor(i).rgb * lightAttenuation(i, length(toLight));
+        vec3 color_spec = NdotL_clamped * brdf_spec * radiance;
+        vec3 color_diff = NdotL_clamped * diffuse_energy_ratio(f0, N, L) * albedo.rgb * radiance;
         color.rgb += (color_diff + color_spec) * shadow(vec4(position, 1.0), i);
     }
 
+    // environment reflection
+    vec3 R = reflect(-V, N);
+    color.rgb += environment(R) * fresnel(f0, N, V) * (1.0 - roughness) * mix(vec3(1.0), albedo.rgb, metalness);
+
     color.a = albedo.a;
     color.rgb = tonemapUncharted2(color.rgb);
     color.rgb = pow(color.rgb, vec3(1.0/2.2));
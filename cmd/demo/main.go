@@ -12,11 +12,16 @@ import (
 	_ "github.com/fcvarela/gosg/imgui/dearimgui"
 	_ "github.com/fcvarela/gosg/physics/bullet"
 	_ "github.com/fcvarela/gosg/render/opengl"
-	_ "github.com/fcvarela/gosg/resource/filesystem"
+	"github.com/fcvarela/gosg/resource/filesystem"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/golang/glog"
 )
 
+var (
+	dataPath      = flag.String("data", "./data", "Data directory")
+	watchInterval = flag.Duration("data-watch", 0, "Poll the data directory for changes at this interval and reload them, 0 disables it")
+)
+
 func init() {
 	// expose profiler
 	go func() {
@@ -33,6 +38,15 @@ func init() {
 func main() {
 	app := new(core.Application)
 
+	resources, err := filesystem.New(*dataPath)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if *watchInterval > 0 {
+		resources.Watch(*watchInterval)
+	}
+	core.GetResourceManager().SetSystem(resources)
+
 	// initialize the window, maybe show an OS-native dialogue here?
 	monitors := glfw.GetMonitors()
 	vms := monitors[0].GetVideoModes()
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// field is a member of a JSON object with the offsets of its key and value in the document
+type field struct {
+	key         string
+	value       json.RawMessage
+	keyOffset   int64
+	valueOffset int64
+}
+
+// syntaxError is a JSON error at an offset of the document
+type syntaxError struct {
+	err    error
+	offset int64
+}
+
+func (e *syntaxError) Error() string {
+	return e.err.Error()
+}
+
+// objectFields returns the members of the JSON object starting at offset in data, in document order. Errors are
+// syntaxErrors with offsets into data.
+func objectFields(data []byte, offset int64) ([]field, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, offsetError(err, offset)
+	}
+	if token != json.Delim('{') {
+		return nil, &syntaxError{errors.New("expected an object"), skipSeparators(data, offset)}
+	}
+
+	var fields []field
+	for dec.More() {
+		keyOffset := skipSeparators(data, offset+dec.InputOffset())
+		token, err := dec.Token()
+		if err != nil {
+			return nil, offsetError(err, offset)
+		}
+
+		valueOffset := skipSeparators(data, offset+dec.InputOffset())
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, offsetError(err, offset)
+		}
+
+		fields = append(fields, field{token.(string), value, keyOffset, valueOffset})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, offsetError(err, offset)
+	}
+	return fields, nil
+}
+
+// skipSeparators returns the offset of the first token at or after offset
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// offsetError returns a decoding error as a syntaxError, with the offset of syntax errors relative to the document
+func offsetError(err error, offset int64) error {
+	var jsonErr *json.SyntaxError
+	if errors.As(err, &jsonErr) {
+		return &syntaxError{err, offset + jsonErr.Offset}
+	}
+	return &syntaxError{err, offset}
+}
+
+// position returns the line and column of an offset in data
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
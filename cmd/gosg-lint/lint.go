@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/protos"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+// Severity is the severity of a diagnostic
+type Severity string
+
+const (
+	// SeverityError is a problem which makes the resource fail to load or draw incorrectly
+	SeverityError Severity = "error"
+
+	// SeverityWarning is a problem which is ignored when loading the resource
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a data directory file. Lines and columns start at 1, they are 0 for problems
+// with the file as a whole.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic as file:line:column: severity: message
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// programStages are the shader stage keys of program specifications. The linter doesn't depend on a backend,
+// TestProgramStages checks these are the stages of render/opengl.
+var programStages = []string{"compute", "tesselationControl", "tesselationEval", "vertex", "geometry", "fragment"}
+
+// programSpecFields are the fields of program specifications
+var programSpecFields = []string{"shaders", "keywords", "uniformBufferBindings", "samplerBindings"}
+
+// shaderIncludeRegexp matches the #include directives of shader sources, as resolved by core.PreprocessShader
+var shaderIncludeRegexp = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"`)
+
+// linter checks the programs and states of a data directory
+type linter struct {
+	fsys             fs.FS
+	programExtension string
+	diagnostics      []Diagnostic
+
+	// programs are the keywords declared by each program specification which parsed
+	programs map[string][]string
+
+	// shaders are the shader sources already checked
+	shaders map[string]bool
+}
+
+// Lint checks the program specifications and states of a data directory with the programs and states layout and
+// returns the problems found, sorted by file and location. Program specifications are the files in programs with
+// the given extension.
+func Lint(fsys fs.FS, programExtension string) []Diagnostic {
+	l := &linter{fsys: fsys, programExtension: programExtension, programs: make(map[string][]string), shaders: make(map[string]bool)}
+
+	for _, file := range l.files("programs", "."+programExtension) {
+		l.lintProgram(file)
+	}
+	for _, file := range l.files("states", ".json") {
+		l.lintState(file)
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+// files returns the files of a directory with the given suffix, recursively
+func (l *linter) files(dir, suffix string) []string {
+	var files []string
+	err := fs.WalkDir(l.fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, suffix) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		l.report(dir, nil, 0, SeverityWarning, "cannot list %s: %v", dir, err)
+	}
+	return files
+}
+
+// report adds a diagnostic at an offset of a file's data
+func (l *linter) report(file string, data []byte, offset int64, severity Severity, format string, args ...interface{}) {
+	d := Diagnostic{File: file, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if data != nil {
+		d.Line, d.Column = position(data, offset)
+	}
+	l.diagnostics = append(l.diagnostics, d)
+}
+
+// readObject reads a file holding a JSON object, reporting it if it can't be read or parsed
+func (l *linter) readObject(file string) ([]byte, []field, bool) {
+	data, err := fs.ReadFile(l.fsys, file)
+	if err != nil {
+		l.report(file, nil, 0, SeverityError, "cannot read: %v", err)
+		return nil, nil, false
+	}
+
+	fields, err := objectFields(data, 0)
+	if err != nil {
+		l.report(file, data, err.(*syntaxError).offset, SeverityError, "invalid JSON: %v", err)
+		return data, nil, false
+	}
+	return data, fields, true
+}
+
+// lintProgram checks a program specification: its stages and shaders, and that binding points don't collide
+func (l *linter) lintProgram(file string) {
+	data, fields, ok := l.readObject(file)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(file, "programs/"), "."+l.programExtension)
+	var keywords []string
+	hasShaders := false
+
+	for _, f := range fields {
+		switch f.key {
+		case "shaders":
+			stages, err := objectFields(data, f.valueOffset)
+			if err != nil {
+				l.report(file, data, f.valueOffset, SeverityError, "shaders must map stages to shader files")
+				continue
+			}
+			for _, stage := range stages {
+				hasShaders = true
+				if !containsString(programStages, stage.key) {
+					l.report(file, data, stage.keyOffset, SeverityError, "unknown shader stage %q, expected one of %s", stage.key, strings.Join(programStages, ", "))
+				}
+
+				var shader string
+				if err := json.Unmarshal(stage.value, &shader); err != nil {
+					l.report(file, data, stage.valueOffset, SeverityError, "%s shader must be a file name", stage.key)
+					continue
+				}
+				if !l.exists(path.Join("programs", shader)) {
+					l.report(file, data, stage.valueOffset, SeverityError, "%s shader %s not found", stage.key, shader)
+					continue
+				}
+				l.lintShader(shader)
+			}
+		case "keywords":
+			if err := json.Unmarshal(f.value, &keywords); err != nil {
+				l.report(file, data, f.valueOffset, SeverityError, "keywords must be a list of strings")
+			}
+		case "uniformBufferBindings":
+			l.lintBindings(file, data, f, "uniform buffer binding")
+		case "samplerBindings":
+			l.lintBindings(file, data, f, "texture unit")
+		default:
+			l.report(file, data, f.keyOffset, SeverityWarning, "unknown program field %q, expected one of %s", f.key, strings.Join(programSpecFields, ", "))
+		}
+	}
+
+	if !hasShaders {
+		l.report(file, data, 0, SeverityError, "program %s has no shaders", name)
+	}
+	l.programs[name] = keywords
+}
+
+// lintBindings checks that the entries of a binding object are binding points and don't share one
+func (l *linter) lintBindings(file string, data []byte, f field, kind string) {
+	bindings, err := objectFields(data, f.valueOffset)
+	if err != nil {
+		l.report(file, data, f.valueOffset, SeverityError, "%s must map names to binding points", f.key)
+		return
+	}
+
+	used := make(map[uint32]string)
+	for _, b := range bindings {
+		var point uint32
+		if err := json.Unmarshal(b.value, &point); err != nil {
+			l.report(file, data, b.valueOffset, SeverityError, "%s of %s must be a non negative integer", kind, b.key)
+			continue
+		}
+		if other, ok := used[point]; ok {
+			l.report(file, data, b.keyOffset, SeverityError, "%s and %s share %s %d", other, b.key, kind, point)
+			continue
+		}
+		used[point] = b.key
+	}
+}
+
+// lintShader checks that the files a shader includes exist, and the files they include in turn
+func (l *linter) lintShader(name string) {
+	if l.shaders[name] {
+		return
+	}
+	l.shaders[name] = true
+
+	file := path.Join("programs", name)
+	data, err := fs.ReadFile(l.fsys, file)
+	if err != nil {
+		l.report(file, nil, 0, SeverityError, "cannot read: %v", err)
+		return
+	}
+
+	offset := int64(0)
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if m := shaderIncludeRegexp.FindStringSubmatchIndex(line); m != nil {
+			include := line[m[2]:m[3]]
+			if strings.HasPrefix(include, core.BuiltinShaderPrefix) {
+				if !containsString(core.BuiltinShaders(), include) {
+					l.report(file, data, offset+int64(m[2]), SeverityError, "builtin file %s does not exist", include)
+				}
+			} else if l.exists(path.Join("programs", include)) {
+				l.lintShader(include)
+			} else {
+				l.report(file, data, offset+int64(m[2]), SeverityError, "included file %s not found", include)
+			}
+		}
+		offset += int64(len(line))
+	}
+}
+
+// lintState checks a state: that the states it builds on and its program exist, that the program declares its
+// keywords, and that its fields parse as a protos.State
+func (l *linter) lintState(file string) {
+	data, fields, ok := l.readObject(file)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(file, "states/"), ".json")
+	var programField, keywordsField *field
+
+	for i, f := range fields {
+		switch core.StateFieldName(f.key) {
+		case "extends":
+			var base string
+			if err := json.Unmarshal(f.value, &base); err != nil {
+				l.report(file, data, f.valueOffset, SeverityError, "extends must be a state name")
+				continue
+			}
+			if !l.exists(path.Join("states", base+".json")) {
+				l.report(file, data, f.valueOffset, SeverityError, "extended state %s not found", base)
+			}
+			continue
+		case "fragments":
+			var fragments []string
+			if err := json.Unmarshal(f.value, &fragments); err != nil {
+				l.report(file, data, f.valueOffset, SeverityError, "fragments must be a list of state names")
+				continue
+			}
+			for _, fragment := range fragments {
+				if !l.exists(path.Join("states", fragment+".json")) {
+					l.report(file, data, f.valueOffset, SeverityError, "fragment %s not found", fragment)
+				}
+			}
+			continue
+		case "programName":
+			programField = &fields[i]
+		case "programKeywords":
+			keywordsField = &fields[i]
+		}
+
+		object := append(append([]byte(`{"`+f.key+`":`), f.value...), '}')
+		if err := jsonpb.Unmarshal(bytes.NewReader(object), &protos.State{}); err != nil {
+			l.report(file, data, f.keyOffset, SeverityError, "invalid state field: %v", err)
+		}
+	}
+
+	if cycle := l.stateCycle(name, nil); cycle != "" {
+		l.report(file, data, 0, SeverityError, "state inheritance cycle: %s", cycle)
+		return
+	}
+
+	// check the program and keywords where the state sets them, the program may be inherited
+	if programField == nil && keywordsField == nil {
+		return
+	}
+	program, _ := l.resolveStateField(name, "programName", nil).(string)
+	if program == "" {
+		return
+	}
+
+	declared, ok := l.programs[program]
+	if !ok {
+		if programField != nil {
+			if l.exists(path.Join("programs", program+"."+l.programExtension)) {
+				return
+			}
+			l.report(file, data, programField.valueOffset, SeverityError, "program %s not found", program)
+		}
+		return
+	}
+
+	keywords, _ := l.resolveStateField(name, "programKeywords", nil).([]interface{})
+	at := programField
+	if keywordsField != nil {
+		at = keywordsField
+	}
+	for _, k := range keywords {
+		if keyword, _ := k.(string); !containsString(declared, keyword) {
+			l.report(file, data, at.valueOffset, SeverityError, "program %s does not declare keyword %v", program, k)
+		}
+	}
+}
+
+// readState returns the fields of a state by their camel case JSON names, nil if it can't be read
+func (l *linter) readState(name string) map[string]interface{} {
+	data, err := fs.ReadFile(l.fsys, path.Join("states", name+".json"))
+	if err != nil {
+		return nil
+	}
+
+	var own map[string]interface{}
+	if json.Unmarshal(data, &own) != nil {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(own))
+	for k, v := range own {
+		fields[core.StateFieldName(k)] = v
+	}
+	return fields
+}
+
+// stateBases returns the states a state is built on, in merge order
+func stateBases(fields map[string]interface{}) []string {
+	var bases []string
+	if base, ok := fields["extends"].(string); ok {
+		bases = append(bases, base)
+	}
+	fragments, _ := fields["fragments"].([]interface{})
+	for _, f := range fragments {
+		if fragment, ok := f.(string); ok {
+			bases = append(bases, fragment)
+		}
+	}
+	return bases
+}
+
+// stateCycle returns the inheritance cycle a state is part of, empty if there is none
+func (l *linter) stateCycle(name string, chain []string) string {
+	for _, n := range chain {
+		if n == name {
+			return strings.Join(append(chain, name), " -> ")
+		}
+	}
+
+	for _, base := range stateBases(l.readState(name)) {
+		if cycle := l.stateCycle(base, append(chain, name)); cycle != "" {
+			return cycle
+		}
+	}
+	return ""
+}
+
+// resolveStateField returns the value of a top level state field after merging the states it is built on, the
+// state's own value first, then the last fragment setting it, then the extended state
+func (l *linter) resolveStateField(name, key string, chain []string) interface{} {
+	for _, n := range chain {
+		if n == name {
+			return nil
+		}
+	}
+
+	fields := l.readState(name)
+	if value, ok := fields[key]; ok {
+		return value
+	}
+
+	bases := stateBases(fields)
+	for i := len(bases) - 1; i >= 0; i-- {
+		if value := l.resolveStateField(bases[i], key, append(chain, name)); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// exists returns whether a file exists in the data directory
+func (l *linter) exists(file string) bool {
+	_, err := fs.Stat(l.fsys, file)
+	return err == nil
+}
+
+// containsString returns whether list contains s
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fcvarela/gosg/render/opengl"
+)
+
+func TestLintClean(t *testing.T) {
+	fsys := fstest.MapFS{
+		"programs/lit.gl.json": {Data: []byte(`{
+    "shaders": {"vertex": "lit.vs.glsl", "fragment": "lit.fs.glsl"},
+    "keywords": ["NORMAL_MAP"],
+    "uniformBufferBindings": {"cameraConstants": 0, "nodeBlock": 1},
+    "samplerBindings": {"albedoTex": 0, "normalTex": 1}
+}`)},
+		"programs/lit.vs.glsl":        {Data: []byte("#version 410 core\n#include \"gosg/camera.glsl\"\n")},
+		"programs/lit.fs.glsl":        {Data: []byte("#version 410 core\n#include \"common/camera.glsl\"\n")},
+		"programs/common/camera.glsl": {Data: []byte("uniform mat4 vMatrix;\n")},
+		"states/opaque.json":          {Data: []byte(`{"programName": "lit", "depth_test": true, "cullFace": "CULL_BACK"}`)},
+		"states/fragments/blend.json": {Data: []byte(`{"blending": true}`)},
+		"states/mapped.json":          {Data: []byte(`{"extends": "opaque", "fragments": ["fragments/blend"], "program_keywords": ["NORMAL_MAP"]}`)},
+	}
+
+	if diagnostics := Lint(fsys, "gl.json"); len(diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics %v", diagnostics)
+	}
+}
+
+func TestLint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"programs/broken.gl.json": {Data: []byte(`{
+    "shaders": {"vertex": "broken.vs.glsl", "pixel": "broken.fs.glsl"},
+    "uniformBufferBindings": {"cameraConstants": 0, "nodeBlock": 0},
+    "samplerBindings": {"albedoTex": 2, "normalTex": 2},
+    "samplers": {}
+}`)},
+		"programs/broken.fs.glsl": {Data: []byte("#version 410 core\n\n#include \"missing.glsl\"\n#include \"gosg/shadows.glsl\"\n")},
+		"programs/syntax.gl.json": {Data: []byte("{\n    \"shaders\": {\n}")},
+		"states/a.json":           {Data: []byte(`{"extends": "b", "programName": "broken", "programKeywords": ["SKINNED"]}`)},
+		"states/b.json":           {Data: []byte(`{"extends": "a"}`)},
+		"states/fields.json": {Data: []byte(`{
+    "programName": "absent",
+    "fragments": ["nothere"],
+    "depthFunc": "DEPTH_SOMETIMES",
+    "wireframe": true
+}`)},
+	}
+
+	// messages from the JSON decoders are matched by prefix
+	expected := []Diagnostic{
+		{"programs/broken.fs.glsl", 3, 11, SeverityError, "included file missing.glsl not found"},
+		{"programs/broken.fs.glsl", 4, 11, SeverityError, "builtin file gosg/shadows.glsl does not exist"},
+		{"programs/broken.gl.json", 2, 27, SeverityError, "vertex shader broken.vs.glsl not found"},
+		{"programs/broken.gl.json", 2, 45, SeverityError, `unknown shader stage "pixel", expected one of compute, tesselationControl, tesselationEval, vertex, geometry, fragment`},
+		{"programs/broken.gl.json", 3, 53, SeverityError, "cameraConstants and nodeBlock share uniform buffer binding 0"},
+		{"programs/broken.gl.json", 4, 41, SeverityError, "albedoTex and normalTex share texture unit 2"},
+		{"programs/broken.gl.json", 5, 5, SeverityWarning, `unknown program field "samplers", expected one of shaders, keywords, uniformBufferBindings, samplerBindings`},
+		{"programs/syntax.gl.json", 3, 2, SeverityError, "invalid JSON: unexpected"},
+		{"states/a.json", 1, 1, SeverityError, "state inheritance cycle: a -> b -> a"},
+		{"states/b.json", 1, 1, SeverityError, "state inheritance cycle: b -> a -> b"},
+		{"states/fields.json", 2, 20, SeverityError, "program absent not found"},
+		{"states/fields.json", 3, 18, SeverityError, "fragment nothere not found"},
+		{"states/fields.json", 4, 5, SeverityError, "invalid state field: unknown value"},
+		{"states/fields.json", 5, 5, SeverityError, `invalid state field: unknown field "wireframe"`},
+	}
+
+	diagnostics := Lint(fsys, "gl.json")
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), diagnostics)
+	}
+	for i, d := range diagnostics {
+		e := expected[i]
+		if d.File != e.File || d.Line != e.Line || d.Column != e.Column || d.Severity != e.Severity || !strings.HasPrefix(d.Message, e.Message) {
+			t.Errorf("diagnostic %d is %v, expected %v", i, d, e)
+		}
+	}
+}
+
+func TestProgramStages(t *testing.T) {
+	stages := append([]string(nil), programStages...)
+	sort.Strings(stages)
+	if expected := opengl.ProgramStages(); !reflect.DeepEqual(stages, expected) {
+		t.Errorf("linting shader stages %v, the backend accepts %v", stages, expected)
+	}
+}
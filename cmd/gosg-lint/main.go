@@ -0,0 +1,51 @@
+// Command gosg-lint checks the program specifications and states of a data directory without a GPU. It reports
+// shader stages which don't exist, missing shaders and included files, binding points shared by two uniform
+// buffers or samplers, states whose program, extended states or fragments don't exist, program keywords the
+// program doesn't declare and state fields which don't parse as a protos.State.
+//
+// Diagnostics are printed one per line as file:line:column: severity: message, or as JSON objects with -json.
+// The exit status is 1 if there are errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var (
+	dataPath         = flag.String("data", "./data", "Data directory")
+	programExtension = flag.String("program-extension", "gl.json", "Extension of program specifications")
+	jsonOutput       = flag.Bool("json", false, "Print diagnostics as JSON objects, one per line")
+	warnings         = flag.Bool("warnings", true, "Print warnings")
+)
+
+func main() {
+	flag.Parse()
+
+	if _, err := os.Stat(*dataPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	errors := 0
+	enc := json.NewEncoder(os.Stdout)
+	for _, d := range Lint(os.DirFS(*dataPath), *programExtension) {
+		if d.Severity == SeverityError {
+			errors++
+		} else if !*warnings {
+			continue
+		}
+
+		if *jsonOutput {
+			enc.Encode(d)
+		} else {
+			fmt.Println(d)
+		}
+	}
+
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
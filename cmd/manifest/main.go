@@ -0,0 +1,91 @@
+// Command manifest writes the manifest of a data directory, listing every resource with its size, SHA-256 hash and
+// dependencies. With -verify it compares the data directory against an existing manifest instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fcvarela/gosg/resource/manifest"
+)
+
+var (
+	dataPath         = flag.String("data", "./data", "Data directory")
+	output           = flag.String("o", "", "Output file, defaults to stdout")
+	programExtension = flag.String("program-extension", "gl.json", "Extension of program specifications")
+	verify           = flag.String("verify", "", "Manifest to verify the data directory against")
+)
+
+func main() {
+	flag.Parse()
+
+	m, err := manifest.Generate(os.DirFS(*dataPath), *programExtension)
+	if err != nil {
+		fatal(err)
+	}
+
+	if *verify != "" {
+		if err := verifyManifest(m, *verify); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	data, err := m.Marshal()
+	if err != nil {
+		fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if err := ioutil.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatal(err)
+	}
+}
+
+// verifyManifest reports resources which were added, removed or changed since the manifest was written
+func verifyManifest(current *manifest.Manifest, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	expected, err := manifest.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+
+	mismatches := 0
+	for _, e := range expected.Resources {
+		c, ok := current.Lookup(e.Type, e.Name)
+		switch {
+		case !ok:
+			fmt.Printf("missing %s %s\n", e.Type, e.Name)
+		case c.SHA256 != e.SHA256 || c.Size != e.Size:
+			fmt.Printf("changed %s %s\n", e.Type, e.Name)
+		default:
+			continue
+		}
+		mismatches++
+	}
+	for _, c := range current.Resources {
+		if _, ok := expected.Lookup(c.Type, c.Name); !ok {
+			fmt.Printf("unlisted %s %s\n", c.Type, c.Name)
+			mismatches++
+		}
+	}
+
+	if mismatches > 0 {
+		return fmt.Errorf("%d resources don't match %s", mismatches, filename)
+	}
+	return nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
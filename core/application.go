@@ -90,6 +90,9 @@ func (app *Application) update(dt float64) {
 		command.Run(app.client)
 	}
 
+	// upload asynchronously loaded resources
+	resourceManager.update()
+
 	// call game object updates
 	sceneManager.update(dt)
 
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"github.com/fcvarela/gosg/atlas"
+)
+
+// NewAtlasTextures creates one texture per atlas page. The descriptor provides sampling parameters, dimensions
+// and formats are taken from the pages. Nodes using regions of the same page share a texture and batch together.
+func NewAtlasTextures(a *atlas.Atlas, d TextureDescriptor) []Texture {
+	textures := make([]Texture, len(a.Pages))
+	for i, p := range a.Pages {
+		pd := d
+		pd.Width = uint32(p.Image.Rect.Dx())
+		pd.Height = uint32(p.Image.Rect.Dy())
+		pd.Depth = 0
+		pd.Target = TextureTarget2D
+		pd.Format = TextureFormatRGBA
+		pd.SizedFormat = TextureSizedFormatRGBA8
+		pd.ComponentType = TextureComponentTypeUNSIGNEDBYTE
+		textures[i] = renderSystem.NewTexture(pd, p.Image.Pix)
+	}
+	return textures
+}
+
+// RemapTextureCoordinates maps a mesh's texture coordinates from its source image into an atlas region. Meshes
+// which were already drawn can be remapped, their texture coordinates are replaced in place.
+func RemapTextureCoordinates(m Mesh, r atlas.Region) {
+	tcoords := append([]float32(nil), m.TextureCoordinates()...)
+	r.Remap(tcoords, 3)
+	m.SetTextureCoordinates(tcoords)
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"image"
+	"testing"
+
+	"github.com/fcvarela/gosg/atlas"
+)
+
+func TestAtlasTextures(t *testing.T) {
+	setupTestSystems()
+
+	a, err := atlas.Build(map[string]image.Image{
+		"a": image.NewRGBA(image.Rect(0, 0, 8, 8)),
+		"b": image.NewRGBA(image.Rect(0, 0, 4, 4)),
+	}, atlas.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	textures := NewAtlasTextures(a, TextureDescriptor{Filter: TextureFilterLinear})
+	if len(textures) != 1 {
+		t.Fatalf("expected a single page texture, got %d", len(textures))
+	}
+	if d := textures[0].Descriptor(); d.Width != uint32(a.Pages[0].Image.Rect.Dx()) || d.Filter != TextureFilterLinear {
+		t.Errorf("unexpected descriptor %+v", d)
+	}
+
+	mesh := renderSystem.NewMesh()
+	mesh.SetTextureCoordinates([]float32{0, 0, 0, 1, 1, 0})
+	RemapTextureCoordinates(mesh, a.Regions["b"])
+
+	uv := a.Regions["b"].UV
+	if tc := mesh.TextureCoordinates(); tc[0] != uv[0] || tc[1] != uv[1] || tc[3] != uv[2] || tc[4] != uv[3] {
+		t.Errorf("unexpected coordinates %v for region %v", tc, uv)
+	}
+}
@@ -3,7 +3,6 @@ package core
 import (
 	"math"
 	"runtime"
-	"unsafe"
 
 	"github.com/fcvarela/gosg/protos"
 	"github.com/go-gl/mathgl/mgl32"
@@ -64,6 +63,7 @@ type Camera struct {
 	cascadingZCuts     [maxCascades]float64
 	constants          *CameraConstants
 	renderTechnique    RenderStageFn
+	sky                *Sky
 	stateBuckets       map[*protos.State][]*Node
 	visibleOpaqueNodes []*Node
 }
@@ -300,6 +300,18 @@ func (c *Camera) SetClearMode(cm RenderTargetClearMode) {
 	c.clearMode = cm
 }
 
+// Sky returns the camera's sky, or nil if it only clears to its clear color.
+func (c *Camera) Sky() *Sky {
+	return c.sky
+}
+
+// SetSky sets the camera's sky. It is drawn after opaque geometry wherever the depth buffer is still at the far
+// plane, and its texture, if any, is exposed to the programs rendered by this camera. Pass nil to remove it.
+func (c *Camera) SetSky(s *Sky) {
+	c.sky = s
+	c.constants.setEnvironment(s)
+}
+
 // RenderTarget returns the camera's render target.
 func (c *Camera) RenderTarget() Framebuffer {
 	return c.framebuffer
@@ -330,51 +342,110 @@ func (c *Camera) SetRenderTechnique(r RenderStageFn) {
 	c.renderTechnique = r
 }
 
-type innerConstants struct {
-	ViewMatrix           mgl32.Mat4
-	ProjectionMatrix     mgl32.Mat4
-	ViewProjectionMatrix mgl32.Mat4
-	LightCount           mgl32.Vec4
-	LightBlocks          [16]LightBlock
-}
+// CameraConstantsBlock is the layout of the cameraConstants uniform block. Shaders declare it by including
+// gosg/camera.glsl. lightClusters holds the camera's cluster grid and whether its slices are exponential,
+// lightClusterDepth the scale and bias mapping view depths to slices.
+var CameraConstantsBlock = NewStd140Block("cameraConstants",
+	Std140Member{Name: "vMatrix", Type: UniformTypeMat4},
+	Std140Member{Name: "pMatrix", Type: UniformTypeMat4},
+	Std140Member{Name: "vpMatrix", Type: UniformTypeMat4},
+	Std140Member{Name: "lightCount", Type: UniformTypeVec4},
+	Std140Member{Name: "lightClusters", Type: UniformTypeVec4},
+	Std140Member{Name: "lightClusterDepth", Type: UniformTypeVec4},
+)
+
+var (
+	cameraViewMatrix           = CameraConstantsBlock.Member("vMatrix")
+	cameraProjectionMatrix     = CameraConstantsBlock.Member("pMatrix")
+	cameraViewProjectionMatrix = CameraConstantsBlock.Member("vpMatrix")
+	cameraLightCount           = CameraConstantsBlock.Member("lightCount")
+	cameraLightClusters        = CameraConstantsBlock.Member("lightClusters")
+	cameraLightClusterDepth    = CameraConstantsBlock.Member("lightClusterDepth")
+)
 
 // CameraConstants holds a uniform buffer passed to all programs which contains global camera transforms
-// and a list of active lights.
+// and the shape of the camera's light clusters, and the textures holding its lights and sky environment.
 type CameraConstants struct {
-	inner  innerConstants
-	buffer UniformBuffer
+	data     Std140Buffer
+	buffer   UniformBuffer
+	clusters *lightClusters
+	textures map[string]Texture
 }
 
-var (
-	lightBlockLen = (maxCascades*16 + maxCascades*4 + 4 + 4)
-	// 16 lights * 16 floats per light + 4 floats lightcount, all mult4 (sizeof float)
-	sceneBlockLen = (3*16 + 16*lightBlockLen + 4) * 4
-)
-
 // NewCameraConstants returns a new CameraConstants. The UniformBuffer is returned by the rendersystem.
 func NewCameraConstants() *CameraConstants {
-	return &CameraConstants{innerConstants{}, renderSystem.NewUniformBuffer()}
+	return &CameraConstants{
+		data:     NewStd140Buffer(CameraConstantsBlock),
+		buffer:   renderSystem.NewUniformBuffer(),
+		clusters: newLightClusters(DefaultLightClusterConfig),
+		textures: make(map[string]Texture),
+	}
+}
+
+// LightClusterConfig returns the configuration of the light clusters
+func (sb *CameraConstants) LightClusterConfig() LightClusterConfig {
+	return sb.clusters.config
 }
 
-// SetData sets matrices and light information for the entire scene
+// SetLightClusterConfig sets the configuration of the light clusters. Invalid configurations are logged and
+// ignored.
+func (sb *CameraConstants) SetLightClusterConfig(config LightClusterConfig) {
+	if err := config.validate(); err != nil {
+		glog.Error(err)
+		return
+	}
+
+	sb.clusters.delete()
+	sb.clusters = newLightClusters(config)
+	for _, name := range []string{LightsSampler, LightClustersSampler, LightIndicesSampler} {
+		delete(sb.textures, name)
+	}
+}
+
+// SetData sets matrices and light information for the entire scene. Lights are assigned to the clusters of the
+// view frustum, lights beyond the configured maximum are dropped.
 func (sb *CameraConstants) SetData(pMatrix, vMatrix mgl64.Mat4, l []*Light) {
 	// matrices
-	sb.inner.ViewMatrix = Mat4DoubleToFloat(vMatrix)
-	sb.inner.ProjectionMatrix = Mat4DoubleToFloat(pMatrix)
-	sb.inner.ViewProjectionMatrix = Mat4DoubleToFloat(pMatrix.Mul4(vMatrix))
-
-	// lights
-	sb.inner.LightCount = mgl32.Vec4{0.0, 0.0, 0.0, 0.0}
-	for i := 0; i < len(l) && i < len(sb.inner.LightBlocks); i++ {
-		sb.inner.LightCount = sb.inner.LightCount.Add(mgl32.Vec4{1.0, 1.0, 1.0, 1.0})
-		sb.inner.LightBlocks[i] = l[i].Block
+	sb.data.SetMat4(cameraViewMatrix, Mat4DoubleToFloat(vMatrix))
+	sb.data.SetMat4(cameraProjectionMatrix, Mat4DoubleToFloat(pMatrix))
+	sb.data.SetMat4(cameraViewProjectionMatrix, Mat4DoubleToFloat(pMatrix.Mul4(vMatrix)))
+
+	// lights, programs skip the cluster textures without any
+	c := sb.clusters
+	c.assign(pMatrix, vMatrix, l)
+	if c.lightCount > 0 {
+		c.upload(l)
+		for name, texture := range c.textures {
+			sb.textures[name] = texture
+		}
 	}
 
-	// copy to buffer
-	inner := sb.inner
+	n := float32(c.lightCount)
+	sb.data.SetVec4(cameraLightCount, mgl32.Vec4{n, n, n, n})
 
-	// set the constant buffer pointer and length
-	sb.buffer.Set(unsafe.Pointer(&inner), sceneBlockLen)
+	perspective := float32(0.0)
+	if c.perspective {
+		perspective = 1.0
+	}
+	sb.data.SetVec4(cameraLightClusters, mgl32.Vec4{float32(c.config.TilesX), float32(c.config.TilesY), float32(c.config.Slices), perspective})
+	sb.data.SetVec4(cameraLightClusterDepth, mgl32.Vec4{float32(c.scale), float32(c.bias), float32(c.near), float32(c.far)})
+
+	sb.buffer.Set(sb.data.Pointer(), len(sb.data))
+}
+
+// Textures returns the camera's textures by sampler name: the textures holding its lights and their clusters once
+// lights are set, and its sky's environment texture if it has one. Programs bind them with their sampler bindings.
+func (sb *CameraConstants) Textures() map[string]Texture {
+	return sb.textures
+}
+
+// setEnvironment exposes a sky's texture under its environment sampler name, removing the previous sky's
+func (sb *CameraConstants) setEnvironment(s *Sky) {
+	delete(sb.textures, EnvironmentCubemapSampler)
+	delete(sb.textures, EnvironmentMapSampler)
+	if s != nil && s.texture != nil {
+		sb.textures[s.samplerName()] = s.texture
+	}
 }
 
 // UniformBuffer returns the camera constants uniform buffer
@@ -25,8 +25,9 @@ func (cc *DefaultCuller) Run(scene *Scene, camera *Camera, node *Node) {
 
 	// the default implementation is to add ourselves to the bucket
 	if node.mesh != nil {
-		camera.stateBuckets[node.state] = append(camera.stateBuckets[node.state], node)
-		if !node.state.Blending {
+		state := node.State()
+		camera.stateBuckets[state] = append(camera.stateBuckets[state], node)
+		if !state.Blending {
 			camera.visibleOpaqueNodes = append(camera.visibleOpaqueNodes, node)
 		}
 	}
@@ -43,7 +44,8 @@ type AlwaysPassCuller struct{}
 func (apcc *AlwaysPassCuller) Run(scene *Scene, camera *Camera, node *Node) {
 	// the default implementation is to add ourselves to the bucket
 	if node.mesh != nil {
-		camera.stateBuckets[node.state] = append(camera.stateBuckets[node.state], node)
+		state := node.State()
+		camera.stateBuckets[state] = append(camera.stateBuckets[state], node)
 	}
 
 	for _, ch := range node.children {
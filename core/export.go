@@ -0,0 +1,167 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/fcvarela/gosg/protos"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/golang/protobuf/proto"
+)
+
+// TransformSpace selects how an exported subtree root is placed.
+type TransformSpace uint8
+
+const (
+	// TransformSpaceLocal exports the subtree relative to its root node, the root's own transform is dropped.
+	TransformSpaceLocal TransformSpace = iota
+
+	// TransformSpaceWorld exports the subtree with the root's world transform applied.
+	TransformSpaceWorld
+)
+
+// ExportOptions configures the model exporters.
+type ExportOptions struct {
+	Space TransformSpace
+}
+
+// materialTextureNames maps the sampler names used by LoadModel to protos.Mesh texture slots.
+var materialTextureNames = []string{"albedoTex", "normalTex", "roughTex", "metalTex"}
+
+// rootTransform returns the transform applied to the subtree root for the given options.
+func (o ExportOptions) rootTransform(root *Node) mgl64.Mat4 {
+	if o.Space == TransformSpaceWorld {
+		return root.WorldTransform()
+	}
+	return mgl64.Ident4()
+}
+
+// ExportModel flattens a node subtree into a protos.Model. The format has no hierarchy, so each mesh has
+// its node transform (relative to the root, or in world space) baked into its vertex data. Only triangle
+// meshes are exported, nodes without meshes only contribute their transforms.
+func ExportModel(root *Node, opts ExportOptions) (*protos.Model, error) {
+	if root == nil {
+		return nil, errors.New("cannot export nil node")
+	}
+
+	model := &protos.Model{}
+	var walk func(n *Node, transform mgl64.Mat4)
+	walk = func(n *Node, transform mgl64.Mat4) {
+		if n.mesh != nil && n.mesh.PrimitiveType() == PrimitiveTypeTriangles {
+			model.Meshes = append(model.Meshes, exportMesh(n, transform))
+		}
+		for _, c := range n.children {
+			walk(c, transform.Mul4(c.transform))
+		}
+	}
+	walk(root, opts.rootTransform(root))
+
+	return model, nil
+}
+
+// WriteModel exports a node subtree with ExportModel and writes the marshalled protos.Model to w.
+func WriteModel(w io.Writer, root *Node, opts ExportOptions) error {
+	model, err := ExportModel(root, opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := proto.Marshal(model)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func exportMesh(n *Node, transform mgl64.Mat4) *protos.Mesh {
+	m := n.mesh
+	normalMatrix := transform.Mat3().Inv().Transpose()
+
+	out := &protos.Mesh{
+		Name:       m.Name(),
+		Indices:    shortToBytes(m.Indices()),
+		Positions:  floatToBytes(transformPoints(m.Positions(), transform)),
+		Normals:    floatToBytes(transformDirections(m.Normals(), normalMatrix)),
+		Tangents:   floatToBytes(transformDirections(m.Tangents(), transform.Mat3())),
+		Bitangents: floatToBytes(transformDirections(m.Bitangents(), transform.Mat3())),
+		Tcoords:    floatToBytes(m.TextureCoordinates()),
+	}
+
+	if state := n.State(); state != nil {
+		out.State = state.Name
+	}
+
+	textures := n.materialData.Textures()
+	out.AlbedoMap = textureImageData(textures[materialTextureNames[0]])
+	out.NormalMap = textureImageData(textures[materialTextureNames[1]])
+	out.RoughMap = textureImageData(textures[materialTextureNames[2]])
+	out.MetalMap = textureImageData(textures[materialTextureNames[3]])
+
+	return out
+}
+
+func textureImageData(t Texture) []byte {
+	if t == nil {
+		return nil
+	}
+	return t.ImageData()
+}
+
+// transformPoints returns a copy of xyz triplets transformed as points by m.
+func transformPoints(p []float32, m mgl64.Mat4) []float32 {
+	if p == nil || m == mgl64.Ident4() {
+		return p
+	}
+
+	out := make([]float32, len(p))
+	for i := 0; i+2 < len(p); i += 3 {
+		v := mgl64.TransformCoordinate(mgl64.Vec3{float64(p[i]), float64(p[i+1]), float64(p[i+2])}, m)
+		out[i], out[i+1], out[i+2] = float32(v[0]), float32(v[1]), float32(v[2])
+	}
+	return out
+}
+
+// transformDirections returns a copy of xyz triplets transformed by m and renormalized.
+func transformDirections(d []float32, m mgl64.Mat3) []float32 {
+	if d == nil || m == mgl64.Ident3() {
+		return d
+	}
+
+	out := make([]float32, len(d))
+	for i := 0; i+2 < len(d); i += 3 {
+		v := m.Mul3x1(mgl64.Vec3{float64(d[i]), float64(d[i+1]), float64(d[i+2])})
+		if l := v.Len(); l > 0 {
+			v = v.Mul(1.0 / l)
+		}
+		out[i], out[i+1], out[i+2] = float32(v[0]), float32(v[1]), float32(v[2])
+	}
+	return out
+}
+
+func floatToBytes(f []float32) []byte {
+	if len(f) == 0 {
+		return nil
+	}
+
+	data := make([]byte, len(f)*4)
+	for i := range f {
+		binary.LittleEndian.PutUint32(data[i*4:(i+1)*4], math.Float32bits(f[i]))
+	}
+	return data
+}
+
+func shortToBytes(s []uint16) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+
+	data := make([]byte, len(s)*2)
+	for i := range s {
+		binary.LittleEndian.PutUint16(data[i*2:(i+1)*2], s[i])
+	}
+	return data
+}
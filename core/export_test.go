@@ -0,0 +1,248 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fcvarela/gosg/protos"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// testMeshNode returns a node drawing a textured triangle with the test state
+func testMeshNode(name string, texture Texture) *Node {
+	n := NewNode(name)
+	n.state = resourceManager.State("test-state")
+	n.materialData.SetTexture("albedoTex", texture)
+
+	m := renderSystem.NewMesh()
+	m.SetName(name)
+	m.SetPositions([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	m.SetNormals([]float32{0, 0, 1, 0, 0, 1, 0, 0, 1})
+	m.SetTangents([]float32{1, 0, 0, 1, 0, 0, 1, 0, 0})
+	m.SetBitangents([]float32{0, -1, 0, 0, -1, 0, 0, -1, 0})
+	m.SetTextureCoordinates([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	m.SetIndices([]uint16{0, 1, 2})
+	m.SetPrimitiveType(PrimitiveTypeTriangles)
+	n.SetMesh(m)
+	return n
+}
+
+// testSubtree returns a translated root with a rotated mesh child which has a scaled mesh grandchild
+func testSubtree(t *testing.T) *Node {
+	texture := renderSystem.NewTextureFromImageData(testPNG(t), TextureDescriptor{})
+
+	root := NewNode("root")
+	root.Translate(mgl64.Vec3{10, 0, 0})
+
+	child := testMeshNode("child", texture)
+	child.Rotate(90, mgl64.Vec3{0, 0, 1})
+	root.AddChild(child)
+
+	grandchild := testMeshNode("grandchild", texture)
+	grandchild.Scale(mgl64.Vec3{2, 2, 2})
+	child.AddChild(grandchild)
+
+	return root
+}
+
+func floatsEqual(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func compareMeshes(t *testing.T, name string, got, want Mesh) {
+	if !floatsEqual(got.Positions(), want.Positions()) {
+		t.Errorf("%s: positions %v, want %v", name, got.Positions(), want.Positions())
+	}
+	if !floatsEqual(got.Normals(), want.Normals()) {
+		t.Errorf("%s: normals %v, want %v", name, got.Normals(), want.Normals())
+	}
+	if !floatsEqual(got.Tangents(), want.Tangents()) {
+		t.Errorf("%s: tangents %v, want %v", name, got.Tangents(), want.Tangents())
+	}
+	if !floatsEqual(got.Bitangents(), want.Bitangents()) {
+		t.Errorf("%s: bitangents %v, want %v", name, got.Bitangents(), want.Bitangents())
+	}
+	if !floatsEqual(got.TextureCoordinates(), want.TextureCoordinates()) {
+		t.Errorf("%s: texture coordinates %v, want %v", name, got.TextureCoordinates(), want.TextureCoordinates())
+	}
+	if len(got.Indices()) != len(want.Indices()) {
+		t.Errorf("%s: indices %v, want %v", name, got.Indices(), want.Indices())
+	}
+}
+
+func TestGLBRoundTrip(t *testing.T) {
+	setupTestSystems()
+
+	for _, space := range []TransformSpace{TransformSpaceLocal, TransformSpaceWorld} {
+		root := testSubtree(t)
+
+		var buf bytes.Buffer
+		if err := WriteGLB(&buf, root, ExportOptions{Space: space}); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadGLB("test.glb", buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(loaded.Children()) != 1 {
+			t.Fatalf("expected a single scene root, got %d", len(loaded.Children()))
+		}
+
+		loadedRoot := loaded.Children()[0]
+		wantRootTransform := mgl64.Ident4()
+		if space == TransformSpaceWorld {
+			wantRootTransform = root.WorldTransform()
+		}
+		if !loadedRoot.Transform().ApproxEqual(wantRootTransform) {
+			t.Errorf("root transform %v, want %v", loadedRoot.Transform(), wantRootTransform)
+		}
+
+		child, loadedChild := root.Children()[0], loadedRoot.Children()[0]
+		grandchild, loadedGrandchild := child.Children()[0], loadedChild.Children()[0]
+
+		for _, pair := range [][2]*Node{{child, loadedChild}, {grandchild, loadedGrandchild}} {
+			if pair[1].Name() != pair[0].Name() {
+				t.Errorf("node name %s, want %s", pair[1].Name(), pair[0].Name())
+			}
+			if !pair[1].Transform().ApproxEqual(pair[0].Transform()) {
+				t.Errorf("%s: transform %v, want %v", pair[0].Name(), pair[1].Transform(), pair[0].Transform())
+			}
+			compareMeshes(t, pair[0].Name(), pair[1].Mesh(), pair[0].Mesh())
+
+			if pair[1].State() == nil || pair[1].State().Name != "test-state" {
+				t.Errorf("%s: state not restored", pair[0].Name())
+			}
+
+			texture, ok := pair[1].MaterialData().Textures()["albedoTex"]
+			if !ok || !bytes.Equal(texture.ImageData(), pair[0].MaterialData().Textures()["albedoTex"].ImageData()) {
+				t.Errorf("%s: albedo texture not restored", pair[0].Name())
+			}
+		}
+	}
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	setupTestSystems()
+
+	root := testSubtree(t)
+	child := root.Children()[0]
+	grandchild := child.Children()[0]
+
+	for _, space := range []TransformSpace{TransformSpaceLocal, TransformSpaceWorld} {
+		var buf bytes.Buffer
+		if err := WriteModel(&buf, root, ExportOptions{Space: space}); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadModel("test.model", buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded.Children()) != 2 {
+			t.Fatalf("expected 2 meshes, got %d", len(loaded.Children()))
+		}
+
+		base := mgl64.Ident4()
+		if space == TransformSpaceWorld {
+			base = root.WorldTransform()
+		}
+
+		// the model format is flat, transforms are baked into the vertex data
+		for i, transform := range []mgl64.Mat4{
+			base.Mul4(child.Transform()),
+			base.Mul4(child.Transform()).Mul4(grandchild.Transform()),
+		} {
+			want := transformPoints(child.Mesh().Positions(), transform)
+			got := loaded.Children()[i].Mesh().Positions()
+			if !floatsEqual(got, want) {
+				t.Errorf("mesh %d: positions %v, want %v", i, got, want)
+			}
+
+			if !floatsEqual(loaded.Children()[i].Mesh().TextureCoordinates(), child.Mesh().TextureCoordinates()) {
+				t.Errorf("mesh %d: texture coordinates not preserved", i)
+			}
+
+			if loaded.Children()[i].State().Name != "test-state" {
+				t.Errorf("mesh %d: state not restored", i)
+			}
+		}
+	}
+}
+
+func TestExportModelSkipsNonTriangles(t *testing.T) {
+	setupTestSystems()
+
+	root := NewNode("root")
+	root.state = &protos.State{Name: "lines"}
+	root.SetMesh(NewAABBMesh())
+
+	model, err := ExportModel(root, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 0 {
+		t.Errorf("expected line meshes to be skipped, got %d meshes", len(model.Meshes))
+	}
+}
+
+// testGLB frames a glTF document and binary chunk as a .glb file
+func testGLB(doc string, bin []byte) []byte {
+	for len(doc)%4 != 0 {
+		doc += " "
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint32{glbMagic, glbVersion, uint32(28 + len(doc) + len(bin)), uint32(len(doc)), glbChunkJSON})
+	buf.WriteString(doc)
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(bin)), glbChunkBIN})
+	buf.Write(bin)
+	return buf.Bytes()
+}
+
+func TestLoadGLBMalformed(t *testing.T) {
+	setupTestSystems()
+
+	const views = `"bufferViews": [{"buffer": 0, "byteLength": 12}, {"buffer": 0, "byteOffset": 12, "byteLength": 4}]`
+	const scene = `"scenes": [{"nodes": [0]}], "nodes": [{"mesh": 0}], "meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}]`
+	bin := make([]byte, 16)
+
+	for name, accessors := range map[string]string{
+		"index count":     `[{"bufferView": 0, "componentType": 5126, "count": 1, "type": "VEC3"}, {"bufferView": 0, "componentType": 5123, "count": 100, "type": "SCALAR"}]`,
+		"index offset":    `[{"bufferView": 0, "componentType": 5126, "count": 1, "type": "VEC3"}, {"bufferView": 1, "byteOffset": 8, "componentType": 5125, "count": 1, "type": "SCALAR"}]`,
+		"negative offset": `[{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 1, "type": "VEC3"}, {"bufferView": 1, "componentType": 5121, "count": 1, "type": "SCALAR"}]`,
+		"position count":  `[{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3"}, {"bufferView": 1, "componentType": 5121, "count": 1, "type": "SCALAR"}]`,
+		"negative count":  `[{"bufferView": 0, "componentType": 5126, "count": -1, "type": "VEC3"}, {"bufferView": 1, "componentType": 5121, "count": 1, "type": "SCALAR"}]`,
+		"index type":      `[{"bufferView": 0, "componentType": 5126, "count": 1, "type": "VEC3"}, {"bufferView": 1, "componentType": 5126, "count": 1, "type": "SCALAR"}]`,
+	} {
+		doc := fmt.Sprintf(`{"asset": {"version": "2.0"}, "buffers": [{"byteLength": 16}], %s, "accessors": %s, %s}`, views, accessors, scene)
+		if _, err := LoadGLB("malformed.glb", testGLB(doc, bin)); err == nil || !strings.HasPrefix(err.Error(), "glb:") {
+			t.Errorf("%s: expected a glb error, got %v", name, err)
+		}
+	}
+
+	// corrupt images fail the load instead of the process
+	doc := fmt.Sprintf(`{"asset": {"version": "2.0"}, "buffers": [{"byteLength": 16}], %s, "images": [{"bufferView": 0, "mimeType": "image/png"}], "textures": [{"source": 0}]}`, views)
+	if _, err := LoadGLB("corrupt.glb", testGLB(doc, bin)); err == nil || !strings.HasPrefix(err.Error(), "glb:") {
+		t.Errorf("expected a glb error for a corrupt image, got %v", err)
+	}
+
+	// a well formed file still loads
+	accessors := `[{"bufferView": 0, "componentType": 5126, "count": 1, "type": "VEC3"}, {"bufferView": 1, "componentType": 5123, "count": 2, "type": "SCALAR"}]`
+	doc = fmt.Sprintf(`{"asset": {"version": "2.0"}, "buffers": [{"byteLength": 16}], %s, "accessors": %s, %s}`, views, accessors, scene)
+	if _, err := LoadGLB("valid.glb", testGLB(doc, bin)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// testMesh is a cpu only core.Mesh
+type testMesh struct {
+	name          string
+	primitiveType PrimitiveType
+	positions     []float32
+	normals       []float32
+	tangents      []float32
+	bitangents    []float32
+	tcoords       []float32
+	indices       []uint16
+	bounds        *AABB
+	deleted       bool
+}
+
+func (m *testMesh) SetPrimitiveType(t PrimitiveType) { m.primitiveType = t }
+func (m *testMesh) PrimitiveType() PrimitiveType     { return m.primitiveType }
+func (m *testMesh) SetPositions(p []float32) {
+	m.positions = p
+	for i := 0; i+2 < len(p); i += 3 {
+		m.bounds.ExtendWithPoint(mgl64.Vec3{float64(p[i]), float64(p[i+1]), float64(p[i+2])})
+	}
+}
+func (m *testMesh) SetNormals(n []float32)            { m.normals = n }
+func (m *testMesh) SetTangents(t []float32)           { m.tangents = t }
+func (m *testMesh) SetBitangents(b []float32)         { m.bitangents = b }
+func (m *testMesh) SetTextureCoordinates(t []float32) { m.tcoords = t }
+func (m *testMesh) SetIndices(i []uint16)             { m.indices = i }
+func (m *testMesh) SetInstanceCount(int)              {}
+func (m *testMesh) SetModelMatrices([]float32)        {}
+func (m *testMesh) Positions() []float32              { return m.positions }
+func (m *testMesh) Normals() []float32                { return m.normals }
+func (m *testMesh) Tangents() []float32               { return m.tangents }
+func (m *testMesh) Bitangents() []float32             { return m.bitangents }
+func (m *testMesh) TextureCoordinates() []float32     { return m.tcoords }
+func (m *testMesh) Indices() []uint16                 { return m.indices }
+func (m *testMesh) SetName(name string)               { m.name = name }
+func (m *testMesh) Name() string                      { return m.name }
+func (m *testMesh) Draw()                             {}
+func (m *testMesh) Delete()                           { m.deleted = true }
+func (m *testMesh) Bounds() *AABB                     { return m.bounds }
+func (m *testMesh) Lt(o Mesh) bool                    { return m.name < o.(*testMesh).name }
+func (m *testMesh) Gt(o Mesh) bool                    { return m.name > o.(*testMesh).name }
+
+// testTexture is a cpu only core.Texture
+type testTexture struct {
+	descriptor TextureDescriptor
+	imageData  []byte
+	deleted    bool
+}
+
+func (t *testTexture) Descriptor() TextureDescriptor { return t.descriptor }
+func (t *testTexture) Handle() unsafe.Pointer        { return unsafe.Pointer(t) }
+func (t *testTexture) SetLayer(int, int, []byte)     {}
+func (t *testTexture) GenerateMipmaps()              {}
+func (t *testTexture) SetImage(img *DecodedImage) {
+	t.descriptor, t.imageData = img.Descriptor, img.Source
+}
+func (t *testTexture) ImageData() []byte { return t.imageData }
+func (t *testTexture) Delete()           { t.deleted = true }
+func (t *testTexture) Lt(o Texture) bool { return uintptr(t.Handle()) < uintptr(o.Handle()) }
+func (t *testTexture) Gt(o Texture) bool { return uintptr(t.Handle()) > uintptr(o.Handle()) }
+
+// testProgram is a core.Program without GPU resources. Its active uniforms are declared in the program data, one
+// "uniform <type> <name>" per line.
+type testProgram struct {
+	name     string
+	keywords []string
+	deleted  bool
+	uniforms map[string]UniformInfo
+}
+
+func newTestProgram(name string, data []byte) *testProgram {
+	p := &testProgram{name: name, uniforms: make(map[string]UniformInfo)}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "uniform" {
+			continue
+		}
+		for t := UniformTypeFloat; t <= UniformTypeUint; t++ {
+			if t.String() == fields[1] {
+				p.uniforms[fields[2]] = UniformInfo{Name: fields[2], Type: t, Size: 1}
+			}
+		}
+	}
+	return p
+}
+
+func (p *testProgram) Name() string { return p.name }
+func (p *testProgram) Delete()      { p.deleted = true }
+func (p *testProgram) Uniform(name string) (UniformInfo, bool) {
+	u, ok := p.uniforms[name]
+	return u, ok
+}
+func (p *testProgram) Uniforms() []UniformInfo {
+	uniforms := make([]UniformInfo, 0, len(p.uniforms))
+	for _, u := range p.uniforms {
+		uniforms = append(uniforms, u)
+	}
+	sort.Slice(uniforms, func(i, j int) bool { return uniforms[i].Name < uniforms[j].Name })
+	return uniforms
+}
+func (p *testProgram) UniformBlocks() []UniformBlockInfo { return nil }
+func (p *testProgram) Samplers() []SamplerInfo           { return nil }
+func (p *testProgram) Attributes() []AttributeInfo       { return nil }
+func (p *testProgram) CompileLog() string                { return "" }
+func (p *testProgram) LinkLog() string                   { return "" }
+
+// testRenderSystem is a core.RenderSystem which never touches a GPU
+type testRenderSystem struct{}
+
+func (r *testRenderSystem) Start()                               {}
+func (r *testRenderSystem) Stop()                                {}
+func (r *testRenderSystem) MakeWindow(WindowConfig) *glfw.Window { return nil }
+func (r *testRenderSystem) NewMesh() Mesh                        { return &testMesh{bounds: NewAABB()} }
+func (r *testRenderSystem) NewIMGUIMesh() IMGUIMesh              { return r.NewMesh() }
+func (r *testRenderSystem) ProgramExtension() string             { return "test.json" }
+func (r *testRenderSystem) NewProgram(name string, data []byte, keywords []string) (Program, error) {
+	if string(data) == "broken" {
+		return nil, errors.New("cannot compile " + name)
+	}
+	p := newTestProgram(name, data)
+	p.keywords = keywords
+	return p, nil
+}
+func (r *testRenderSystem) NewTextureFromImageData(data []byte, d TextureDescriptor) Texture {
+	return &testTexture{d, data, false}
+}
+func (r *testRenderSystem) NewTextureFromImage(img *DecodedImage) Texture {
+	return &testTexture{img.Descriptor, img.Source, false}
+}
+func (r *testRenderSystem) SupportsTextureFormat(TextureSizedFormat) bool { return true }
+func (r *testRenderSystem) NewUniform() Uniform                           { return &testUniform{} }
+func (r *testRenderSystem) NewUniformBuffer() UniformBuffer               { return &testUniformBuffer{} }
+func (r *testRenderSystem) NewTexture(d TextureDescriptor, _ []byte) Texture {
+	return &testTexture{d, nil, false}
+}
+func (r *testRenderSystem) NewFramebuffer() Framebuffer  { return nil }
+func (r *testRenderSystem) ExecuteRenderPlan(RenderPlan) {}
+func (r *testRenderSystem) RenderLog() string            { return "" }
+
+type testUniform struct {
+	value UniformValue
+}
+
+func (u *testUniform) Set(value UniformValue) { u.value = value }
+func (u *testUniform) Value() UniformValue    { return u.value }
+func (u *testUniform) Copy() Uniform          { return &testUniform{u.value} }
+
+type testUniformBuffer struct {
+	size int
+}
+
+func (b *testUniformBuffer) Set(_ unsafe.Pointer, size int) { b.size = size }
+func (b *testUniformBuffer) Delete()                        {}
+func (b *testUniformBuffer) Lt(UniformBuffer) bool          { return false }
+func (b *testUniformBuffer) Gt(UniformBuffer) bool          { return false }
+
+// testResourceSystem serves resources from memory. Missing models, textures and materials are errors, states
+// default to an empty json object and programs to no data. Changes are reported from the changes list.
+type testResourceSystem struct {
+	sync.Mutex
+	models    map[string][]byte
+	textures  map[string][]byte
+	programs  map[string][]byte
+	states    map[string][]byte
+	materials map[string][]byte
+	changes   []ResourceChange
+}
+
+func (r *testResourceSystem) Start() {}
+func (r *testResourceSystem) Stop()  {}
+func (r *testResourceSystem) Model(name string) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	return testResource(r.models, name)
+}
+func (r *testResourceSystem) Texture(name string) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	return testResource(r.textures, name)
+}
+func (r *testResourceSystem) Program(name string) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	return r.programs[name], nil
+}
+func (r *testResourceSystem) State(name string) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	if data, ok := r.states[name]; ok {
+		return data, nil
+	}
+	return []byte("{}"), nil
+}
+func (r *testResourceSystem) ProgramData(string) ([]byte, error) { return nil, nil }
+func (r *testResourceSystem) Material(name string) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	return testResource(r.materials, name)
+}
+func (r *testResourceSystem) Changes() []ResourceChange {
+	r.Lock()
+	defer r.Unlock()
+	changes := r.changes
+	r.changes = nil
+	return changes
+}
+
+func testResource(resources map[string][]byte, name string) ([]byte, error) {
+	if data, ok := resources[name]; ok {
+		return data, nil
+	}
+	return nil, errors.New("no such resource " + name)
+}
+
+// change updates a resource and reports it as changed
+func (r *testResourceSystem) change(t ResourceType, name string, data []byte) {
+	r.Lock()
+	defer r.Unlock()
+	switch t {
+	case ResourceTypeModel:
+		r.models[name] = data
+	case ResourceTypeTexture:
+		r.textures[name] = data
+	case ResourceTypeProgram:
+		r.programs[name] = data
+	case ResourceTypeState:
+		r.states[name] = data
+	case ResourceTypeMaterial:
+		r.materials[name] = data
+	}
+	r.changes = append(r.changes, ResourceChange{t, name})
+}
+
+var (
+	setupTestSystemsOnce sync.Once
+	testResources        = &testResourceSystem{
+		models:    make(map[string][]byte),
+		textures:  make(map[string][]byte),
+		programs:  make(map[string][]byte),
+		states:    make(map[string][]byte),
+		materials: make(map[string][]byte),
+	}
+)
+
+// setupTestSystems sets the test render system and a fresh ResourceManager, so tests don't see resources loaded
+// by earlier ones
+func setupTestSystems() {
+	setupTestSystemsOnce.Do(func() {
+		SetRenderSystem(&testRenderSystem{})
+	})
+	resourceManager = newResourceManager()
+	resourceManager.SetSystem(testResources)
+}
+
+func testPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
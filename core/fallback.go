@@ -0,0 +1,105 @@
+package core
+
+import (
+	"github.com/fcvarela/gosg/protos"
+	"github.com/golang/glog"
+)
+
+// DefaultFallbackProgram is the program drawn in place of programs and states which fail to load.
+const DefaultFallbackProgram = "error"
+
+// fallbacks are the assets used in place of resources which fail to load, so broken assets show up visibly
+// instead of stopping the application. The texture and model are created when first needed.
+type fallbacks struct {
+	texture Texture
+	program string
+	model   *Node
+}
+
+// SetFallbackTexture sets the texture returned in place of textures which fail to load. The default is a magenta
+// and black checkerboard.
+func (r *ResourceManager) SetFallbackTexture(t Texture) {
+	r.fallbacks.texture = t
+}
+
+// SetFallbackProgram sets the name of the program used in place of programs which fail to load, and by states
+// which fail to load. The fallback program itself must load. The default is DefaultFallbackProgram.
+func (r *ResourceManager) SetFallbackProgram(name string) {
+	r.fallbacks.program = name
+}
+
+// SetFallbackModel sets the model copied in place of models which fail to load. The default is a unit cube using
+// the fallback texture and program.
+func (r *ResourceManager) SetFallbackModel(n *Node) {
+	r.fallbacks.model = n
+}
+
+func (r *ResourceManager) fallbackTexture() Texture {
+	if r.fallbacks.texture == nil {
+		r.fallbacks.texture = newCheckerboardTexture(8, [4]uint8{255, 0, 255, 255}, [4]uint8{0, 0, 0, 255})
+	}
+	return r.fallbacks.texture
+}
+
+// fallbackProgram returns the fallback program, loading it if needed. There is nothing left to fall back to if
+// it fails.
+func (r *ResourceManager) fallbackProgram() Program {
+	program, err := r.TryProgram(r.fallbacks.program)
+	if err != nil {
+		glog.Fatalf("Cannot load fallback program: %v", err)
+	}
+	return program
+}
+
+// fallbackState returns a new state which draws opaque geometry with the fallback program
+func (r *ResourceManager) fallbackState(name string) *protos.State {
+	return &protos.State{
+		Name:        name,
+		ProgramName: r.fallbacks.program,
+		Culling:     true,
+		CullFace:    protos.State_CULL_BACK,
+		DepthTest:   true,
+		DepthWrite:  true,
+		DepthFunc:   protos.State_DEPTH_LESS_EQUAL,
+		ColorWrite:  true,
+	}
+}
+
+func (r *ResourceManager) fallbackModel() *Node {
+	if r.fallbacks.model == nil {
+		cube := NewNode("fallback-0")
+		cube.SetMesh(NewCubeMesh())
+		material := NewMaterial("fallback", r.fallbackState("fallback"))
+		material.data.SetTexture(materialTextureNames[0], r.fallbackTexture())
+		cube.SetMaterial(material)
+
+		r.fallbacks.model = NewNode("fallback")
+		r.fallbacks.model.AddChild(cube)
+	}
+	return r.fallbacks.model
+}
+
+// newCheckerboardTexture returns a size x size texture of alternating texels, sampled without filtering
+func newCheckerboardTexture(size int, a, b [4]uint8) Texture {
+	texels := make([]byte, 0, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if (x+y)%2 == 0 {
+				texels = append(texels, a[:]...)
+			} else {
+				texels = append(texels, b[:]...)
+			}
+		}
+	}
+
+	return renderSystem.NewTexture(TextureDescriptor{
+		Width:         uint32(size),
+		Height:        uint32(size),
+		Target:        TextureTarget2D,
+		Format:        TextureFormatRGBA,
+		SizedFormat:   TextureSizedFormatRGBA8,
+		ComponentType: TextureComponentTypeUNSIGNEDBYTE,
+		Filter:        TextureFilterNearest,
+		WrapMode:      TextureWrapModeRepeat,
+	}, texels)
+}
@@ -0,0 +1,52 @@
+package core
+
+import "testing"
+
+func TestFallbacks(t *testing.T) {
+	setupTestSystems()
+
+	if _, err := resourceManager.TryModel("missing.model"); err == nil {
+		t.Error("expected an error loading a missing model")
+	}
+	model := resourceManager.Model("missing.model")
+	if len(model.Children()) != 1 || model.Children()[0].Mesh() == nil {
+		t.Error("missing models should be replaced by the fallback model")
+	}
+
+	if _, err := resourceManager.TryTexture("missing.png", TextureDescriptor{}); err == nil {
+		t.Error("expected an error loading a missing texture")
+	}
+	if texture := resourceManager.Texture("missing.png", TextureDescriptor{}); texture != resourceManager.fallbackTexture() {
+		t.Error("missing textures should be replaced by the fallback texture")
+	}
+
+	testResources.change(ResourceTypeState, "broken-state", []byte("{"))
+	if _, err := resourceManager.TryState("broken-state"); err == nil {
+		t.Error("expected an error loading a broken state")
+	}
+	state := resourceManager.State("broken-state")
+	if state.ProgramName != DefaultFallbackProgram {
+		t.Errorf("broken states should draw with the fallback program, got %q", state.ProgramName)
+	}
+
+	// fixing the state replaces the fallback in place
+	testResources.change(ResourceTypeState, "broken-state", []byte(`{"blending": true}`))
+	resourceManager.update()
+	if resourceManager.State("broken-state") != state || !state.Blending || state.ProgramName != "" {
+		t.Errorf("fallback state not replaced: %v", state)
+	}
+
+	testResources.change(ResourceTypeProgram, "broken-program", []byte("broken"))
+	if _, err := resourceManager.TryProgram("broken-program"); err == nil {
+		t.Error("expected an error compiling a broken program")
+	}
+	if program := resourceManager.Program("broken-program"); program.Name() != DefaultFallbackProgram {
+		t.Errorf("broken programs should be replaced by the fallback program, got %s", program.Name())
+	}
+
+	testResources.change(ResourceTypeProgram, "broken-program", []byte("fixed"))
+	resourceManager.update()
+	if program := resourceManager.Program("broken-program"); program.Name() != "broken-program" {
+		t.Errorf("fixed programs should replace the fallback, got %s", program.Name())
+	}
+}
@@ -6,6 +6,10 @@ type Framebuffer interface {
 	// SetColorAttachment sets the color attachment at the specified index.
 	SetColorAttachment(index int, attachment Texture)
 
+	// SetColorAttachmentLayer sets a single layer of a cubemap, 2D array or 3D texture as the color
+	// attachment at the specified index. A negative layer attaches every layer for layered rendering.
+	SetColorAttachmentLayer(index int, attachment Texture, layer int)
+
 	// ColorAttachments returns the framebuffer color attachments.
 	ColorAttachments() map[int]Texture
 
@@ -15,6 +19,13 @@ type Framebuffer interface {
 	// SetDepthAttachment sets the depth attachment.
 	SetDepthAttachment(attachment Texture)
 
+	// SetDepthAttachmentLayer sets a single layer of a cubemap, 2D array or 3D texture as the depth
+	// attachment. A negative layer attaches every layer for layered rendering.
+	SetDepthAttachmentLayer(attachment Texture, layer int)
+
 	// DepthAttachment returns the framebuffer depth attachment
 	DepthAttachment() Texture
+
+	// Delete frees the framebuffer. Attachments are owned by the caller and are not deleted.
+	Delete()
 }
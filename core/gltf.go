@@ -0,0 +1,811 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/golang/glog"
+)
+
+// binary glTF container constants
+const (
+	glbMagic     = 0x46546C67
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// glTF enums we read and write
+const (
+	gltfModePoints    = 0
+	gltfModeLines     = 1
+	gltfModeTriangles = 4
+
+	gltfComponentUnsignedByte  = 5121
+	gltfComponentUnsignedShort = 5123
+	gltfComponentUnsignedInt   = 5125
+	gltfComponentFloat         = 5126
+
+	gltfTargetArrayBuffer        = 34962
+	gltfTargetElementArrayBuffer = 34963
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string       `json:"name,omitempty"`
+	Children    []int        `json:"children,omitempty"`
+	Mesh        *int         `json:"mesh,omitempty"`
+	Matrix      *[16]float64 `json:"matrix,omitempty"`
+	Translation *[3]float64  `json:"translation,omitempty"`
+	Rotation    *[4]float64  `json:"rotation,omitempty"`
+	Scale       *[3]float64  `json:"scale,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       *int           `json:"mode,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string            `json:"name,omitempty"`
+	PbrMetallicRoughness *gltfPBR          `json:"pbrMetallicRoughness,omitempty"`
+	NormalTexture        *gltfTextureInfo  `json:"normalTexture,omitempty"`
+	Extras               *gltfMaterialInfo `json:"extras,omitempty"`
+}
+
+type gltfPBR struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+// gltfMaterialInfo carries the gosg state and the full sampler binding set, which the core glTF
+// material model can't express.
+type gltfMaterialInfo struct {
+	State    string         `json:"state,omitempty"`
+	Textures map[string]int `json:"textures,omitempty"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	BufferView int    `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+var gltfTypeComponents = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT4": 16}
+
+// gltfWriter accumulates a glTF document and its binary chunk
+type gltfWriter struct {
+	doc       gltfDocument
+	bin       bytes.Buffer
+	textures  map[Texture]int
+	materials map[string]int
+}
+
+// WriteGLB exports a node subtree as binary glTF. The hierarchy is preserved with one glTF node per scenegraph
+// node. The root carries either an identity or its world transform depending on opts, children keep their
+// local transforms. Meshes are written from their CPU-side geometry. Textures which were created from png or
+// jpeg images are embedded, albedoTex and normalTex map to the glTF base color and normal textures and the full
+// sampler set plus state name is kept in the material extras.
+func WriteGLB(w io.Writer, root *Node, opts ExportOptions) error {
+	if root == nil {
+		return errors.New("cannot export nil node")
+	}
+
+	gw := &gltfWriter{
+		textures:  make(map[Texture]int),
+		materials: make(map[string]int),
+	}
+	gw.doc.Asset = gltfAsset{Version: "2.0", Generator: "gosg"}
+
+	rootIndex := gw.addNode(root, opts.rootTransform(root))
+	gw.doc.Scenes = []gltfScene{{Nodes: []int{rootIndex}}}
+
+	// pad binary chunk to 4 bytes
+	for gw.bin.Len()%4 != 0 {
+		gw.bin.WriteByte(0)
+	}
+	if gw.bin.Len() > 0 {
+		gw.doc.Buffers = []gltfBuffer{{ByteLength: gw.bin.Len()}}
+	}
+
+	jsonData, err := json.Marshal(gw.doc)
+	if err != nil {
+		return err
+	}
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+
+	length := 12 + 8 + len(jsonData)
+	if gw.bin.Len() > 0 {
+		length += 8 + gw.bin.Len()
+	}
+
+	header := []uint32{glbMagic, glbVersion, uint32(length), uint32(len(jsonData)), glbChunkJSON}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(jsonData); err != nil {
+		return err
+	}
+
+	if gw.bin.Len() > 0 {
+		if err := binary.Write(w, binary.LittleEndian, []uint32{uint32(gw.bin.Len()), glbChunkBIN}); err != nil {
+			return err
+		}
+		if _, err := w.Write(gw.bin.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gw *gltfWriter) addNode(n *Node, transform mgl64.Mat4) int {
+	index := len(gw.doc.Nodes)
+	gw.doc.Nodes = append(gw.doc.Nodes, gltfNode{Name: n.name})
+
+	if transform != mgl64.Ident4() {
+		matrix := [16]float64(transform)
+		gw.doc.Nodes[index].Matrix = &matrix
+	}
+
+	if n.mesh != nil {
+		mesh := gw.addMesh(n)
+		gw.doc.Nodes[index].Mesh = &mesh
+	}
+
+	var children []int
+	for _, c := range n.children {
+		children = append(children, gw.addNode(c, c.transform))
+	}
+	gw.doc.Nodes[index].Children = children
+
+	return index
+}
+
+func (gw *gltfWriter) addView(data []byte, target int) int {
+	for gw.bin.Len()%4 != 0 {
+		gw.bin.WriteByte(0)
+	}
+
+	gw.doc.BufferViews = append(gw.doc.BufferViews, gltfBufferView{
+		Buffer:     0,
+		ByteOffset: gw.bin.Len(),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	gw.bin.Write(data)
+
+	return len(gw.doc.BufferViews) - 1
+}
+
+func (gw *gltfWriter) addFloatAccessor(data []float32, accessorType string, bounds bool) int {
+	components := gltfTypeComponents[accessorType]
+	accessor := gltfAccessor{
+		BufferView:    gw.addView(floatToBytes(data), gltfTargetArrayBuffer),
+		ComponentType: gltfComponentFloat,
+		Count:         len(data) / components,
+		Type:          accessorType,
+	}
+
+	if bounds && accessor.Count > 0 {
+		accessor.Min = make([]float64, components)
+		accessor.Max = make([]float64, components)
+		for c := 0; c < components; c++ {
+			accessor.Min[c], accessor.Max[c] = math.Inf(1), math.Inf(-1)
+		}
+		for i := range data {
+			c := i % components
+			accessor.Min[c] = math.Min(accessor.Min[c], float64(data[i]))
+			accessor.Max[c] = math.Max(accessor.Max[c], float64(data[i]))
+		}
+	}
+
+	gw.doc.Accessors = append(gw.doc.Accessors, accessor)
+	return len(gw.doc.Accessors) - 1
+}
+
+func (gw *gltfWriter) addMesh(n *Node) int {
+	m := n.mesh
+	prim := gltfPrimitive{Attributes: make(map[string]int)}
+
+	mode := gltfModeTriangles
+	switch m.PrimitiveType() {
+	case PrimitiveTypeLines:
+		mode = gltfModeLines
+	case PrimitiveTypePoints:
+		mode = gltfModePoints
+	}
+	prim.Mode = &mode
+
+	positions := m.Positions()
+	vertexCount := len(positions) / 3
+	prim.Attributes["POSITION"] = gw.addFloatAccessor(positions, "VEC3", true)
+
+	if normals := m.Normals(); len(normals) == vertexCount*3 {
+		prim.Attributes["NORMAL"] = gw.addFloatAccessor(normals, "VEC3", false)
+	}
+
+	// glTF tangents carry bitangent handedness in w, we keep the exact bitangents as an application attribute
+	if tangents := m.Tangents(); len(tangents) == vertexCount*3 {
+		prim.Attributes["TANGENT"] = gw.addFloatAccessor(tangentsWithHandedness(tangents, m.Normals(), m.Bitangents()), "VEC4", false)
+	}
+
+	if bitangents := m.Bitangents(); len(bitangents) == vertexCount*3 {
+		prim.Attributes["_BITANGENT"] = gw.addFloatAccessor(bitangents, "VEC3", false)
+	}
+
+	// texture coordinates are stored as vec3 in gosg
+	if tcoords := m.TextureCoordinates(); len(tcoords) == vertexCount*3 {
+		uv := make([]float32, vertexCount*2)
+		for i := 0; i < vertexCount; i++ {
+			uv[i*2+0], uv[i*2+1] = tcoords[i*3+0], tcoords[i*3+1]
+		}
+		prim.Attributes["TEXCOORD_0"] = gw.addFloatAccessor(uv, "VEC2", false)
+	}
+
+	if indices := m.Indices(); len(indices) > 0 {
+		gw.doc.Accessors = append(gw.doc.Accessors, gltfAccessor{
+			BufferView:    gw.addView(shortToBytes(indices), gltfTargetElementArrayBuffer),
+			ComponentType: gltfComponentUnsignedShort,
+			Count:         len(indices),
+			Type:          "SCALAR",
+		})
+		accessor := len(gw.doc.Accessors) - 1
+		prim.Indices = &accessor
+	}
+
+	if material, ok := gw.addMaterial(n); ok {
+		prim.Material = &material
+	}
+
+	gw.doc.Meshes = append(gw.doc.Meshes, gltfMesh{Name: m.Name(), Primitives: []gltfPrimitive{prim}})
+	return len(gw.doc.Meshes) - 1
+}
+
+func (gw *gltfWriter) addTexture(t Texture) (int, bool) {
+	if index, ok := gw.textures[t]; ok {
+		return index, true
+	}
+
+	data := t.ImageData()
+	var mimeType string
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG")):
+		mimeType = "image/png"
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		mimeType = "image/jpeg"
+	default:
+		return 0, false
+	}
+
+	gw.doc.Images = append(gw.doc.Images, gltfImage{BufferView: gw.addView(data, 0), MimeType: mimeType})
+	gw.doc.Textures = append(gw.doc.Textures, gltfTexture{Source: len(gw.doc.Images) - 1})
+
+	index := len(gw.doc.Textures) - 1
+	gw.textures[t] = index
+	return index, true
+}
+
+func (gw *gltfWriter) addMaterial(n *Node) (int, bool) {
+	info := &gltfMaterialInfo{Textures: make(map[string]int)}
+	if state := n.State(); state != nil {
+		info.State = state.Name
+	}
+
+	for name, texture := range n.materialData.Textures() {
+		if index, ok := gw.addTexture(texture); ok {
+			info.Textures[name] = index
+		}
+	}
+
+	if info.State == "" && len(info.Textures) == 0 {
+		return 0, false
+	}
+
+	// materials are shared by nodes with the same state and texture set
+	key, _ := json.Marshal(info)
+	if index, ok := gw.materials[string(key)]; ok {
+		return index, true
+	}
+
+	material := gltfMaterial{Name: info.State, Extras: info}
+	if index, ok := info.Textures["albedoTex"]; ok {
+		material.PbrMetallicRoughness = &gltfPBR{BaseColorTexture: &gltfTextureInfo{index}}
+	}
+	if index, ok := info.Textures["normalTex"]; ok {
+		material.NormalTexture = &gltfTextureInfo{index}
+	}
+
+	gw.doc.Materials = append(gw.doc.Materials, material)
+	index := len(gw.doc.Materials) - 1
+	gw.materials[string(key)] = index
+	return index, true
+}
+
+// tangentsWithHandedness returns xyzw tangents where w is the sign of the bitangent relative to cross(n, t).
+func tangentsWithHandedness(tangents, normals, bitangents []float32) []float32 {
+	count := len(tangents) / 3
+	out := make([]float32, count*4)
+	for i := 0; i < count; i++ {
+		t := mgl64.Vec3{float64(tangents[i*3]), float64(tangents[i*3+1]), float64(tangents[i*3+2])}
+		w := float32(1.0)
+		if len(normals) == len(tangents) && len(bitangents) == len(tangents) {
+			n := mgl64.Vec3{float64(normals[i*3]), float64(normals[i*3+1]), float64(normals[i*3+2])}
+			b := mgl64.Vec3{float64(bitangents[i*3]), float64(bitangents[i*3+1]), float64(bitangents[i*3+2])}
+			if n.Cross(t).Dot(b) < 0.0 {
+				w = -1.0
+			}
+		}
+		copy(out[i*4:i*4+3], tangents[i*3:i*3+3])
+		out[i*4+3] = w
+	}
+	return out
+}
+
+// gltfReader resolves accessors of a parsed binary glTF file
+type gltfReader struct {
+	doc       gltfDocument
+	bin       []byte
+	materials map[int]*Material
+}
+
+func readGLB(data []byte) (*gltfReader, error) {
+	if len(data) < 20 {
+		return nil, errors.New("glb: file too short")
+	}
+
+	le := binary.LittleEndian
+	if le.Uint32(data[0:4]) != glbMagic {
+		return nil, errors.New("glb: bad magic")
+	}
+	if v := le.Uint32(data[4:8]); v != glbVersion {
+		return nil, fmt.Errorf("glb: unsupported version %d", v)
+	}
+
+	gr := &gltfReader{materials: make(map[int]*Material)}
+	offset := 12
+	for offset+8 <= len(data) {
+		chunkLength := int(le.Uint32(data[offset : offset+4]))
+		chunkType := le.Uint32(data[offset+4 : offset+8])
+		offset += 8
+		if offset+chunkLength > len(data) {
+			return nil, errors.New("glb: truncated chunk")
+		}
+
+		chunk := data[offset : offset+chunkLength]
+		switch chunkType {
+		case glbChunkJSON:
+			if err := json.Unmarshal(chunk, &gr.doc); err != nil {
+				return nil, fmt.Errorf("glb: %v", err)
+			}
+		case glbChunkBIN:
+			gr.bin = chunk
+		}
+		offset += chunkLength
+	}
+
+	return gr, nil
+}
+
+func (gr *gltfReader) view(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(gr.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("glb: invalid buffer view %d", index)
+	}
+
+	bv := gr.doc.BufferViews[index]
+	if bv.Buffer != 0 || bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteOffset+bv.ByteLength > len(gr.bin) {
+		return nil, 0, fmt.Errorf("glb: buffer view %d out of range", index)
+	}
+	return gr.bin[bv.ByteOffset : bv.ByteOffset+bv.ByteLength], bv.ByteStride, nil
+}
+
+// accessorFits returns whether an accessor's elements of the given size, stride bytes apart, fit in a buffer
+// view of viewLength bytes
+func accessorFits(a gltfAccessor, stride int, size int, viewLength int) bool {
+	if a.ByteOffset < 0 || a.Count < 0 || a.ByteOffset+size > viewLength {
+		return a.Count == 0 && a.ByteOffset >= 0
+	}
+	return a.Count <= 1 || a.Count-1 <= (viewLength-a.ByteOffset-size)/stride
+}
+
+// floats returns a float accessor as a tightly packed slice
+func (gr *gltfReader) floats(index int, accessorType string) ([]float32, error) {
+	if index < 0 || index >= len(gr.doc.Accessors) {
+		return nil, fmt.Errorf("glb: invalid accessor %d", index)
+	}
+
+	a := gr.doc.Accessors[index]
+	if a.ComponentType != gltfComponentFloat || a.Type != accessorType {
+		return nil, fmt.Errorf("glb: accessor %d is not a float %s", index, accessorType)
+	}
+
+	data, stride, err := gr.view(a.BufferView)
+	if err != nil {
+		return nil, err
+	}
+
+	components := gltfTypeComponents[a.Type]
+	if stride == 0 {
+		stride = components * 4
+	}
+	if stride < components*4 || !accessorFits(a, stride, components*4, len(data)) {
+		return nil, fmt.Errorf("glb: accessor %d out of range", index)
+	}
+
+	out := make([]float32, a.Count*components)
+	for i := 0; i < a.Count; i++ {
+		base := a.ByteOffset + i*stride
+		for c := 0; c < components; c++ {
+			out[i*components+c] = math.Float32frombits(binary.LittleEndian.Uint32(data[base+c*4:]))
+		}
+	}
+	return out, nil
+}
+
+// indices returns an index accessor converted to 16 bit indices
+func (gr *gltfReader) indices(index int) ([]uint16, error) {
+	if index < 0 || index >= len(gr.doc.Accessors) {
+		return nil, fmt.Errorf("glb: invalid accessor %d", index)
+	}
+
+	a := gr.doc.Accessors[index]
+	data, _, err := gr.view(a.BufferView)
+	if err != nil {
+		return nil, err
+	}
+
+	size := map[int]int{gltfComponentUnsignedByte: 1, gltfComponentUnsignedShort: 2, gltfComponentUnsignedInt: 4}[a.ComponentType]
+	if size == 0 {
+		return nil, fmt.Errorf("glb: accessor %d has invalid index type %d", index, a.ComponentType)
+	}
+	if !accessorFits(a, size, size, len(data)) {
+		return nil, fmt.Errorf("glb: accessor %d out of range", index)
+	}
+	data = data[a.ByteOffset:]
+
+	out := make([]uint16, a.Count)
+	for i := range out {
+		var v uint32
+		switch size {
+		case 1:
+			v = uint32(data[i])
+		case 2:
+			v = uint32(binary.LittleEndian.Uint16(data[i*2:]))
+		case 4:
+			v = binary.LittleEndian.Uint32(data[i*4:])
+		}
+		if v > math.MaxUint16 {
+			return nil, fmt.Errorf("glb: accessor %d index %d does not fit 16 bits", index, v)
+		}
+		out[i] = uint16(v)
+	}
+	return out, nil
+}
+
+// LoadGLB parses a binary glTF file and returns a node ready to insert into the scenegraph. Each glTF node maps
+// to a scenegraph node, meshes with more than one primitive get a child node per primitive. The node state is
+// taken from the material extras written by WriteGLB, falling back to the material name.
+func LoadGLB(name string, data []byte) (*Node, error) {
+	gr, err := readGLB(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode every image before creating any texture so corrupt files don't leave textures behind
+	images := make([]*DecodedImage, len(gr.doc.Textures))
+	textureDescriptor := TextureDescriptor{
+		Mipmaps:  true,
+		Filter:   TextureFilterMipmapLinear,
+		WrapMode: TextureWrapModeRepeat,
+	}
+	for i, t := range gr.doc.Textures {
+		if t.Source < 0 || t.Source >= len(gr.doc.Images) {
+			return nil, fmt.Errorf("glb: texture %d has invalid image", i)
+		}
+		imageData, _, err := gr.view(gr.doc.Images[t.Source].BufferView)
+		if err != nil {
+			return nil, err
+		}
+		if images[i], err = DecodeImage(imageData, textureDescriptor); err != nil {
+			return nil, fmt.Errorf("glb: cannot decode image %d: %v", t.Source, err)
+		}
+	}
+
+	textures := make([]Texture, len(images))
+	for i, img := range images {
+		textures[i] = renderSystem.NewTextureFromImage(img)
+	}
+
+	parentNode := NewNode(filepath.Base(name))
+	if len(gr.doc.Scenes) == 0 {
+		return parentNode, nil
+	}
+	if gr.doc.Scene < 0 || gr.doc.Scene >= len(gr.doc.Scenes) {
+		return nil, fmt.Errorf("glb: invalid scene %d", gr.doc.Scene)
+	}
+
+	for _, index := range gr.doc.Scenes[gr.doc.Scene].Nodes {
+		child, err := gr.loadNode(index, textures, 0)
+		if err != nil {
+			return nil, err
+		}
+		parentNode.AddChild(child)
+	}
+
+	return parentNode, nil
+}
+
+func (gr *gltfReader) loadNode(index int, textures []Texture, depth int) (*Node, error) {
+	if index < 0 || index >= len(gr.doc.Nodes) || depth > len(gr.doc.Nodes) {
+		return nil, fmt.Errorf("glb: invalid node %d", index)
+	}
+
+	gn := gr.doc.Nodes[index]
+	node := NewNode(gn.Name)
+
+	switch {
+	case gn.Matrix != nil:
+		node.transform = mgl64.Mat4(*gn.Matrix)
+	case gn.Translation != nil || gn.Rotation != nil || gn.Scale != nil:
+		t, r, s := mgl64.Ident4(), mgl64.Ident4(), mgl64.Ident4()
+		if gn.Translation != nil {
+			t = mgl64.Translate3D(gn.Translation[0], gn.Translation[1], gn.Translation[2])
+		}
+		if gn.Rotation != nil {
+			r = mgl64.Quat{W: gn.Rotation[3], V: mgl64.Vec3{gn.Rotation[0], gn.Rotation[1], gn.Rotation[2]}}.Mat4()
+		}
+		if gn.Scale != nil {
+			s = mgl64.Scale3D(gn.Scale[0], gn.Scale[1], gn.Scale[2])
+		}
+		node.transform = t.Mul4(r).Mul4(s)
+	}
+
+	if gn.Mesh != nil {
+		if *gn.Mesh < 0 || *gn.Mesh >= len(gr.doc.Meshes) {
+			return nil, fmt.Errorf("glb: node %d has invalid mesh", index)
+		}
+
+		gm := gr.doc.Meshes[*gn.Mesh]
+		for i, prim := range gm.Primitives {
+			target := node
+			if len(gm.Primitives) > 1 {
+				target = NewNode(fmt.Sprintf("%s-%d", gn.Name, i))
+				node.AddChild(target)
+			}
+			if err := gr.loadPrimitive(target, gm.Name, prim, textures); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, c := range gn.Children {
+		child, err := gr.loadNode(c, textures, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.AddChild(child)
+	}
+
+	return node, nil
+}
+
+func (gr *gltfReader) loadPrimitive(node *Node, name string, prim gltfPrimitive, textures []Texture) error {
+	positionsIndex, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return fmt.Errorf("glb: mesh %s has no positions", name)
+	}
+
+	positions, err := gr.floats(positionsIndex, "VEC3")
+	if err != nil {
+		return err
+	}
+
+	var normals, tangents, bitangents, tcoords []float32
+	if i, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, err = gr.floats(i, "VEC3"); err != nil {
+			return err
+		}
+	}
+
+	if i, ok := prim.Attributes["TANGENT"]; ok {
+		tangents4, err := gr.floats(i, "VEC4")
+		if err != nil {
+			return err
+		}
+
+		tangents = make([]float32, len(tangents4)/4*3)
+		for v := 0; v < len(tangents4)/4; v++ {
+			copy(tangents[v*3:v*3+3], tangents4[v*4:v*4+3])
+		}
+
+		if i, ok := prim.Attributes["_BITANGENT"]; ok {
+			if bitangents, err = gr.floats(i, "VEC3"); err != nil {
+				return err
+			}
+		} else if len(normals) == len(tangents) {
+			bitangents = make([]float32, len(tangents))
+			for v := 0; v < len(tangents)/3; v++ {
+				n := mgl64.Vec3{float64(normals[v*3]), float64(normals[v*3+1]), float64(normals[v*3+2])}
+				t := mgl64.Vec3{float64(tangents[v*3]), float64(tangents[v*3+1]), float64(tangents[v*3+2])}
+				b := n.Cross(t).Mul(float64(tangents4[v*4+3]))
+				bitangents[v*3], bitangents[v*3+1], bitangents[v*3+2] = float32(b[0]), float32(b[1]), float32(b[2])
+			}
+		}
+	}
+
+	if i, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		uv, err := gr.floats(i, "VEC2")
+		if err != nil {
+			return err
+		}
+		tcoords = make([]float32, len(uv)/2*3)
+		for v := 0; v < len(uv)/2; v++ {
+			tcoords[v*3], tcoords[v*3+1] = uv[v*2], uv[v*2+1]
+		}
+	}
+
+	var indices []uint16
+	if prim.Indices != nil {
+		if indices, err = gr.indices(*prim.Indices); err != nil {
+			return err
+		}
+	} else {
+		indices = make([]uint16, len(positions)/3)
+		for i := range indices {
+			indices[i] = uint16(i)
+		}
+	}
+
+	primitiveType := PrimitiveTypeTriangles
+	if prim.Mode != nil {
+		switch *prim.Mode {
+		case gltfModeTriangles:
+		case gltfModeLines:
+			primitiveType = PrimitiveTypeLines
+		case gltfModePoints:
+			primitiveType = PrimitiveTypePoints
+		default:
+			return fmt.Errorf("glb: mesh %s has unsupported mode %d", name, *prim.Mode)
+		}
+	}
+
+	if prim.Material != nil {
+		if *prim.Material < 0 || *prim.Material >= len(gr.doc.Materials) {
+			return fmt.Errorf("glb: mesh %s has invalid material", name)
+		}
+
+		// primitives using the same glTF material share a material
+		shared, ok := gr.materials[*prim.Material]
+		if !ok {
+			material := gr.doc.Materials[*prim.Material]
+			stateName := material.Name
+			bindings := make(map[string]int)
+			if material.PbrMetallicRoughness != nil && material.PbrMetallicRoughness.BaseColorTexture != nil {
+				bindings["albedoTex"] = material.PbrMetallicRoughness.BaseColorTexture.Index
+			}
+			if material.NormalTexture != nil {
+				bindings["normalTex"] = material.NormalTexture.Index
+			}
+			if material.Extras != nil {
+				if material.Extras.State != "" {
+					stateName = material.Extras.State
+				}
+				for k, v := range material.Extras.Textures {
+					bindings[k] = v
+				}
+			}
+
+			shared = NewMaterial(material.Name, nil)
+			for sampler, index := range bindings {
+				if index < 0 || index >= len(textures) {
+					return fmt.Errorf("glb: material %s has invalid texture %d", material.Name, index)
+				}
+				shared.data.SetTexture(sampler, textures[index])
+			}
+
+			if stateName != "" {
+				shared.state = resourceManager.State(stateName)
+			}
+			gr.materials[*prim.Material] = shared
+		}
+		node.SetMaterial(shared)
+	}
+
+	if node.State() == nil {
+		glog.Warningf("glb: mesh %s has no state", name)
+	}
+
+	// meshes share vertex buffers, every attribute must be present to keep them aligned
+	vertexFloats := len(positions)
+	if len(normals) != vertexFloats {
+		normals = make([]float32, vertexFloats)
+	}
+	if len(tangents) != vertexFloats {
+		tangents = make([]float32, vertexFloats)
+	}
+	if len(bitangents) != vertexFloats {
+		bitangents = make([]float32, vertexFloats)
+	}
+	if len(tcoords) != vertexFloats {
+		tcoords = make([]float32, vertexFloats)
+	}
+
+	mesh := renderSystem.NewMesh()
+	mesh.SetName(name)
+	mesh.SetPositions(positions)
+	mesh.SetNormals(normals)
+	mesh.SetTangents(tangents)
+	mesh.SetBitangents(bitangents)
+	mesh.SetTextureCoordinates(tcoords)
+	mesh.SetIndices(indices)
+	mesh.SetPrimitiveType(primitiveType)
+	node.SetMesh(mesh)
+
+	return nil
+}
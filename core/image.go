@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fcvarela/gosg/imagecodec"
+)
+
+// DecodedImage holds texel data decoded from an image file and the descriptor it should be uploaded with.
+// Decoding is CPU only and safe to run off the main thread, uploading is done by the RenderSystem.
+type DecodedImage struct {
+	Descriptor TextureDescriptor
+
+	// Levels holds the texels of each mip level, starting at the full size image. Each level has the
+	// texels of every cubemap face or array layer, one after the other. When there is a single level and
+	// the descriptor asks for mipmaps, the RenderSystem generates them if the format allows it.
+	Levels [][]byte
+
+	// Source is the encoded image the texels were decoded from
+	Source []byte
+}
+
+// imageFormats maps decoded texel formats to texture formats
+var imageFormats = map[imagecodec.Format]struct {
+	format        TextureFormat
+	sizedFormat   TextureSizedFormat
+	componentType TextureComponentType
+}{
+	imagecodec.FormatR8:      {TextureFormatR, TextureSizedFormatR8, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatRG8:     {TextureFormatRG, TextureSizedFormatRG8, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatRGB8:    {TextureFormatRGB, TextureSizedFormatRGB8, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatRGBA8:   {TextureFormatRGBA, TextureSizedFormatRGBA8, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatR16F:    {TextureFormatR, TextureSizedFormatR16F, TextureComponentTypeHALFFLOAT},
+	imagecodec.FormatRG16F:   {TextureFormatRG, TextureSizedFormatRG16F, TextureComponentTypeHALFFLOAT},
+	imagecodec.FormatRGBA16F: {TextureFormatRGBA, TextureSizedFormatRGBA16F, TextureComponentTypeHALFFLOAT},
+	imagecodec.FormatR32F:    {TextureFormatR, TextureSizedFormatR32F, TextureComponentTypeFLOAT},
+	imagecodec.FormatRG32F:   {TextureFormatRG, TextureSizedFormatRG32F, TextureComponentTypeFLOAT},
+	imagecodec.FormatRGB32F:  {TextureFormatRGB, TextureSizedFormatRGB32F, TextureComponentTypeFLOAT},
+	imagecodec.FormatRGBA32F: {TextureFormatRGBA, TextureSizedFormatRGBA32F, TextureComponentTypeFLOAT},
+	imagecodec.FormatBC1:     {TextureFormatRGBA, TextureSizedFormatBC1, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatBC2:     {TextureFormatRGBA, TextureSizedFormatBC2, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatBC3:     {TextureFormatRGBA, TextureSizedFormatBC3, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatBC4:     {TextureFormatR, TextureSizedFormatBC4, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatBC5:     {TextureFormatRG, TextureSizedFormatBC5, TextureComponentTypeUNSIGNEDBYTE},
+	imagecodec.FormatBC6H:    {TextureFormatRGB, TextureSizedFormatBC6H, TextureComponentTypeFLOAT},
+	imagecodec.FormatBC7:     {TextureFormatRGBA, TextureSizedFormatBC7, TextureComponentTypeUNSIGNEDBYTE},
+}
+
+// DecodeImage decodes an image file into texels. Besides the formats registered with the image package,
+// which decode to RGBA8, Radiance HDR files decode to RGB32F, TGA to RGBA8 and DDS/KTX keep their own
+// format, mip chain, cubemap faces and array layers. Block compressed images are decompressed when the
+// RenderSystem does not support their format. The passed descriptor provides sampling parameters, target,
+// dimensions and formats are overridden from the image.
+func DecodeImage(data []byte, d TextureDescriptor) (*DecodedImage, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty image data")
+	}
+
+	img, err := imagecodec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if img.Cubemap && img.Layers != 6 {
+		return nil, errors.New("cubemap arrays are not supported")
+	}
+
+	formats, ok := imageFormats[img.Format]
+	if ok && formats.sizedFormat.Compressed() && !renderSystem.SupportsTextureFormat(formats.sizedFormat) {
+		if img, err = imagecodec.Decompress(img); err != nil {
+			return nil, err
+		}
+		formats, ok = imageFormats[img.Format]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported image format %s", img.Format)
+	}
+
+	// drop provided mip levels if they were not asked for
+	levels := img.Levels
+	if !d.Mipmaps {
+		levels = levels[:1]
+	}
+
+	d.Width = uint32(img.Width)
+	d.Height = uint32(img.Height)
+	d.Depth = 0
+	switch {
+	case img.Cubemap:
+		d.Target = TextureTargetCubemap
+	case img.Layers > 1:
+		d.Target = TextureTarget2DArray
+		d.Depth = uint32(img.Layers)
+	default:
+		d.Target = TextureTarget2D
+	}
+	d.Format = formats.format
+	d.SizedFormat = formats.sizedFormat
+	d.ComponentType = formats.componentType
+
+	return &DecodedImage{d, levels, data}, nil
+}
@@ -0,0 +1,51 @@
+package core
+
+import "testing"
+
+func TestDecodeImageHDR(t *testing.T) {
+	setupTestSystems()
+
+	data := []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 2\n")
+	data = append(data, 128, 64, 0, 129, 0, 0, 0, 0)
+
+	img, err := DecodeImage(data, TextureDescriptor{Mipmaps: true, Filter: TextureFilterLinear})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := img.Descriptor
+	if d.Width != 2 || d.Height != 1 || d.Format != TextureFormatRGB ||
+		d.SizedFormat != TextureSizedFormatRGB32F || d.ComponentType != TextureComponentTypeFLOAT {
+		t.Errorf("unexpected descriptor %+v", d)
+	}
+	if d.Filter != TextureFilterLinear || len(img.Levels) != 1 || len(img.Levels[0]) != 24 {
+		t.Errorf("unexpected texels, %d levels", len(img.Levels))
+	}
+}
+
+func TestDecodeImageCubemap(t *testing.T) {
+	setupTestSystems()
+
+	// 1x1 rgba8 ktx cubemap with one level
+	data := []byte{0xab, 'K', 'T', 'X', ' ', '1', '1', 0xbb, '\r', '\n', 0x1a, '\n'}
+	for _, v := range []uint32{0x04030201, 0, 1, 0, 0x8058, 0, 1, 1, 0, 0, 6, 1, 0} {
+		data = append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	data = append(data, 4, 0, 0, 0)
+	for face := 0; face < 6; face++ {
+		data = append(data, byte(face), 0, 0, 255)
+	}
+
+	img, err := DecodeImage(data, TextureDescriptor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := img.Descriptor
+	if d.Target != TextureTargetCubemap || d.Layers(0) != 6 || len(img.Levels[0]) != 24 {
+		t.Errorf("unexpected cubemap %+v with %d bytes", d, len(img.Levels[0]))
+	}
+	if TextureTargetCubemapZNegative.CubemapFace() != 5 {
+		t.Error("unexpected face index")
+	}
+}
@@ -2,13 +2,39 @@ package core
 
 import "github.com/go-gl/mathgl/mgl32"
 
-// LightBlock holds a light's properties. It is embedded in a sceneblock and
+// LightBlock holds a light's properties. It is packed as a LightStruct into the light texture of every camera and
 // passed to every program.
 type LightBlock struct {
 	VPMatrix [maxCascades]mgl32.Mat4
 	ZCuts    [maxCascades]mgl32.Vec4
 	Position mgl32.Vec4
 	Color    mgl32.Vec4
+
+	// Radius bounds the light's influence around its position. Lights with a radius are only assigned to the
+	// clusters their sphere touches and fade out towards it, lights without one light every cluster.
+	Radius float32
+}
+
+// LightStruct is the layout of a LightBlock in the light texture, read in GLSL with the accessors of
+// gosg/lights.glsl. Fields added to LightBlock need a member here and in Pack.
+var LightStruct = NewStd140Struct("light",
+	Std140Member{Name: "vpMatrix", Type: UniformTypeMat4, Length: maxCascades},
+	Std140Member{Name: "zCuts", Type: UniformTypeVec4, Length: maxCascades},
+	Std140Member{Name: "position", Type: UniformTypeVec4},
+	Std140Member{Name: "color", Type: UniformTypeVec4},
+	Std140Member{Name: "radius", Type: UniformTypeFloat},
+)
+
+// Pack writes the light block to a field of a buffer whose type is LightStruct
+func (lb *LightBlock) Pack(buf Std140Buffer, f Std140Field) {
+	vpMatrix, zCuts := f.Member("vpMatrix"), f.Member("zCuts")
+	for i := 0; i < maxCascades; i++ {
+		buf.SetMat4(vpMatrix.Index(i), lb.VPMatrix[i])
+		buf.SetVec4(zCuts.Index(i), lb.ZCuts[i])
+	}
+	buf.SetVec4(f.Member("position"), lb.Position)
+	buf.SetVec4(f.Member("color"), lb.Color)
+	buf.SetFloat(f.Member("radius"), lb.Radius)
 }
 
 // Light represents a light. It contains a properties block and an optional shadower.
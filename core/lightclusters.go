@@ -0,0 +1,394 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/golang/glog"
+)
+
+// Lights are assigned to clusters of each camera's view frustum on the CPU, so fragments only shade the lights
+// which can reach them. The frustum is divided in TilesX by TilesY tiles across the viewport and Slices slices
+// in depth, spaced exponentially between the near and far planes of perspective projections. Lights with a
+// radius are added to the clusters their bounding sphere touches, lights without one to every cluster.
+//
+// Programs which include gosg/lights.glsl read the lights of their fragment's cluster from three textures, which
+// need sampler bindings:
+//
+//	ivec2 cluster = lightCluster(position);
+//	for (int i = 0; i < cluster.y; i++) {
+//	    int light = clusterLight(cluster.x + i);
+//	    vec3 L = normalize(lightPosition(light).xyz - position);
+//	    ...
+//	}
+//
+// lightsTex holds a LightStruct per row, read with the light* accessors. lightClustersTex holds the offset and
+// count of the lights of each cluster, with a row of tiles per slice, and lightIndicesTex the lights of all
+// clusters, lightIndexWidth per row.
+const (
+	// LightsSampler is the sampler name of the camera's light texture
+	LightsSampler = "lightsTex"
+
+	// LightClustersSampler is the sampler name of the camera's cluster texture
+	LightClustersSampler = "lightClustersTex"
+
+	// LightIndicesSampler is the sampler name of the camera's light index texture
+	LightIndicesSampler = "lightIndicesTex"
+)
+
+// lightIndexWidth is the width of the light index texture, longer lists continue on the next rows
+const lightIndexWidth = 1024
+
+// LightClusterConfig configures the light clusters of a camera
+type LightClusterConfig struct {
+	// TilesX and TilesY are the number of clusters across and up the viewport
+	TilesX, TilesY int
+
+	// Slices is the number of clusters in depth
+	Slices int
+
+	// MaxLights is the number of lights passed to programs, further lights are dropped
+	MaxLights int
+
+	// MaxClusterLights is the number of lights of a cluster, further lights are dropped from it
+	MaxClusterLights int
+}
+
+// DefaultLightClusterConfig is the light cluster configuration of new cameras
+var DefaultLightClusterConfig = LightClusterConfig{TilesX: 16, TilesY: 9, Slices: 24, MaxLights: 256, MaxClusterLights: 64}
+
+// validate returns an error if the config has no clusters or lights
+func (c LightClusterConfig) validate() error {
+	if c.TilesX < 1 || c.TilesY < 1 || c.Slices < 1 || c.MaxLights < 1 || c.MaxClusterLights < 1 {
+		return fmt.Errorf("light cluster config %+v needs at least one cluster and light", c)
+	}
+	return nil
+}
+
+// lightClusters assigns a camera's lights to its clusters and uploads them to its light textures
+type lightClusters struct {
+	config LightClusterConfig
+
+	// depth slicing, slices are log(depth)*scale+bias for perspective projections and depth*scale+bias otherwise
+	near, far   float64
+	perspective bool
+	scale, bias float64
+
+	// lightCount is the number of lights assigned, offsets, counts and indices hold the light lists of each
+	// cluster, indices being the lights of all clusters one after the other
+	lightCount int
+	offsets    []int
+	counts     []int
+	indices    []int
+
+	// assignedClusters and assignedLights are the cluster and light of each assignment, next the end of each
+	// cluster's list while scattering them
+	assignedClusters []int
+	assignedLights   []int
+	next             []int
+
+	// warned is set once dropped lights have been reported
+	warned bool
+
+	// texture data, reused across frames
+	lights      Std140Buffer
+	clusterData []byte
+	indexData   []byte
+	textures    map[string]Texture
+}
+
+// newLightClusters returns clusters with the given config
+func newLightClusters(config LightClusterConfig) *lightClusters {
+	clusters := config.TilesX * config.TilesY * config.Slices
+	return &lightClusters{
+		config:  config,
+		offsets: make([]int, clusters),
+		counts:  make([]int, clusters),
+	}
+}
+
+// delete frees the cluster textures
+func (c *lightClusters) delete() {
+	for _, t := range c.textures {
+		t.Delete()
+	}
+	c.textures = nil
+}
+
+// setProjection sets the depth range and slicing from a projection matrix
+func (c *lightClusters) setProjection(p mgl64.Mat4) {
+	c.perspective = p[15] == 0.0
+	slices := float64(c.config.Slices)
+
+	if c.perspective {
+		c.near, c.far = p[14]/(p[10]-1.0), p[14]/(p[10]+1.0)
+		c.scale = slices / math.Log(c.far/c.near)
+		c.bias = -math.Log(c.near) * c.scale
+	} else {
+		c.near, c.far = (p[14]+1.0)/p[10], (p[14]-1.0)/p[10]
+		c.scale = slices / (c.far - c.near)
+		c.bias = -c.near * c.scale
+	}
+}
+
+// slice returns the slice holding a view space depth
+func (c *lightClusters) slice(depth float64) int {
+	s := depth
+	if c.perspective {
+		s = math.Log(depth)
+	}
+
+	slice := int(math.Floor(s*c.scale + c.bias))
+	if slice < 0 {
+		return 0
+	}
+	if slice >= c.config.Slices {
+		return c.config.Slices - 1
+	}
+	return slice
+}
+
+// sliceDepth returns the view space depth where a slice starts
+func (c *lightClusters) sliceDepth(slice int) float64 {
+	if c.perspective {
+		return math.Exp((float64(slice) - c.bias) / c.scale)
+	}
+	return (float64(slice) - c.bias) / c.scale
+}
+
+// tiles returns the range of tiles of an axis covering normalized device coordinates from min to max, and
+// whether it is in the viewport at all
+func tiles(min, max float64, count int) (int, int, bool) {
+	if max < -1.0 || min > 1.0 {
+		return 0, 0, false
+	}
+
+	first := int(math.Floor((min + 1.0) * 0.5 * float64(count)))
+	last := int(math.Floor((max + 1.0) * 0.5 * float64(count)))
+	if first < 0 {
+		first = 0
+	}
+	if last >= count {
+		last = count - 1
+	}
+	return first, last, true
+}
+
+// assign builds the light lists of each cluster for a camera with the given transforms. Lights beyond
+// MaxLights are dropped.
+func (c *lightClusters) assign(pMatrix, vMatrix mgl64.Mat4, lights []*Light) {
+	c.setProjection(pMatrix)
+
+	c.lightCount = len(lights)
+	if c.lightCount > c.config.MaxLights {
+		c.lightCount = c.config.MaxLights
+	}
+
+	for i := range c.counts {
+		c.counts[i] = 0
+	}
+
+	// every light is added to the clusters it touches, in light order so each cluster's list is sorted
+	dropped := false
+	c.assignedClusters, c.assignedLights = c.assignedClusters[:0], c.assignedLights[:0]
+	for i, l := range lights[:c.lightCount] {
+		first := len(c.assignedClusters)
+		c.assignedClusters = c.lightClusters(c.assignedClusters, pMatrix, vMatrix, &l.Block)
+
+		kept := first
+		for _, cluster := range c.assignedClusters[first:] {
+			if c.counts[cluster] == c.config.MaxClusterLights {
+				dropped = true
+				continue
+			}
+			c.counts[cluster]++
+			c.assignedClusters[kept] = cluster
+			c.assignedLights = append(c.assignedLights, i)
+			kept++
+		}
+		c.assignedClusters = c.assignedClusters[:kept]
+	}
+
+	if dropped && !c.warned {
+		glog.Warningf("Light clusters hold more than %d lights, dropping lights", c.config.MaxClusterLights)
+		c.warned = true
+	}
+
+	// each cluster's list starts after the previous one, the assigned lights are then scattered to their lists
+	offset := 0
+	for cluster, count := range c.counts {
+		c.offsets[cluster] = offset
+		offset += count
+	}
+
+	if cap(c.indices) < offset {
+		c.indices = make([]int, offset)
+	}
+	c.indices = c.indices[:offset]
+	c.next = append(c.next[:0], c.offsets...)
+	for a, cluster := range c.assignedClusters {
+		c.indices[c.next[cluster]] = c.assignedLights[a]
+		c.next[cluster]++
+	}
+}
+
+// lightClusters appends the clusters a light touches to clusters
+func (c *lightClusters) lightClusters(clusters []int, pMatrix, vMatrix mgl64.Mat4, light *LightBlock) []int {
+	tilesX, tilesY := c.config.TilesX, c.config.TilesY
+	if light.Radius <= 0.0 {
+		for cluster := range c.counts {
+			clusters = append(clusters, cluster)
+		}
+		return clusters
+	}
+
+	r := float64(light.Radius)
+	p := light.Position
+	center := vMatrix.Mul4x1(mgl64.Vec4{float64(p[0]), float64(p[1]), float64(p[2]), 1.0})
+	depth := -center.Z()
+
+	near, far := math.Max(depth-r, c.near), math.Min(depth+r, c.far)
+	if near > far {
+		return clusters
+	}
+
+	for slice := c.slice(near); slice <= c.slice(far); slice++ {
+		// the part of the sphere's bounding box in the slice projects inside the hull of its corners
+		z0, z1 := math.Max(near, c.sliceDepth(slice)), math.Min(far, c.sliceDepth(slice+1))
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, corner := range [8]mgl64.Vec4{
+			{center.X() - r, center.Y() - r, -z0, 1.0}, {center.X() + r, center.Y() - r, -z0, 1.0},
+			{center.X() - r, center.Y() + r, -z0, 1.0}, {center.X() + r, center.Y() + r, -z0, 1.0},
+			{center.X() - r, center.Y() - r, -z1, 1.0}, {center.X() + r, center.Y() - r, -z1, 1.0},
+			{center.X() - r, center.Y() + r, -z1, 1.0}, {center.X() + r, center.Y() + r, -z1, 1.0},
+		} {
+			clip := pMatrix.Mul4x1(corner)
+			x, y := clip.X()/clip.W(), clip.Y()/clip.W()
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+
+		x0, x1, okX := tiles(minX, maxX, tilesX)
+		y0, y1, okY := tiles(minY, maxY, tilesY)
+		if !okX || !okY {
+			continue
+		}
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				clusters = append(clusters, x+y*tilesX+slice*tilesX*tilesY)
+			}
+		}
+	}
+	return clusters
+}
+
+// upload packs the assigned lights and light lists into the cluster textures, creating them on first use
+func (c *lightClusters) upload(lights []*Light) {
+	clusters := len(c.counts)
+	if c.textures == nil {
+		c.lights = make(Std140Buffer, c.config.MaxLights*LightStruct.Size())
+		c.textures = map[string]Texture{
+			LightsSampler:        newLightTexture(uint32(LightStruct.Size()/16), uint32(c.config.MaxLights), TextureFormatRGBA, TextureSizedFormatRGBA32F),
+			LightClustersSampler: newLightTexture(uint32(c.config.TilesX*c.config.TilesY), uint32(c.config.Slices), TextureFormatRG, TextureSizedFormatRG32F),
+			LightIndicesSampler:  newLightTexture(lightIndexWidth, 1, TextureFormatR, TextureSizedFormatR32F),
+		}
+	}
+
+	rows := Std140Field{Struct: LightStruct, Length: c.config.MaxLights, ArrayStride: LightStruct.Size()}
+	for i, l := range lights[:c.lightCount] {
+		l.Block.Pack(c.lights, rows.Index(i))
+	}
+	c.textures[LightsSampler].SetLayer(0, 0, c.lights)
+
+	if len(c.clusterData) != clusters*8 {
+		c.clusterData = make([]byte, clusters*8)
+	}
+	for cluster := range c.counts {
+		putFloat32(c.clusterData[cluster*8:], float32(c.offsets[cluster]))
+		putFloat32(c.clusterData[cluster*8+4:], float32(c.counts[cluster]))
+	}
+	c.textures[LightClustersSampler].SetLayer(0, 0, c.clusterData)
+
+	// the index texture grows to the next power of two rows
+	height := 1
+	for height*lightIndexWidth < len(c.indices) {
+		height *= 2
+	}
+	if len(c.indexData) != height*lightIndexWidth*4 {
+		c.indexData = make([]byte, height*lightIndexWidth*4)
+	}
+	for i, light := range c.indices {
+		putFloat32(c.indexData[i*4:], float32(light))
+	}
+
+	indices := c.textures[LightIndicesSampler]
+	if d := indices.Descriptor(); int(d.Height) != height {
+		d.Height = uint32(height)
+		indices.SetImage(&DecodedImage{Descriptor: d, Levels: [][]byte{c.indexData}})
+	} else {
+		indices.SetLayer(0, 0, c.indexData)
+	}
+}
+
+// newLightTexture returns a float texture of the cluster textures, sampled with texelFetch
+func newLightTexture(width, height uint32, format TextureFormat, sizedFormat TextureSizedFormat) Texture {
+	return renderSystem.NewTexture(TextureDescriptor{
+		Width:         width,
+		Height:        height,
+		Target:        TextureTarget2D,
+		Format:        format,
+		SizedFormat:   sizedFormat,
+		ComponentType: TextureComponentTypeFLOAT,
+		Filter:        TextureFilterNearest,
+		WrapMode:      TextureWrapModeClampEdge,
+	}, nil)
+}
+
+// putFloat32 writes a float in the byte order of the texture data
+func putFloat32(b []byte, v float32) {
+	binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+}
+
+// lightsGLSL returns gosg/lights.glsl, which declares the cluster textures, the light accessors and the cluster
+// lookup functions
+func lightsGLSL() string {
+	return "// generated from core.LightStruct\n" +
+		"#include \"gosg/camera.glsl\"\n\n" +
+		fmt.Sprintf("uniform sampler2D %s;\nuniform sampler2D %s;\nuniform sampler2D %s;\n\n", LightsSampler, LightClustersSampler, LightIndicesSampler) +
+		LightStruct.TexelAccessors("light", LightsSampler) +
+		fmt.Sprintf(`// lightCluster returns the offset and count of the lights of the cluster holding a world position
+ivec2 lightCluster(vec3 worldPosition) {
+    if (lightCount.x == 0.0) {
+        return ivec2(0);
+    }
+
+    vec4 clip = vpMatrix * vec4(worldPosition, 1.0);
+    ivec2 tile = clamp(ivec2((clip.xy / clip.w * 0.5 + 0.5) * lightClusters.xy), ivec2(0), ivec2(lightClusters.xy) - 1);
+
+    float depth = -(vMatrix * vec4(worldPosition, 1.0)).z;
+    float d = lightClusters.w > 0.0 ? log(max(depth, 0.000001)) : depth;
+    int slice = clamp(int(floor(d * lightClusterDepth.x + lightClusterDepth.y)), 0, int(lightClusters.z) - 1);
+
+    return ivec2(texelFetch(%[1]s, ivec2(tile.x + tile.y * int(lightClusters.x), slice), 0).xy);
+}
+
+// clusterLight returns the light at an offset of the cluster light lists
+int clusterLight(int i) {
+    return int(texelFetch(%[2]s, ivec2(i %% %[3]d, i / %[3]d), 0).x);
+}
+
+// lightAttenuation returns the falloff of a light at a distance, 1 for lights without a radius
+float lightAttenuation(int light, float distance) {
+    float radius = lightRadius(light);
+    if (radius <= 0.0) {
+        return 1.0;
+    }
+
+    float x = distance / radius;
+    float window = clamp(1.0 - x * x * x * x, 0.0, 1.0);
+    return window * window / (distance * distance + 1.0);
+}
+`, LightClustersSampler, LightIndicesSampler, lightIndexWidth)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestLightClusterSlices(t *testing.T) {
+	c := newLightClusters(LightClusterConfig{TilesX: 4, TilesY: 2, Slices: 8, MaxLights: 4, MaxClusterLights: 2})
+
+	c.setProjection(mgl64.Perspective(math.Pi/2.0, 2.0, 1.0, 100.0))
+	if !c.perspective || math.Abs(c.near-1.0) > 1e-9 || math.Abs(c.far-100.0) > 1e-6 {
+		t.Errorf("perspective projection has depth range %v-%v", c.near, c.far)
+	}
+	for _, depth := range []float64{1.0, 2.5, 9.0, 40.0, 99.0} {
+		slice := c.slice(depth)
+		if depth < c.sliceDepth(slice)-1e-9 || depth >= c.sliceDepth(slice+1) {
+			t.Errorf("depth %v is outside of slice %d, %v-%v", depth, slice, c.sliceDepth(slice), c.sliceDepth(slice+1))
+		}
+	}
+	if c.slice(0.5) != 0 || c.slice(500.0) != 7 {
+		t.Errorf("depths outside of the frustum should clamp to the first and last slices")
+	}
+
+	c.setProjection(mgl64.Ortho(-1.0, 1.0, -1.0, 1.0, 2.0, 10.0))
+	if c.perspective || math.Abs(c.near-2.0) > 1e-9 || math.Abs(c.far-10.0) > 1e-9 {
+		t.Errorf("orthographic projection has depth range %v-%v", c.near, c.far)
+	}
+	if c.slice(2.0) != 0 || c.slice(3.0) != 1 || c.slice(9.99) != 7 {
+		t.Errorf("orthographic slices should be linear")
+	}
+}
+
+func TestLightClusterAssignment(t *testing.T) {
+	c := newLightClusters(LightClusterConfig{TilesX: 4, TilesY: 2, Slices: 8, MaxLights: 4, MaxClusterLights: 2})
+
+	light := func(x, y, z float32, radius float32) *Light {
+		return &Light{Block: LightBlock{Position: mgl32.Vec4{x, y, z, 1.0}, Radius: radius}}
+	}
+	lights := []*Light{
+		light(0, 0, 0, 0),    // lights every cluster
+		light(0, 0, -10, 1),  // ahead of the camera
+		light(0, 0, 10, 1),   // behind the camera
+		light(50, 0, 0, 0),   // dropped where clusters are full
+		light(0, 0, -10, 50), // beyond MaxLights
+	}
+
+	// the camera looks down -z, with 45 degrees to the sides and up
+	c.assign(mgl64.Perspective(math.Pi/2.0, 1.0, 1.0, 100.0), mgl64.Ident4(), lights)
+	if c.lightCount != 4 {
+		t.Errorf("assigned %d lights, expected 4", c.lightCount)
+	}
+
+	list := func(x, y, slice int) []int {
+		cluster := x + y*4 + slice*8
+		return c.indices[c.offsets[cluster] : c.offsets[cluster]+c.counts[cluster]]
+	}
+
+	// the sphere at depth 9 to 11 covers slices 3 and 4 and the middle tiles, 1/9 of the viewport each way
+	for slice := 0; slice < 8; slice++ {
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 4; x++ {
+				expected := []int{0, 3}
+				if (slice == 3 || slice == 4) && (x == 1 || x == 2) {
+					expected = []int{0, 1}
+				}
+				if l := list(x, y, slice); !reflect.DeepEqual(l, expected) {
+					t.Errorf("cluster %d,%d,%d has lights %v, expected %v", x, y, slice, l, expected)
+				}
+			}
+		}
+	}
+	if !c.warned {
+		t.Error("dropping lights from full clusters should be reported")
+	}
+
+	// lists are rebuilt every frame
+	c.assign(mgl64.Perspective(math.Pi/2.0, 1.0, 1.0, 100.0), mgl64.Ident4(), lights[1:2])
+	if len(c.indices) != 8 || !reflect.DeepEqual(list(1, 0, 3), []int{0}) {
+		t.Errorf("reassigned lights %v", c.indices)
+	}
+}
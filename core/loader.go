@@ -0,0 +1,397 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// LoadHandle tracks an asynchronous resource load. All of its methods and callbacks must be used from the main
+// thread, callbacks are invoked by the ResourceManager while it processes uploads at the start of each frame.
+type LoadHandle struct {
+	name        string
+	placeholder *Node
+	texture     Texture
+	steps       int
+	totalSteps  int
+	done        bool
+	err         error
+	onProgress  []func(*LoadHandle)
+	onComplete  []func(*LoadHandle)
+}
+
+// Name returns the name of the resource being loaded.
+func (h *LoadHandle) Name() string {
+	return h.name
+}
+
+// Node returns the placeholder node for a model load. It is empty until the model is ready, at which point the
+// model's meshes are attached to it as children, or the fallback model's if the load failed. It can be inserted
+// into the scenegraph right away. Returns nil for texture loads.
+func (h *LoadHandle) Node() *Node {
+	return h.placeholder
+}
+
+// Texture returns the loaded texture for a texture load, or nil until it is ready. Failed loads return the
+// fallback texture.
+func (h *LoadHandle) Texture() Texture {
+	return h.texture
+}
+
+// Progress returns the fraction of the load which is complete, from 0 to 1.
+func (h *LoadHandle) Progress() float64 {
+	if h.done {
+		return 1.0
+	}
+	if h.totalSteps == 0 {
+		return 0.0
+	}
+	return float64(h.steps) / float64(h.totalSteps)
+}
+
+// Done returns whether the load has finished, successfully or not.
+func (h *LoadHandle) Done() bool {
+	return h.done
+}
+
+// Err returns the error which caused the load to fail, if any.
+func (h *LoadHandle) Err() error {
+	return h.err
+}
+
+// OnProgress registers a callback which is called every time the load progresses.
+func (h *LoadHandle) OnProgress(fn func(*LoadHandle)) {
+	h.onProgress = append(h.onProgress, fn)
+}
+
+// OnComplete registers a callback which is called when the load finishes. If it already has, the callback
+// is called immediately.
+func (h *LoadHandle) OnComplete(fn func(*LoadHandle)) {
+	if h.done {
+		fn(h)
+		return
+	}
+	h.onComplete = append(h.onComplete, fn)
+}
+
+func (h *LoadHandle) addSteps(n int) {
+	h.totalSteps += n
+}
+
+func (h *LoadHandle) step() {
+	h.steps++
+	for _, fn := range h.onProgress {
+		fn(h)
+	}
+}
+
+func (h *LoadHandle) complete(err error) {
+	h.err = err
+	h.done = true
+	for _, fn := range h.onProgress {
+		fn(h)
+	}
+	for _, fn := range h.onComplete {
+		fn(h)
+	}
+	h.onProgress, h.onComplete = nil, nil
+}
+
+// uploadQueue holds jobs which must run on the main thread, typically GPU uploads. Workers push, the main
+// thread pops within a time budget.
+type uploadQueue struct {
+	mutex sync.Mutex
+	jobs  []func()
+}
+
+func (q *uploadQueue) push(job func()) {
+	q.mutex.Lock()
+	q.jobs = append(q.jobs, job)
+	q.mutex.Unlock()
+}
+
+func (q *uploadQueue) pop() func() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.jobs) == 0 {
+		return nil
+	}
+
+	job := q.jobs[0]
+	q.jobs[0] = nil
+	q.jobs = q.jobs[1:]
+	return job
+}
+
+func (q *uploadQueue) len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.jobs)
+}
+
+// workQueueLength is the number of background jobs handed to the worker pool at once, later ones wait on the
+// main thread until workers catch up
+const workQueueLength = 256
+
+// asyncLoader runs file IO and decoding on a pool of worker goroutines and hands results back to the main thread
+type asyncLoader struct {
+	startOnce    sync.Once
+	work         chan func()
+	waiting      []func()
+	uploads      uploadQueue
+	uploadBudget time.Duration
+
+	// loads in flight, later requests for the same resource wait for the first one
+	pendingModels   map[string][]*LoadHandle
+	pendingTextures map[textureKey][]*LoadHandle
+}
+
+func newAsyncLoader() *asyncLoader {
+	return &asyncLoader{
+		uploadBudget:    2 * time.Millisecond,
+		pendingModels:   make(map[string][]*LoadHandle),
+		pendingTextures: make(map[textureKey][]*LoadHandle),
+	}
+}
+
+func (l *asyncLoader) start() {
+	l.startOnce.Do(func() {
+		l.work = make(chan func(), workQueueLength)
+		for i := 0; i < runtime.NumCPU(); i++ {
+			go func() {
+				for job := range l.work {
+					job()
+				}
+			}()
+		}
+	})
+}
+
+// background schedules a job on the worker pool. Jobs start in the order they are scheduled, scheduling never
+// blocks, jobs which don't fit the work queue are handed to the workers by update.
+func (l *asyncLoader) background(job func()) {
+	l.start()
+	l.waiting = append(l.waiting, job)
+	l.dispatch()
+}
+
+// dispatch moves waiting jobs to the work queue until it is full
+func (l *asyncLoader) dispatch() {
+	for len(l.waiting) > 0 {
+		select {
+		case l.work <- l.waiting[0]:
+			l.waiting[0] = nil
+			l.waiting = l.waiting[1:]
+		default:
+			return
+		}
+	}
+}
+
+// update hands waiting jobs to the worker pool and runs queued main thread jobs until the upload budget is spent.
+// At least one job runs per call so loads always make progress.
+func (l *asyncLoader) update() {
+	l.dispatch()
+
+	start := time.Now()
+	for job := l.uploads.pop(); job != nil; job = l.uploads.pop() {
+		job()
+		if time.Since(start) >= l.uploadBudget {
+			break
+		}
+	}
+}
+
+// SetUploadBudget sets the maximum time per frame spent on the main thread creating GPU resources for
+// asynchronous loads. At least one upload is always performed per frame.
+func (r *ResourceManager) SetUploadBudget(budget time.Duration) {
+	r.loader.uploadBudget = budget
+}
+
+// PendingUploads returns the number of main thread upload jobs waiting to run.
+func (r *ResourceManager) PendingUploads() int {
+	return r.loader.uploads.len()
+}
+
+// ModelAsync starts loading a model in the background and returns a handle to track it. File IO, unmarshalling
+// and image decoding run on worker goroutines, textures and meshes are created on the main thread a few at a time
+// every frame. The handle's node is returned immediately and receives a copy of the model's meshes once they are
+// all uploaded. Models are cached like with Model, a successful load adds a reference which must be dropped by
+// passing the handle's node to ReleaseModel.
+func (r *ResourceManager) ModelAsync(name string) *LoadHandle {
+	h := &LoadHandle{name: name, placeholder: NewNode(filepath.Base(name))}
+
+	// cached, complete on the next frame so callers can register callbacks
+	if entry := r.models[name]; entry != nil {
+		entry.acquire(r.frame)
+		r.loader.uploads.push(func() {
+			r.attachModel(h, name, entry)
+			h.complete(nil)
+		})
+		return h
+	}
+
+	// already in flight
+	if pending, ok := r.loader.pendingModels[name]; ok {
+		r.loader.pendingModels[name] = append(pending, h)
+		return h
+	}
+	r.loader.pendingModels[name] = []*LoadHandle{h}
+
+	system := r.system
+	r.loader.background(func() {
+		resource, err := system.Model(name)
+		if err != nil {
+			r.loader.uploads.push(func() { r.finishModel(name, nil, fmt.Errorf("cannot load model %s: %v", name, err)) })
+			return
+		}
+
+		model, err := unmarshalModel(resource)
+		if err != nil {
+			r.loader.uploads.push(func() { r.finishModel(name, nil, fmt.Errorf("cannot load model %s: %v", name, err)) })
+			return
+		}
+
+		// read + unmarshal + images + uploads, no progress is reported before the total is known
+		imageCount := embeddedImageCount(model)
+		r.loader.uploads.push(func() {
+			h.addSteps(2 + imageCount + len(model.Meshes))
+			h.steps++
+			h.step()
+		})
+
+		dm := decodeModel(name, model, func() { r.loader.uploads.push(h.step) })
+
+		r.queueModelUploads(name, dm, h)
+	})
+
+	return h
+}
+
+// queueModelUploads queues one main thread job per mesh and a final job which caches the model and
+// completes all handles waiting on it.
+func (r *ResourceManager) queueModelUploads(name string, dm *decodedModel, h *LoadHandle) {
+	basename := filepath.Base(name)
+	parentNode := NewNode(basename)
+
+	for i := range dm.model.Meshes {
+		i := i
+		r.loader.uploads.push(func() {
+			parentNode.AddChild(dm.buildMesh(basename, i))
+
+			// images decoded for this mesh were already accounted for while decoding
+			h.step()
+		})
+	}
+
+	r.loader.uploads.push(func() { r.finishModel(name, parentNode, nil) })
+}
+
+func (r *ResourceManager) finishModel(name string, model *Node, err error) {
+	var entry *modelEntry
+	if err != nil {
+		glog.Warning(err)
+	} else {
+		entry = newModelEntry(model)
+		r.models[name] = entry
+	}
+
+	for _, h := range r.loader.pendingModels[name] {
+		if entry != nil {
+			entry.acquire(r.frame)
+			r.attachModel(h, name, entry)
+		} else {
+			for _, c := range r.fallbackModel().Copy().children {
+				h.placeholder.AddChild(c)
+			}
+		}
+		h.complete(err)
+	}
+	delete(r.loader.pendingModels, name)
+}
+
+// attachModel attaches a copy of the cached model's meshes to the handle's placeholder node, which holds the
+// handle's model reference
+func (r *ResourceManager) attachModel(h *LoadHandle, name string, entry *modelEntry) {
+	for _, c := range entry.node.Copy().children {
+		h.placeholder.AddChild(c)
+	}
+	r.modelCopies[h.placeholder] = name
+}
+
+// TextureAsync starts loading and decoding a texture image in the background and returns a handle to track
+// it. The texture is created on the main thread once decoded. Textures are shared with Texture, a successful
+// load adds a reference which must be dropped with ReleaseTexture.
+func (r *ResourceManager) TextureAsync(name string, descriptor TextureDescriptor) *LoadHandle {
+	h := &LoadHandle{name: name}
+	key := textureKey{name, descriptor}
+
+	// cached, complete on the next frame so callers can register callbacks
+	if entry, ok := r.textures[key]; ok {
+		entry.acquire(r.frame)
+		r.loader.uploads.push(func() {
+			h.texture = entry.texture
+			h.complete(nil)
+		})
+		return h
+	}
+
+	// already in flight
+	if pending, ok := r.loader.pendingTextures[key]; ok {
+		r.loader.pendingTextures[key] = append(pending, h)
+		return h
+	}
+	r.loader.pendingTextures[key] = []*LoadHandle{h}
+
+	// read, decode, upload
+	h.addSteps(3)
+
+	system := r.system
+	r.loader.background(func() {
+		resource, err := system.Texture(name)
+		if err != nil {
+			r.loader.uploads.push(func() { r.finishTexture(key, nil, fmt.Errorf("cannot load texture %s: %v", name, err)) })
+			return
+		}
+		r.loader.uploads.push(h.step)
+
+		img, err := DecodeImage(resource, descriptor)
+		if err != nil {
+			r.loader.uploads.push(func() { r.finishTexture(key, nil, fmt.Errorf("cannot decode texture %s: %v", name, err)) })
+			return
+		}
+		r.loader.uploads.push(h.step)
+
+		r.loader.uploads.push(func() {
+			h.step()
+			r.finishTexture(key, img, nil)
+		})
+	})
+
+	return h
+}
+
+func (r *ResourceManager) finishTexture(key textureKey, img *DecodedImage, err error) {
+	var texture Texture
+	if err != nil {
+		glog.Warning(err)
+		texture = r.fallbackTexture()
+	} else {
+		texture = r.addTexture(key, renderSystem.NewTextureFromImage(img))
+	}
+
+	for i, h := range r.loader.pendingTextures[key] {
+		// addTexture took the first reference
+		if err == nil && i > 0 {
+			r.textures[key].acquire(r.frame)
+		}
+		h.texture = texture
+		h.complete(err)
+	}
+	delete(r.loader.pendingTextures, key)
+}
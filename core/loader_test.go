@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pumpUploads runs the main thread side of the loader until the handle completes
+func pumpUploads(t *testing.T, h *LoadHandle) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !h.Done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out loading %s", h.Name())
+		}
+		resourceManager.update()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestModelAsync(t *testing.T) {
+	setupTestSystems()
+
+	var buf bytes.Buffer
+	if err := WriteModel(&buf, testSubtree(t), ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	testResources.Lock()
+	testResources.models["async.model"] = buf.Bytes()
+	testResources.Unlock()
+
+	h1 := resourceManager.ModelAsync("async.model")
+	h2 := resourceManager.ModelAsync("async.model")
+
+	var lastProgress float64
+	h1.OnProgress(func(h *LoadHandle) {
+		if h.Progress() < lastProgress {
+			t.Errorf("progress went backwards: %f -> %f", lastProgress, h.Progress())
+		}
+		lastProgress = h.Progress()
+	})
+
+	completed := 0
+	h1.OnComplete(func(*LoadHandle) { completed++ })
+	h2.OnComplete(func(*LoadHandle) { completed++ })
+
+	if len(h1.Node().Children()) != 0 {
+		t.Error("placeholder should be empty before the model is uploaded")
+	}
+
+	pumpUploads(t, h1)
+	pumpUploads(t, h2)
+
+	for _, h := range []*LoadHandle{h1, h2} {
+		if h.Err() != nil {
+			t.Fatal(h.Err())
+		}
+		if len(h.Node().Children()) != 2 {
+			t.Errorf("expected 2 meshes on the placeholder, got %d", len(h.Node().Children()))
+		}
+	}
+
+	if completed != 2 || lastProgress != 1.0 {
+		t.Errorf("expected 2 completions at full progress, got %d at %f", completed, lastProgress)
+	}
+
+	// cached models complete on the next update
+	h3 := resourceManager.ModelAsync("async.model")
+	pumpUploads(t, h3)
+	if len(h3.Node().Children()) != 2 {
+		t.Errorf("expected 2 meshes from the cached model, got %d", len(h3.Node().Children()))
+	}
+}
+
+func TestTextureAsync(t *testing.T) {
+	setupTestSystems()
+
+	testResources.Lock()
+	testResources.textures["async.png"] = testPNG(t)
+	testResources.textures["broken.png"] = []byte("not an image")
+	testResources.Unlock()
+
+	h := resourceManager.TextureAsync("async.png", TextureDescriptor{})
+	pumpUploads(t, h)
+	if h.Err() != nil || h.Texture() == nil {
+		t.Fatalf("expected a texture, got error %v", h.Err())
+	}
+	if d := h.Texture().Descriptor(); d.Width != 2 || d.Height != 2 {
+		t.Errorf("unexpected texture size %dx%d", d.Width, d.Height)
+	}
+
+	h = resourceManager.TextureAsync("broken.png", TextureDescriptor{})
+	pumpUploads(t, h)
+	if h.Err() == nil {
+		t.Error("expected a decode error")
+	}
+}
+
+func TestBackgroundNeverBlocks(t *testing.T) {
+	l := newAsyncLoader()
+
+	// more jobs than the workers and the work queue can hold while every worker is busy
+	release := make(chan struct{})
+	var finished int32
+	count := workQueueLength + runtime.NumCPU() + 16
+	scheduled := make(chan struct{})
+	go func() {
+		for i := 0; i < count; i++ {
+			l.background(func() {
+				<-release
+				atomic.AddInt32(&finished, 1)
+			})
+		}
+		close(scheduled)
+	}()
+
+	select {
+	case <-scheduled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduling blocked while the workers were busy")
+	}
+
+	// waiting jobs reach the workers as the main thread updates
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&finished) != int32(count) {
+		if time.Now().After(deadline) {
+			t.Fatalf("finished %d of %d jobs", atomic.LoadInt32(&finished), count)
+		}
+		l.update()
+		time.Sleep(time.Millisecond)
+	}
+}
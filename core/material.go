@@ -0,0 +1,289 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fcvarela/gosg/protos"
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+)
+
+// Material is a state with default uniforms, uniform buffers and textures shared by many nodes. Nodes reference a
+// material with SetMaterial and override individual values through their MaterialData, like material instances.
+// Nodes sharing a material and overriding nothing are batched together.
+//
+// Material resources are JSON files naming a state, uniform values and textures by resource name:
+//
+//	{
+//	    "state": "pbr-opaque",
+//	    "uniforms": {"roughness": 0.5, "tint": [1, 0.8, 0.8, 1]},
+//	    "textures": {"albedoTex": "bricks.png", "normalTex": "bricks-normal.png"},
+//	    "keywords": ["NORMAL_MAP"]
+//	}
+//
+// Keywords are added to the state's program keywords, drawing the material with a variant of the state's
+// program. Materials with keywords draw with a copy of their state which is updated when the state is reloaded.
+//
+// Uniforms are numbers or arrays of numbers, matrices in column major order, converted to the type the state's
+// program declares. Materials setting uniforms the program doesn't have or with the wrong number of components
+// fail to load. Textures are loaded with the texture descriptor used for model textures.
+type Material struct {
+	id    uint64
+	name  string
+	state *protos.State
+	data  MaterialData
+}
+
+var materialIDs uint64
+
+// NewMaterial returns a new material named `name` drawn with the given state.
+func NewMaterial(name string, state *protos.State) *Material {
+	materialIDs++
+	return &Material{materialIDs, name, state, NewMaterialData()}
+}
+
+// identity returns the material's id for sorting, 0 for no material
+func (m *Material) identity() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.id
+}
+
+// Name returns the material's name
+func (m *Material) Name() string {
+	return m.name
+}
+
+// State returns the material's state
+func (m *Material) State() *protos.State {
+	return m.state
+}
+
+// SetState sets the material's state
+func (m *Material) SetState(state *protos.State) {
+	m.state = state
+}
+
+// MaterialData returns the material's defaults. Changing them affects every node using the material.
+func (m *Material) MaterialData() *MaterialData {
+	return &m.data
+}
+
+// SetUniform sets a default uniform of the material. It returns an error if the program of the material's state
+// has no such active uniform or its type differs.
+func (m *Material) SetUniform(name string, v UniformValue) error {
+	return m.data.setUniform(m.state, name, v)
+}
+
+// materialSpec is the JSON encoding of a material resource
+type materialSpec struct {
+	State    string                 `json:"state"`
+	Uniforms map[string]interface{} `json:"uniforms"`
+	Textures map[string]string      `json:"textures"`
+	Keywords []string               `json:"keywords"`
+}
+
+// materialEntry is a cached material and the textures and program it acquired. Materials which failed to load are
+// cached as fallback entries until they are reloaded.
+type materialEntry struct {
+	resourceEntry
+	material *Material
+	textures []Texture
+	program  string
+	fallback bool
+}
+
+// Material returns a shared material and adds a reference to it, which must be dropped with ReleaseMaterial. If
+// the material can't be loaded the error is logged and a material drawing with the fallback state and texture
+// is returned, reloading the material replaces it in place.
+func (r *ResourceManager) Material(name string) *Material {
+	material, err := r.TryMaterial(name)
+	if err != nil {
+		glog.Error(err)
+
+		material = NewMaterial(name, r.fallbackState(name))
+		material.data.SetTexture(materialTextureNames[0], r.fallbackTexture())
+		r.AcquireProgram(StateProgram(material.state))
+
+		entry := &materialEntry{material: material, program: StateProgram(material.state), fallback: true}
+		entry.acquire(r.frame)
+		r.materials[name] = entry
+	}
+	return material
+}
+
+// TryMaterial is like Material but returns an error if the material can't be loaded.
+func (r *ResourceManager) TryMaterial(name string) (*Material, error) {
+	if entry, ok := r.materials[name]; ok {
+		entry.acquire(r.frame)
+		return entry.material, nil
+	}
+
+	spec, err := r.readMaterial(name)
+	if err != nil {
+		return nil, err
+	}
+
+	material := NewMaterial(name, nil)
+	entry := &materialEntry{material: material}
+	if err := r.applyMaterial(entry, spec); err != nil {
+		return nil, err
+	}
+
+	entry.acquire(r.frame)
+	r.materials[name] = entry
+	return material, nil
+}
+
+// ReleaseMaterial drops a reference added by Material or TryMaterial. Unreferenced materials are unloaded and
+// release their textures, nodes still using them keep working.
+func (r *ResourceManager) ReleaseMaterial(name string) {
+	entry, ok := r.materials[name]
+	if !ok || entry.refs == 0 {
+		return
+	}
+
+	entry.refs--
+	if entry.refs == 0 {
+		r.unloadMaterial(name)
+	}
+}
+
+func (r *ResourceManager) unloadMaterial(name string) {
+	entry := r.materials[name]
+	delete(r.materials, name)
+
+	if entry.program != "" {
+		r.ReleaseProgram(entry.program)
+	}
+	for _, t := range entry.textures {
+		r.ReleaseTexture(t)
+	}
+}
+
+// readMaterial reads and unmarshals a material resource
+func (r *ResourceManager) readMaterial(name string) (*materialSpec, error) {
+	resource, err := r.system.Material(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load material %s: %v", name, err)
+	}
+
+	var spec materialSpec
+	if err := json.Unmarshal(resource, &spec); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal material %s: %v", name, err)
+	}
+	if spec.State == "" {
+		return nil, fmt.Errorf("material %s has no state", name)
+	}
+	return &spec, nil
+}
+
+// applyMaterial sets a cached material's state, uniforms and textures from its resource, releasing the textures
+// and program it previously held. Nothing is changed if a uniform can't be converted.
+func (r *ResourceManager) applyMaterial(entry *materialEntry, spec *materialSpec) error {
+	state := r.State(spec.State)
+	variant := ""
+	if len(spec.Keywords) > 0 {
+		state = proto.Clone(state).(*protos.State)
+		state.ProgramKeywords = append(state.ProgramKeywords, spec.Keywords...)
+		variant = StateProgram(state)
+	}
+	program := r.Program(StateProgram(state))
+
+	uniforms := make(map[string]Uniform, len(spec.Uniforms))
+	for name, value := range spec.Uniforms {
+		info, ok := program.Uniform(name)
+		if !ok {
+			return fmt.Errorf("material %s: program %s has no active uniform %s", entry.material.name, program.Name(), name)
+		}
+
+		v, err := materialUniformValue(value, info.Type)
+		if err == nil {
+			err = info.Validate(v)
+		}
+		if err != nil {
+			return fmt.Errorf("material %s: %v", entry.material.name, err)
+		}
+
+		uniforms[name] = renderSystem.NewUniform()
+		uniforms[name].Set(v)
+	}
+
+	textures := make(map[string]Texture, len(spec.Textures))
+	var acquired []Texture
+	for sampler, textureName := range spec.Textures {
+		texture := r.Texture(textureName, modelTextureDescriptor)
+		textures[sampler] = texture
+		acquired = append(acquired, texture)
+	}
+
+	// the state holds a reference to its program, variants selected by the material are held by the entry
+	if variant != "" {
+		r.AcquireProgram(variant)
+	}
+	if entry.program != "" {
+		r.ReleaseProgram(entry.program)
+	}
+	for _, t := range entry.textures {
+		r.ReleaseTexture(t)
+	}
+
+	m := entry.material
+	m.state = state
+	m.data.uniforms = uniforms
+	m.data.textures = textures
+	entry.textures = acquired
+	entry.program = variant
+	entry.fallback = false
+	return nil
+}
+
+// materialUniformValue converts a JSON number or array of numbers to a uniform value of the given type
+func materialUniformValue(value interface{}, t UniformType) (UniformValue, error) {
+	var components []float32
+	switch v := value.(type) {
+	case float64:
+		components = []float32{float32(v)}
+	case []interface{}:
+		for _, e := range v {
+			f, ok := e.(float64)
+			if !ok {
+				return UniformValue{}, fmt.Errorf("arrays must only contain numbers")
+			}
+			components = append(components, float32(f))
+		}
+	default:
+		return UniformValue{}, fmt.Errorf("unsupported value %v", value)
+	}
+
+	return NewUniformValue(t, components)
+}
+
+// reloadMaterial updates a cached material in place, nodes using it pick up the change
+func (r *ResourceManager) reloadMaterial(name string) {
+	entry, ok := r.materials[name]
+	if !ok {
+		return
+	}
+
+	spec, err := r.readMaterial(name)
+	if err == nil {
+		err = r.applyMaterial(entry, spec)
+	}
+	if err != nil {
+		glog.Errorf("Keeping previous material: %v", err)
+		return
+	}
+	glog.Info("Reloaded material ", name)
+}
+
+// reloadStateMaterials reloads the materials drawing with copies of the given states to select program variants
+func (r *ResourceManager) reloadStateMaterials(states map[string]bool) {
+	for name, entry := range r.materials {
+		if entry.program != "" && !entry.fallback && states[entry.material.state.Name] {
+			r.reloadMaterial(name)
+		}
+	}
+}
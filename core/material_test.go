@@ -0,0 +1,182 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestMaterial(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeTexture, "material-albedo.png", testPNG(t))
+	testResources.change(ResourceTypeTexture, "material-other.png", testPNG(t))
+	testResources.change(ResourceTypeProgram, "material", []byte("uniform float roughness\nuniform vec4 tint"))
+	testResources.change(ResourceTypeState, "material-state", []byte(`{"programName": "material"}`))
+	testResources.change(ResourceTypeMaterial, "bricks", []byte(`{"state": "material-state", "uniforms": {"roughness": 0.5, "tint": [1, 0.5, 0.5, 1]}, "textures": {"albedoTex": "material-albedo.png"}}`))
+
+	material := resourceManager.Material("bricks")
+	defer resourceManager.ReleaseMaterial("bricks")
+	if resourceManager.Material("bricks") != material {
+		t.Fatal("materials should be shared")
+	}
+	resourceManager.ReleaseMaterial("bricks")
+
+	a, b := NewNode("a"), NewNode("b")
+	a.SetMaterial(material)
+	b.SetMaterial(material)
+
+	if a.State() == nil || a.State().ProgramName != "material" {
+		t.Errorf("nodes should draw with their material's state, got %v", a.State())
+	}
+	if a.MaterialData().Texture("albedoTex") == nil {
+		t.Error("nodes should use their material's textures")
+	}
+	if tint := a.MaterialData().Uniforms()["tint"].Value(); !tint.Equal(UniformVec4(mgl64.Vec4{1, 0.5, 0.5, 1})) {
+		t.Errorf("unexpected tint %v", tint)
+	}
+	if !a.MaterialData().Equal(b.MaterialData()) {
+		t.Error("nodes sharing a material should batch")
+	}
+
+	// uniform overrides are per node
+	if err := a.SetUniform("roughness", UniformFloat(1)); err != nil {
+		t.Fatal(err)
+	}
+	if roughness := b.MaterialData().Uniforms()["roughness"].Value(); !roughness.Equal(UniformFloat(0.5)) {
+		t.Errorf("overriding a uniform changed the material, roughness %v", roughness)
+	}
+
+	// texture overrides break batches and are kept by copies
+	other := resourceManager.Texture("material-other.png", modelTextureDescriptor)
+	defer resourceManager.ReleaseTexture(other)
+	b.MaterialData().SetTexture("albedoTex", other)
+	if a.MaterialData().Equal(b.MaterialData()) {
+		t.Error("nodes overriding textures should not batch")
+	}
+
+	parent := NewNode("parent")
+	parent.AddChild(b)
+	c := parent.Copy().Children()[0]
+	if c.Material() != material || c.MaterialData().Texture("albedoTex") != other {
+		t.Error("copies should keep the material and overrides")
+	}
+	c.MaterialData().SetTexture("normalTex", other)
+	if b.MaterialData().Texture("normalTex") != nil {
+		t.Error("copies should not share overrides")
+	}
+
+	// reloading updates the material in place
+	testResources.change(ResourceTypeMaterial, "bricks", []byte(`{"state": "material-state", "uniforms": {"roughness": 0.25}}`))
+	resourceManager.update()
+	if roughness := b.MaterialData().Uniforms()["roughness"].Value(); !roughness.Equal(UniformFloat(0.25)) {
+		t.Errorf("material not reloaded, roughness %v", roughness)
+	}
+	if a.MaterialData().Texture("albedoTex") != nil {
+		t.Error("reloaded material kept a removed texture")
+	}
+}
+
+func TestMaterialFallback(t *testing.T) {
+	setupTestSystems()
+
+	material := resourceManager.Material("material-missing")
+	defer resourceManager.ReleaseMaterial("material-missing")
+
+	if material.State() == nil || material.State().ProgramName != DefaultFallbackProgram {
+		t.Errorf("missing materials should draw with the fallback state, got %v", material.State())
+	}
+	if material.MaterialData().Texture(materialTextureNames[0]) == nil {
+		t.Error("missing materials should use the fallback texture")
+	}
+}
+
+func TestNodesByMaterialMesh(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeProgram, "sorted", []byte("uniform float roughness"))
+	testResources.change(ResourceTypeState, "sorted-state", []byte(`{"programName": "sorted"}`))
+	testResources.change(ResourceTypeMaterial, "sorted", []byte(`{"state": "sorted-state"}`))
+
+	material := resourceManager.Material("sorted")
+	defer resourceManager.ReleaseMaterial("sorted")
+
+	cube, sphere := renderSystem.NewMesh(), renderSystem.NewMesh()
+	cube.SetName("cube")
+	sphere.SetName("sphere")
+
+	// nodes sharing a material but not a mesh cannot be drawn together
+	var nodes []*Node
+	for i, mesh := range []Mesh{sphere, cube, sphere, cube} {
+		n := NewNode(fmt.Sprintf("node%d", i))
+		n.SetMaterial(material)
+		n.SetMesh(mesh)
+		nodes = append(nodes, n)
+	}
+	if !nodes[0].MaterialData().Equal(nodes[1].MaterialData()) {
+		t.Fatal("nodes sharing a material should have equal material data")
+	}
+
+	sort.Sort(NodesByMaterial(nodes))
+	for i, expected := range []Mesh{cube, cube, sphere, sphere} {
+		if nodes[i].Mesh() != expected {
+			t.Errorf("node %d draws %s, expected %s", i, nodes[i].Mesh().Name(), expected.Name())
+		}
+	}
+}
+
+func TestNodesByMaterialTextureOverrides(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeProgram, "overrides", []byte("uniform float roughness"))
+	testResources.change(ResourceTypeState, "overrides-state", []byte(`{"programName": "overrides"}`))
+	testResources.change(ResourceTypeMaterial, "overrides", []byte(`{"state": "overrides-state"}`))
+
+	material := resourceManager.Material("overrides")
+	defer resourceManager.ReleaseMaterial("overrides")
+
+	mesh := renderSystem.NewMesh()
+	low, high := &testTexture{}, &testTexture{}
+	if high.Lt(low) {
+		low, high = high, low
+	}
+
+	// every combination of two overridden samplers, the order must not depend on map iteration
+	var nodes []*Node
+	for i, textures := range [][2]Texture{{low, low}, {low, high}, {high, low}, {high, high}, {low, nil}, {nil, high}} {
+		n := NewNode(fmt.Sprintf("node%d", i))
+		n.SetMaterial(material)
+		n.SetMesh(mesh)
+		for s, texture := range textures {
+			if texture != nil {
+				n.MaterialData().SetTexture(fmt.Sprintf("tex%d", s), texture)
+			}
+		}
+		nodes = append(nodes, n)
+	}
+
+	byMaterial := NodesByMaterial(nodes)
+	for run := 0; run < 20; run++ {
+		for i := range nodes {
+			for j := range nodes {
+				if byMaterial.Less(i, j) && byMaterial.Less(j, i) {
+					t.Fatalf("%s and %s are both less than each other", nodes[i].Name(), nodes[j].Name())
+				}
+				if i != j && !byMaterial.Less(i, j) && !byMaterial.Less(j, i) {
+					t.Fatalf("%s and %s override different textures but sort as equal", nodes[i].Name(), nodes[j].Name())
+				}
+			}
+		}
+	}
+
+	sort.Sort(byMaterial)
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.Name())
+	}
+	if expected := []string{"node5", "node4", "node0", "node1", "node2", "node3"}; fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("sorted %v, expected %v", names, expected)
+	}
+}
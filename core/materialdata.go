@@ -1,10 +1,19 @@
 package core
 
-// MaterialData contains material properties for a specific drawable
+import (
+	"fmt"
+
+	"github.com/fcvarela/gosg/protos"
+)
+
+// MaterialData contains material properties for a specific drawable. If it references a shared Material, the
+// material provides defaults and the values set here override them for this drawable only, like a material
+// instance.
 type MaterialData struct {
 	uniforms       map[string]Uniform
 	uniformBuffers map[string]UniformBuffer
 	textures       map[string]Texture
+	material       *Material
 }
 
 // NewMaterialData returns a new MaterialData
@@ -13,44 +22,175 @@ func NewMaterialData() MaterialData {
 		make(map[string]Uniform),
 		make(map[string]UniformBuffer),
 		make(map[string]Texture),
+		nil,
 	}
 	return s
 }
 
-// Uniforms returns the state's uniform map.
+// Material returns the shared material providing defaults, nil if there is none.
+func (s *MaterialData) Material() *Material {
+	return s.material
+}
+
+// Uniforms returns the state's uniforms, including the material's uniforms which are not overridden. The map must
+// not be modified.
 func (s *MaterialData) Uniforms() map[string]Uniform {
-	return s.uniforms
+	if s.material == nil {
+		return s.uniforms
+	}
+	if len(s.uniforms) == 0 {
+		return s.material.data.uniforms
+	}
+
+	merged := make(map[string]Uniform, len(s.material.data.uniforms)+len(s.uniforms))
+	for name, u := range s.material.data.uniforms {
+		merged[name] = u
+	}
+	for name, u := range s.uniforms {
+		merged[name] = u
+	}
+	return merged
 }
 
-// Uniform returns the uniform with the given name.
+// Uniform returns the uniform with the given name. Uniforms of the material are copied on first access, so setting
+// the returned uniform only affects this drawable. Values set on the returned uniform are not validated, see
+// Node.SetUniform and Material.SetUniform. Render systems pass uniforms to instanced draws per instance
+// where the program supports it, see the render system's documentation, and draw drawables with different values
+// separately otherwise.
 func (s *MaterialData) Uniform(name string) Uniform {
 	_, ok := s.uniforms[name]
 	if ok == false {
-		s.uniforms[name] = renderSystem.NewUniform()
+		if s.material != nil && s.material.data.uniforms[name] != nil {
+			s.uniforms[name] = copyUniform(s.material.data.uniforms[name])
+		} else {
+			s.uniforms[name] = renderSystem.NewUniform()
+		}
 	}
 	return s.uniforms[name]
 }
 
-// SetTexture sets the material's texture named `name` to the provided texture
+// SetTexture sets the material's texture named `name` to the provided texture, overriding the shared material's
+// texture
 func (s *MaterialData) SetTexture(name string, t Texture) {
 	s.textures[name] = t
 }
 
-// Textures returns the material's textures
+// Texture returns the texture named `name`, falling back to the shared material's texture
+func (s *MaterialData) Texture(name string) Texture {
+	if t, ok := s.textures[name]; ok {
+		return t
+	}
+	if s.material != nil {
+		return s.material.data.textures[name]
+	}
+	return nil
+}
+
+// Textures returns the material's textures, including the shared material's textures which are not overridden.
+// The map must not be modified.
 func (s *MaterialData) Textures() map[string]Texture {
-	return s.textures
+	if s.material == nil {
+		return s.textures
+	}
+	if len(s.textures) == 0 {
+		return s.material.data.textures
+	}
+
+	merged := make(map[string]Texture, len(s.material.data.textures)+len(s.textures))
+	for name, t := range s.material.data.textures {
+		merged[name] = t
+	}
+	for name, t := range s.textures {
+		merged[name] = t
+	}
+	return merged
 }
 
-// UniformBuffer returns the uniform buffer with the given name
+// UniformBuffer returns the uniform buffer with the given name. Buffers of the shared material are returned as is,
+// they can't be copied, setting them affects every drawable using the material.
 func (s *MaterialData) UniformBuffer(name string) UniformBuffer {
 	_, ok := s.uniformBuffers[name]
 	if !ok {
+		if s.material != nil && s.material.data.uniformBuffers[name] != nil {
+			return s.material.data.uniformBuffers[name]
+		}
 		s.uniformBuffers[name] = renderSystem.NewUniformBuffer()
 	}
 	return s.uniformBuffers[name]
 }
 
-// UniformBuffers returns the state's uniform buffers
+// UniformBuffers returns the state's uniform buffers, including the shared material's buffers which are not
+// overridden. The map must not be modified.
 func (s *MaterialData) UniformBuffers() map[string]UniformBuffer {
-	return s.uniformBuffers
+	if s.material == nil {
+		return s.uniformBuffers
+	}
+	if len(s.uniformBuffers) == 0 {
+		return s.material.data.uniformBuffers
+	}
+
+	merged := make(map[string]UniformBuffer, len(s.material.data.uniformBuffers)+len(s.uniformBuffers))
+	for name, ub := range s.material.data.uniformBuffers {
+		merged[name] = ub
+	}
+	for name, ub := range s.uniformBuffers {
+		merged[name] = ub
+	}
+	return merged
+}
+
+// Equal returns whether two material data bind the same textures and uniform buffers, so their drawables can be
+// batched. Drawables sharing a material are compared by its identity and only their overrides are compared.
+func (s *MaterialData) Equal(o *MaterialData) bool {
+	if s.material != o.material || len(s.textures) != len(o.textures) || len(s.uniformBuffers) != len(o.uniformBuffers) {
+		return false
+	}
+
+	for name, t := range s.textures {
+		if o.textures[name] != t {
+			return false
+		}
+	}
+	for name, ub := range s.uniformBuffers {
+		if o.uniformBuffers[name] != ub {
+			return false
+		}
+	}
+	return true
+}
+
+// setUniform sets a uniform after validating it against the program of the given state
+func (s *MaterialData) setUniform(state *protos.State, name string, v UniformValue) error {
+	if state == nil {
+		return fmt.Errorf("cannot set uniform %s without a state", name)
+	}
+	if err := ValidateUniform(resourceManager.Program(StateProgram(state)), name, v); err != nil {
+		return err
+	}
+
+	s.Uniform(name).Set(v)
+	return nil
+}
+
+// copy returns a copy with its own overrides, sharing textures, uniform buffers and the material
+func (s *MaterialData) copy() MaterialData {
+	c := NewMaterialData()
+	c.material = s.material
+	for name, u := range s.uniforms {
+		c.uniforms[name] = copyUniform(u)
+	}
+	for name, t := range s.textures {
+		c.textures[name] = t
+	}
+	for name, ub := range s.uniformBuffers {
+		c.uniformBuffers[name] = ub
+	}
+	return c
+}
+
+// copyUniform returns a new uniform holding the same value
+func copyUniform(u Uniform) Uniform {
+	c := renderSystem.NewUniform()
+	c.Set(u.Value())
+	return c
 }
@@ -17,7 +17,11 @@ const (
 // Mesh is an interface which wraps handling of geometry.
 type Mesh interface {
 	SetPrimitiveType(PrimitiveType)
+	PrimitiveType() PrimitiveType
 
+	// Vertex attributes hold 3 floats per vertex. Setting an attribute again replaces it in place, keeping
+	// the mesh's other attributes, as long as the vertex count is unchanged. Setting an empty attribute also
+	// keeps the other attributes, meshes without tangents for instance.
 	SetPositions(positions []float32)
 	SetNormals(normals []float32)
 	SetTangents(tangents []float32)
@@ -27,11 +31,23 @@ type Mesh interface {
 	SetInstanceCount(count int)
 	SetModelMatrices(matrices []float32)
 
+	// CPU-side copies of the geometry, as last passed to the setters. These are used
+	// by exporters and tools which can't read back GPU buffers.
+	Positions() []float32
+	Normals() []float32
+	Tangents() []float32
+	Bitangents() []float32
+	TextureCoordinates() []float32
+	Indices() []uint16
+
 	SetName(name string)
 	Name() string
 
 	Draw()
 
+	// Delete frees the mesh's GPU storage. The mesh must not be used afterwards.
+	Delete()
+
 	Bounds() *AABB
 
 	Lt(Mesh) bool
@@ -69,6 +85,50 @@ func NewScreenQuadMesh(width float32, height float32) Mesh {
 	return m
 }
 
+// NewCubeMesh returns a unit cube centered at the origin. Each face has its own vertices with normals, tangents
+// and texture coordinates covering the whole 0-1 range.
+func NewCubeMesh() Mesh {
+	// normal, tangent and bitangent of each face, tangent x bitangent = normal so faces wind counter clockwise
+	faces := [6][3][3]float32{
+		{{+1, 0, 0}, {0, 0, -1}, {0, 1, 0}},
+		{{-1, 0, 0}, {0, 0, +1}, {0, 1, 0}},
+		{{0, +1, 0}, {1, 0, 0}, {0, 0, -1}},
+		{{0, -1, 0}, {1, 0, 0}, {0, 0, +1}},
+		{{0, 0, +1}, {+1, 0, 0}, {0, 1, 0}},
+		{{0, 0, -1}, {-1, 0, 0}, {0, 1, 0}},
+	}
+	corners := [4][2]float32{{-1, -1}, {+1, -1}, {+1, +1}, {-1, +1}}
+
+	var positions, normals, tangents, bitangents, tcoords []float32
+	var indices []uint16
+	for f, face := range faces {
+		n, t, b := face[0], face[1], face[2]
+		for _, c := range corners {
+			for i := 0; i < 3; i++ {
+				positions = append(positions, 0.5*(n[i]+c[0]*t[i]+c[1]*b[i]))
+			}
+			normals = append(normals, n[:]...)
+			tangents = append(tangents, t[:]...)
+			bitangents = append(bitangents, b[:]...)
+			tcoords = append(tcoords, 0.5*(c[0]+1), 0.5*(c[1]+1), 0)
+		}
+
+		base := uint16(f * 4)
+		indices = append(indices, base, base+1, base+2, base+2, base+3, base)
+	}
+
+	m := renderSystem.NewMesh()
+	m.SetPrimitiveType(PrimitiveTypeTriangles)
+	m.SetPositions(positions)
+	m.SetNormals(normals)
+	m.SetTangents(tangents)
+	m.SetBitangents(bitangents)
+	m.SetTextureCoordinates(tcoords)
+	m.SetIndices(indices)
+	m.SetName("Cube")
+	return m
+}
+
 // NewAABBMesh returns a normalized cube centered at the origin. This is
 // used to draw bounding boxes by translating and scaling it according to node bounds.
 func NewAABBMesh() Mesh {
@@ -12,64 +12,127 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
-// LoadModel parses model data from a raw resource and returns a node ready
-// to insert into the screnegraph
-func LoadModel(name string, res []byte) *Node {
+// decodedModel is a model resource with all of its embedded images decoded. Producing one does not touch
+// the RenderSystem, so it can be done on a worker goroutine.
+type decodedModel struct {
+	model  *protos.Model
+	images []map[string]*DecodedImage
+}
+
+// modelTextureDescriptor is used for all textures embedded in models
+var modelTextureDescriptor = TextureDescriptor{
+	Mipmaps:  true,
+	Filter:   TextureFilterMipmapLinear,
+	WrapMode: TextureWrapModeRepeat,
+}
+
+// meshImages returns the embedded images of a mesh keyed by sampler name
+func meshImages(m *protos.Mesh) map[string][]byte {
+	return map[string][]byte{
+		materialTextureNames[0]: m.AlbedoMap,
+		materialTextureNames[1]: m.NormalMap,
+		materialTextureNames[2]: m.RoughMap,
+		materialTextureNames[3]: m.MetalMap,
+	}
+}
+
+// unmarshalModel unmarshals model data
+func unmarshalModel(res []byte) (*protos.Model, error) {
 	var model = &protos.Model{}
 	if err := proto.Unmarshal(res, model); err != nil {
-		glog.Fatalln(err)
+		return nil, err
 	}
+	return model, nil
+}
 
-	basename := filepath.Base(name)
-	parentNode := NewNode(basename)
-	for i := 0; i < len(model.Meshes); i++ {
-		node := NewNode(basename + fmt.Sprintf("-%d", i))
+// decodeModel decodes the embedded images of a model. Images which fail to decode are skipped. The progress
+// callback, if not nil, is called after every image.
+func decodeModel(name string, model *protos.Model, progress func()) *decodedModel {
+	dm := &decodedModel{model, make([]map[string]*DecodedImage, len(model.Meshes))}
+	for i, mesh := range model.Meshes {
+		dm.images[i] = make(map[string]*DecodedImage)
+		for sampler, data := range meshImages(mesh) {
+			if len(data) == 0 {
+				continue
+			}
+
+			img, err := DecodeImage(data, modelTextureDescriptor)
+			if err != nil {
+				glog.Warningf("Cannot decode %s for mesh %d of %s: %v", sampler, i, name, err)
+			} else {
+				dm.images[i][sampler] = img
+			}
+
+			if progress != nil {
+				progress()
+			}
+		}
+	}
 
-		node.state = resourceManager.State(model.Meshes[i].State)
+	return dm
+}
 
-		// get textures
-		textureDescriptor := TextureDescriptor{
-			Mipmaps:  true,
-			Filter:   TextureFilterMipmapLinear,
-			WrapMode: TextureWrapModeRepeat,
+// embeddedImageCount returns the number of embedded images in a model
+func embeddedImageCount(model *protos.Model) int {
+	var count int
+	for _, mesh := range model.Meshes {
+		for _, data := range meshImages(mesh) {
+			if len(data) > 0 {
+				count++
+			}
 		}
+	}
+	return count
+}
 
-		if len(model.Meshes[i].AlbedoMap) > 0 {
-			var albedoTexture = renderSystem.NewTextureFromImageData(model.Meshes[i].AlbedoMap, textureDescriptor)
-			node.MaterialData().SetTexture("albedoTex", albedoTexture)
-		}
+// buildMesh creates the node for mesh i, uploading its textures and geometry. This must run on the main thread.
+func (dm *decodedModel) buildMesh(basename string, i int) *Node {
+	m := dm.model.Meshes[i]
+	node := NewNode(basename + fmt.Sprintf("-%d", i))
 
-		if len(model.Meshes[i].NormalMap) > 0 {
-			var normalTexture = renderSystem.NewTextureFromImageData(model.Meshes[i].NormalMap, textureDescriptor)
-			node.MaterialData().SetTexture("normalTex", normalTexture)
-		}
+	// copies of the model share the mesh's material
+	material := NewMaterial(node.name, resourceManager.State(m.State))
+	for sampler, img := range dm.images[i] {
+		material.data.SetTexture(sampler, renderSystem.NewTextureFromImage(img))
+	}
+	node.SetMaterial(material)
 
-		if len(model.Meshes[i].RoughMap) > 0 {
-			var roughTexture = renderSystem.NewTextureFromImageData(model.Meshes[i].RoughMap, textureDescriptor)
-			node.MaterialData().SetTexture("roughTex", roughTexture)
-		}
+	// set mesh data
+	mesh := renderSystem.NewMesh()
+	mesh.SetName(node.name)
+	setMeshData(mesh, m)
 
-		if len(model.Meshes[i].MetalMap) > 0 {
-			var metalTexture = renderSystem.NewTextureFromImageData(model.Meshes[i].MetalMap, textureDescriptor)
-			node.MaterialData().SetTexture("metalTex", metalTexture)
-		}
+	node.SetMesh(mesh)
+	return node
+}
 
-		// set mesh data
-		mesh := renderSystem.NewMesh()
-		mesh.SetName(node.name)
-		mesh.SetPositions(bytesToFloat(model.Meshes[i].Positions))
-		mesh.SetNormals(bytesToFloat(model.Meshes[i].Normals))
-		mesh.SetTangents(bytesToFloat(model.Meshes[i].Tangents))
-		mesh.SetBitangents(bytesToFloat(model.Meshes[i].Bitangents))
-		mesh.SetTextureCoordinates(bytesToFloat(model.Meshes[i].Tcoords))
-		mesh.SetIndices(bytesToShort(model.Meshes[i].Indices))
-		mesh.SetPrimitiveType(PrimitiveTypeTriangles)
-
-		node.SetMesh(mesh)
-		parentNode.AddChild(node)
+// setMeshData copies a model mesh's geometry into a mesh
+func setMeshData(mesh Mesh, m *protos.Mesh) {
+	mesh.SetPositions(bytesToFloat(m.Positions))
+	mesh.SetNormals(bytesToFloat(m.Normals))
+	mesh.SetTangents(bytesToFloat(m.Tangents))
+	mesh.SetBitangents(bytesToFloat(m.Bitangents))
+	mesh.SetTextureCoordinates(bytesToFloat(m.Tcoords))
+	mesh.SetIndices(bytesToShort(m.Indices))
+	mesh.SetPrimitiveType(PrimitiveTypeTriangles)
+}
+
+// LoadModel parses model data from a raw resource and returns a node ready
+// to insert into the screnegraph
+func LoadModel(name string, res []byte) (*Node, error) {
+	model, err := unmarshalModel(res)
+	if err != nil {
+		return nil, err
+	}
+	dm := decodeModel(name, model, nil)
+
+	basename := filepath.Base(name)
+	parentNode := NewNode(basename)
+	for i := range dm.model.Meshes {
+		parentNode.AddChild(dm.buildMesh(basename, i))
 	}
 
-	return parentNode
+	return parentNode, nil
 }
 
 func bytesToFloat(b []byte) []float32 {
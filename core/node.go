@@ -2,6 +2,7 @@ package core
 
 import (
 	"runtime"
+	"sort"
 
 	"github.com/fcvarela/gosg/protos"
 	"github.com/go-gl/mathgl/mgl64"
@@ -67,35 +68,36 @@ func (a NodesByMaterial) Swap(i, j int) {
 
 // Less implements the sort.Interface interface.
 func (a NodesByMaterial) Less(i, j int) bool {
-	// we want to sort by: program, textures, bufferobjects
-	// this maps to: node.materialName, node.materialData.textures, node.materialData.bufferObjects
+	// we want to sort by: state, program, material, texture overrides
+	// this maps to: node.State(), node.materialData.material, node.materialData.textures
 
-	// sort by materialName first
-	if a[i].state.Name < a[j].state.Name {
+	stateI, stateJ := a[i].State(), a[j].State()
+
+	// sort by state name first
+	if stateI.Name < stateJ.Name {
 		return true
-	} else if a[i].state.Name > a[j].state.Name {
+	} else if stateI.Name > stateJ.Name {
 		return false
 	}
 
-	// if we got here they share the same material, check program
-	if a[i].state.ProgramName < a[j].state.ProgramName {
+	// if we got here they share the same state, check program variant
+	programI, programJ := StateProgram(stateI), StateProgram(stateJ)
+	if programI < programJ {
 		return true
-	} else if a[i].state.ProgramName > a[j].state.ProgramName {
+	} else if programI > programJ {
 		return false
 	}
 
-	// if we got here, they share the same program hence use the same sampler names, look for those textures
-	for textureName := range a[i].materialData.textures {
-		textureI, textureJ := a[i].materialData.textures[textureName], a[j].materialData.textures[textureName]
-		if textureI.Lt(textureJ) {
-			return true
-		} else if textureI.Gt(textureJ) {
-			return false
-		}
+	// if we got here, they share the same program, nodes sharing a material bind the same defaults
+	materialI, materialJ := a[i].materialData.material.identity(), a[j].materialData.material.identity()
+	if materialI < materialJ {
+		return true
+	} else if materialI > materialJ {
+		return false
 	}
 
-	// if we got here, they use the same textures for the same samplers. Check mesh. Do not call this with any nodes
-	// not containing meshes
+	// if we got here, they share the same material, check mesh so nodes drawing the same mesh are adjacent. Do not
+	// call this with any nodes not containing meshes
 	meshI, meshJ := a[i].mesh, a[j].mesh
 	if meshI.Lt(meshJ) {
 		return true
@@ -103,6 +105,13 @@ func (a NodesByMaterial) Less(i, j int) bool {
 		return false
 	}
 
+	// if we got here, they share the same material and mesh, look for the textures they override
+	if texturesLess(a[i].materialData.textures, a[j].materialData.textures) {
+		return true
+	} else if texturesLess(a[j].materialData.textures, a[i].materialData.textures) {
+		return false
+	}
+
 	/*
 		// if we got here, they use the same textures for the same samplers. Check uniform buffers
 		for uniformBufferName := range a[i].materialData.uniformBuffers {
@@ -119,6 +128,35 @@ func (a NodesByMaterial) Less(i, j int) bool {
 	return false
 }
 
+// texturesLess orders texture overrides by sampler name, so the order doesn't depend on map iteration. Samplers
+// without an override sort first.
+func texturesLess(texturesI, texturesJ map[string]Texture) bool {
+	names := make([]string, 0, len(texturesI)+len(texturesJ))
+	for name := range texturesI {
+		names = append(names, name)
+	}
+	for name := range texturesJ {
+		if _, ok := texturesI[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		textureI, okI := texturesI[name]
+		textureJ, okJ := texturesJ[name]
+		if okI != okJ {
+			return okJ
+		}
+		if textureI.Lt(textureJ) {
+			return true
+		} else if textureI.Gt(textureJ) {
+			return false
+		}
+	}
+	return false
+}
+
 // NodesByCameraDistanceNearToFar is used to sort nodes according to camera distance from near to far.
 type NodesByCameraDistanceNearToFar struct {
 	Nodes   []*Node
@@ -267,11 +305,32 @@ func (n *Node) setDirtyBounds() {
 	}
 }
 
-// MaterialName returns the node's material
+// State returns the node's state, or its material's state if it has none of its own
 func (n *Node) State() *protos.State {
+	if n.state == nil && n.materialData.material != nil {
+		return n.materialData.material.state
+	}
 	return n.state
 }
 
+// Material returns the node's shared material, nil if it has none
+func (n *Node) Material() *Material {
+	return n.materialData.material
+}
+
+// SetMaterial sets the node's shared material. The node draws with the material's state, uniforms and textures
+// unless it overrides them in its MaterialData. Nodes sharing a material without overriding textures or uniform
+// buffers are batched together.
+func (n *Node) SetMaterial(m *Material) {
+	n.materialData.material = m
+}
+
+// SetUniform sets a uniform of the node, overriding its material's value. It returns an error if the program of
+// the node's state has no such active uniform or its type differs.
+func (n *Node) SetUniform(name string, v UniformValue) error {
+	return n.materialData.setUniform(n.State(), name, v)
+}
+
 // MaterialData returns the node's state
 func (n *Node) MaterialData() *MaterialData {
 	return &n.materialData
@@ -445,13 +504,7 @@ func (n *Node) Copy() *Node {
 
 	for _, c := range n.children {
 		cc := *c
-		cc.materialData.uniforms = make(map[string]Uniform)
-		for k, v := range c.materialData.uniforms {
-			cc.materialData.Uniform(k).Set(v.Value())
-		}
-		for k, v := range c.materialData.textures {
-			cc.materialData.SetTexture(k, v)
-		}
+		cc.materialData = c.materialData.copy()
 		nc.AddChild(&cc)
 	}
 
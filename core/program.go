@@ -2,6 +2,97 @@ package core
 
 // Program is an interface which wraps a GPU program (OpenGL, etc). This will be removed soon and programs
 // will be accessed by name handle via the resource system or abstracted in opaque material definitions.
+//
+// Programs expose what the compiler reports about them for tools and validation. Inputs optimized out by the
+// compiler are not active and are not reported. Lists are sorted by name.
 type Program interface {
 	Name() string
+
+	// Uniform returns the active uniform with the given name. Uniforms optimized out by the compiler are not
+	// active.
+	Uniform(name string) (UniformInfo, bool)
+
+	// Uniforms returns the active uniforms which can be set with MaterialData uniforms, samplers included.
+	Uniforms() []UniformInfo
+
+	// UniformBlocks returns the active uniform blocks, which are bound to uniform buffers.
+	UniformBlocks() []UniformBlockInfo
+
+	// Samplers returns the active samplers and the texture units they are bound to.
+	Samplers() []SamplerInfo
+
+	// Attributes returns the active vertex attribute inputs.
+	Attributes() []AttributeInfo
+
+	// CompileLog returns the compiler output of the program's shaders, warnings included. It is empty if the
+	// compiler had nothing to report.
+	CompileLog() string
+
+	// LinkLog returns the linker output of the program.
+	LinkLog() string
+
+	// Delete frees the program's GPU resources. The program must not be used afterwards.
+	Delete()
+}
+
+// UniformBlockInfo describes an active uniform block of a program
+type UniformBlockInfo struct {
+	// Name is the name of the block
+	Name string
+
+	// Binding is the uniform buffer binding point of the block
+	Binding uint32
+
+	// Size is the minimum size of the uniform buffer bound to the block, in bytes
+	Size int
+
+	// Members are the active members of the block, ordered by offset
+	Members []UniformBlockMember
+}
+
+// UniformBlockMember describes a member of a uniform block
+type UniformBlockMember struct {
+	// Name is the name of the member, without array subscripts. Members of structs are named by their path,
+	// for example lights[0].color.
+	Name string
+
+	// Type is the type of the member, or of its elements for arrays. Types which can't be set are
+	// UniformTypeNone.
+	Type UniformType
+
+	// Size is the array length, 1 for members which are not arrays
+	Size int
+
+	// Offset is the offset of the member from the start of the block, in bytes
+	Offset int
+
+	// ArrayStride is the distance between array elements in bytes, 0 for members which are not arrays
+	ArrayStride int
+
+	// MatrixStride is the distance between matrix columns in bytes, 0 for members which are not matrices
+	MatrixStride int
+}
+
+// SamplerInfo describes an active sampler of a program
+type SamplerInfo struct {
+	// Name is the name of the sampler, which is the name textures are bound to in MaterialData
+	Name string
+
+	// Unit is the texture unit the sampler reads from, -1 if the program spec doesn't bind the sampler
+	Unit int
+}
+
+// AttributeInfo describes an active vertex attribute input of a program
+type AttributeInfo struct {
+	// Name is the name of the attribute
+	Name string
+
+	// Type is the type of the attribute, named like uniform types
+	Type UniformType
+
+	// Size is the array length, 1 for attributes which are not arrays
+	Size int
+
+	// Location is the attribute location
+	Location int
 }
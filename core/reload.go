@@ -0,0 +1,206 @@
+package core
+
+import (
+	"github.com/golang/glog"
+)
+
+// ResourceWatcher is implemented by ResourceSystems which can detect changes to their resources. The
+// ResourceManager polls it once per frame and reloads changed resources in place.
+type ResourceWatcher interface {
+	// Changes returns the resources which changed since the last call. It is called from the main thread every
+	// frame and must not block.
+	Changes() []ResourceChange
+}
+
+// ResourceLister is implemented by ResourceSystems which can enumerate their resources.
+type ResourceLister interface {
+	// Resources returns the names of the resources of the given type, as they are requested from the
+	// ResourceManager. States and programs are listed without their file extensions.
+	Resources(ResourceType) ([]string, error)
+}
+
+// ResourceChange identifies a changed resource by its type and the name it is requested with.
+type ResourceChange struct {
+	Type ResourceType
+	Name string
+}
+
+// reloadChanged reloads the resources reported by the resource system, if it watches for changes
+func (r *ResourceManager) reloadChanged() {
+	watcher, ok := r.system.(ResourceWatcher)
+	if !ok {
+		return
+	}
+
+	for _, c := range watcher.Changes() {
+		r.Reload(c.Type, c.Name)
+	}
+}
+
+// Reload reloads a cached resource in place: states, programs, textures, materials and models keep their identity
+// so nodes, cameras and materials using them pick up the change. Resources which fail to load keep their previous
+// contents and the error is logged. Resources which are not cached are ignored.
+func (r *ResourceManager) Reload(t ResourceType, name string) {
+	switch t {
+	case ResourceTypeState:
+		reloaded := map[string]bool{name: true}
+		r.reloadState(name)
+		for state := range r.stateSources[name] {
+			r.reloadState(state)
+			reloaded[state] = true
+		}
+		r.reloadStateMaterials(reloaded)
+	case ResourceTypeProgram:
+		for variant := range r.programs {
+			if programName, _ := SplitProgramVariant(variant); programName == name {
+				r.reloadProgram(variant)
+			}
+		}
+	case ResourceTypeProgramData:
+		for program := range r.programSources[name] {
+			r.reloadProgram(program)
+		}
+	case ResourceTypeTexture:
+		r.reloadTexture(name)
+	case ResourceTypeModel:
+		r.reloadModel(name)
+	case ResourceTypeMaterial:
+		r.reloadMaterial(name)
+	}
+}
+
+// ReloadAll reloads every cached state, program, texture, material and model, for example after the resource
+// system started serving different data.
+func (r *ResourceManager) ReloadAll() {
+	for name := range r.states {
+		r.reloadState(name)
+	}
+	for name := range r.programs {
+		r.reloadProgram(name)
+	}
+	textures := make(map[string]bool)
+	for key := range r.textures {
+		textures[key.name] = true
+	}
+	for name := range textures {
+		r.reloadTexture(name)
+	}
+	for name := range r.materials {
+		r.reloadMaterial(name)
+	}
+	for name := range r.models {
+		r.reloadModel(name)
+	}
+}
+
+func (r *ResourceManager) reloadState(name string) {
+	state, ok := r.states[name]
+	if !ok {
+		return
+	}
+
+	reloaded, err := r.readState(name)
+	if err != nil {
+		glog.Errorf("Keeping previous state: %v", err)
+		return
+	}
+
+	// move the state's program reference, programs which fail to load resolve to the fallback program
+	if StateProgram(reloaded) != StateProgram(state) {
+		if reloaded.ProgramName != "" {
+			r.AcquireProgram(StateProgram(reloaded))
+		}
+		r.ReleaseProgram(StateProgram(state))
+	}
+
+	*state = *reloaded
+	glog.Info("Reloaded state ", name)
+}
+
+func (r *ResourceManager) reloadProgram(name string) {
+	entry, ok := r.programs[name]
+	if !ok {
+		return
+	}
+
+	program, err := r.loadProgram(name)
+	if err != nil {
+		glog.Errorf("Keeping previous program: %v", err)
+		return
+	}
+
+	if !entry.fallback {
+		entry.program.Delete()
+	}
+	entry.program, entry.fallback = program, false
+	glog.Info("Reloaded program ", name)
+}
+
+func (r *ResourceManager) reloadTexture(name string) {
+	for key, entry := range r.textures {
+		if key.name != name {
+			continue
+		}
+
+		resource, err := r.system.Texture(name)
+		if err != nil {
+			glog.Errorf("Cannot reload texture %s: %v", name, err)
+			return
+		}
+
+		img, err := DecodeImage(resource, key.descriptor)
+		if err != nil {
+			glog.Errorf("Cannot reload texture %s: %v", name, err)
+			return
+		}
+
+		entry.texture.SetImage(img)
+		entry.size = img.Descriptor.StorageSize()
+		glog.Info("Reloaded texture ", name)
+	}
+}
+
+// reloadModel replaces the geometry and textures of a cached model. Copies share its meshes and textures so
+// they are updated too. Models whose mesh count changed can't be updated in place and must be loaded again.
+func (r *ResourceManager) reloadModel(name string) {
+	entry, ok := r.models[name]
+	if !ok {
+		return
+	}
+
+	resource, err := r.system.Model(name)
+	if err != nil {
+		glog.Errorf("Cannot reload model %s: %v", name, err)
+		return
+	}
+
+	model, err := unmarshalModel(resource)
+	if err != nil {
+		glog.Errorf("Cannot reload model %s: %v", name, err)
+		return
+	}
+	if len(model.Meshes) != len(entry.meshes) || len(model.Meshes) != len(entry.node.children) {
+		glog.Errorf("Cannot reload model %s in place, its mesh count changed", name)
+		return
+	}
+
+	dm := decodeModel(name, model, nil)
+	for i, m := range model.Meshes {
+		setMeshData(entry.meshes[i], m)
+
+		material := entry.node.children[i].Material()
+		for sampler, img := range dm.images[i] {
+			if texture, ok := material.data.textures[sampler]; ok {
+				texture.SetImage(img)
+				continue
+			}
+
+			texture := renderSystem.NewTextureFromImage(img)
+			material.data.SetTexture(sampler, texture)
+			entry.textures = append(entry.textures, texture)
+		}
+	}
+
+	entry.updateSize()
+	glog.Info("Reloaded model ", name)
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestReloadState(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeProgram, "reload-a", []byte("a"))
+	testResources.change(ResourceTypeProgram, "reload-b", []byte("b"))
+	testResources.change(ResourceTypeState, "reload", []byte(`{"programName": "reload-a", "depthTest": true}`))
+	resourceManager.update()
+
+	state := resourceManager.State("reload")
+	programA := resourceManager.Program("reload-a").(*testProgram)
+
+	testResources.change(ResourceTypeState, "reload", []byte(`{"programName": "reload-b", "blending": true}`))
+	resourceManager.update()
+
+	if resourceManager.State("reload") != state {
+		t.Fatal("reloaded states should keep their identity")
+	}
+	if state.DepthTest || !state.Blending || state.ProgramName != "reload-b" || state.Name != "reload" {
+		t.Errorf("state not reloaded: %v", state)
+	}
+	if !programA.deleted {
+		t.Error("the previous program should be released")
+	}
+
+	// broken files keep the previous contents
+	testResources.change(ResourceTypeState, "reload", []byte(`{`))
+	resourceManager.update()
+	if !state.Blending {
+		t.Error("states which fail to parse should be kept")
+	}
+}
+
+func TestReloadProgram(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeProgram, "reload-program", []byte("ok"))
+	program := resourceManager.AcquireProgram("reload-program")
+	defer resourceManager.ReleaseProgram("reload-program")
+
+	testResources.change(ResourceTypeProgram, "reload-program", []byte("broken"))
+	resourceManager.update()
+	if resourceManager.Program("reload-program") != program || program.(*testProgram).deleted {
+		t.Error("programs which fail to compile should keep the previous program")
+	}
+
+	testResources.change(ResourceTypeProgram, "reload-program", []byte("fixed"))
+	resourceManager.update()
+	if resourceManager.Program("reload-program") == program || !program.(*testProgram).deleted {
+		t.Error("programs should be replaced once they compile")
+	}
+}
+
+func TestReloadTexture(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeTexture, "reload.png", testPNG(t))
+	texture := resourceManager.Texture("reload.png", TextureDescriptor{})
+	defer resourceManager.ReleaseTexture(texture)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	testResources.change(ResourceTypeTexture, "reload.png", buf.Bytes())
+	resourceManager.update()
+
+	if resourceManager.Texture("reload.png", TextureDescriptor{}) != texture {
+		t.Error("reloaded textures should keep their identity")
+	}
+	resourceManager.ReleaseTexture(texture)
+
+	if d := texture.Descriptor(); d.Width != 4 || d.Height != 4 {
+		t.Errorf("texture not reloaded, %dx%d", d.Width, d.Height)
+	}
+}
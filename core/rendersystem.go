@@ -29,8 +29,10 @@ type RenderSystem interface {
 	// ProgramExtension exposes the resource extension of program definitions for the implementation.
 	ProgramExtension() string
 
-	// NewProgram creates a new program from a list of subprogram source files.
-	NewProgram(name string, data []byte) Program
+	// NewProgram creates a new program from a list of subprogram source files, compiled with the given variant
+	// keywords. Sources are read with ResourceManager.PreprocessShader. Compile and link errors are returned
+	// rather than fatal so programs can be reloaded while running.
+	NewProgram(name string, data []byte, keywords []string) (Program, error)
 
 	// NewTexture creates a new texture from a byte buffer containing an image file, not raw bitmap.
 	// This always generates RGBA, unsigned byte, power of two and will generate mipmaps
@@ -38,6 +40,14 @@ type RenderSystem interface {
 	// It also defaults to ClampEdge and mipmapped filtering.
 	NewTextureFromImageData(r []byte, d TextureDescriptor) Texture
 
+	// NewTextureFromImage creates a new texture from an image previously decoded with DecodeImage. This
+	// lets callers decode on worker goroutines and only upload on the main thread.
+	NewTextureFromImage(img *DecodedImage) Texture
+
+	// SupportsTextureFormat returns whether textures with the given sized format can be created. It must be
+	// safe to call from any goroutine once the RenderSystem is started.
+	SupportsTextureFormat(f TextureSizedFormat) bool
+
 	// NewUniform creates a new empty uniform
 	NewUniform() Uniform
 
@@ -103,7 +113,7 @@ func GetRenderSystem() RenderSystem {
 	return renderSystem
 }
 
-// DefaultRenderTechnique does z pre-pass, diffuse pass, transparency pass
+// DefaultRenderTechnique does z pre-pass, diffuse pass, sky pass, transparency pass
 func DefaultRenderTechnique(camera *Camera, materialBuckets map[*protos.State][]*Node) RenderStage {
 	var out RenderStage
 	out.Name = fmt.Sprintf("%s-DefaultRenderTechnique", camera.name)
@@ -142,6 +152,9 @@ func DefaultRenderTechnique(camera *Camera, materialBuckets map[*protos.State][]
 
 	out.Passes = append(out.Passes, zPrepass)
 	out.Passes = append(out.Passes, opaquePasses...)
+	if camera.sky != nil {
+		out.Passes = append(out.Passes, camera.sky.renderPass())
+	}
 	out.Passes = append(out.Passes, transparentPasses...)
 
 	return out
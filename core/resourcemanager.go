@@ -1,14 +1,18 @@
 package core
 
 import (
-	"log"
+	"fmt"
+	"sort"
 
 	"github.com/fcvarela/gosg/protos"
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/jsonpb"
 )
 
-// ResourceSystem is an interface which wraps all resource management logic.
+// ResourceSystem is an interface which wraps all resource management logic. Model and Texture may be called from
+// the ResourceManager's loader goroutines and must be safe for concurrent use. Resources which can't be read,
+// missing files included, are reported as errors. Missing resources should be reported with an error wrapping
+// fs.ErrNotExist, so composite systems can tell them apart from resources which exist but can't be read.
 type ResourceSystem interface {
 	// Start is called at application startup time. Implementations requiring init may do so here.
 	Start()
@@ -17,29 +21,181 @@ type ResourceSystem interface {
 	Stop()
 
 	// Model returns a byte array representing a model.
-	Model(string) []byte
+	Model(string) ([]byte, error)
 
 	// Texture returns a byte array representing a texture.
-	Texture(string) []byte
+	Texture(string) ([]byte, error)
 
 	// Program returns a byte array representing a program.
-	Program(string) []byte
+	Program(string) ([]byte, error)
 
 	// State returns a byte array representing a raster state
-	State(string) []byte
+	State(string) ([]byte, error)
 
 	// ProgramData returns a byte array representing program data.
-	ProgramData(string) []byte
+	ProgramData(string) ([]byte, error)
+
+	// Material returns a byte array representing a material.
+	Material(string) ([]byte, error)
 }
 
 // ResourceManager wraps a resourcesystem and contains configuration about the location of each resource type.
+// Models, textures and programs are reference counted, unreferenced ones stay cached until they are evicted to
+// fit the memory budget, see SetMemoryBudget. Resources which fail to load are replaced by fallback assets and
+// the error is logged, the Try variants return the error instead.
 type ResourceManager struct {
 	system          ResourceSystem
-	programs        map[string]Program
+	started         bool
+	programs        map[string]*programEntry
 	states          map[string]*protos.State
-	models          map[string]*Node
+	materials       map[string]*materialEntry
+	models          map[string]*modelEntry
+	modelCopies     map[*Node]string
 	instancedModels map[string]*Node
-	textures        map[string]Texture
+	textures        map[textureKey]*textureEntry
+	textureKeys     map[Texture]textureKey
+	loader          *asyncLoader
+	budget          int
+	frame           uint64
+
+	// program data names and the programs which read them, for reloading
+	programSources map[string]map[string]bool
+	loadingProgram string
+
+	// state names and the states which extend or include them, for reloading
+	stateSources map[string]map[string]bool
+
+	fallbacks fallbacks
+}
+
+// ResourceType is the kind of a resource cached by the ResourceManager
+type ResourceType int
+
+const (
+	// ResourceTypeModel is a model loaded with Model or ModelAsync.
+	ResourceTypeModel ResourceType = iota
+
+	// ResourceTypeTexture is a texture loaded with Texture or TextureAsync.
+	ResourceTypeTexture
+
+	// ResourceTypeProgram is a GPU program.
+	ResourceTypeProgram
+
+	// ResourceTypeState is a raster state.
+	ResourceTypeState
+
+	// ResourceTypeProgramData is a source file used by programs.
+	ResourceTypeProgramData
+
+	// ResourceTypeMaterial is a shared material loaded with Material.
+	ResourceTypeMaterial
+)
+
+func (t ResourceType) String() string {
+	switch t {
+	case ResourceTypeModel:
+		return "model"
+	case ResourceTypeTexture:
+		return "texture"
+	case ResourceTypeProgram:
+		return "program"
+	case ResourceTypeState:
+		return "state"
+	case ResourceTypeProgramData:
+		return "program data"
+	case ResourceTypeMaterial:
+		return "material"
+	}
+	return fmt.Sprintf("ResourceType(%d)", int(t))
+}
+
+// resourceEntry is the bookkeeping shared by every cached resource
+type resourceEntry struct {
+	refs     int
+	size     int
+	lastUsed uint64
+}
+
+func (e *resourceEntry) acquire(frame uint64) {
+	e.refs++
+	e.lastUsed = frame
+}
+
+// textureKey identifies a cached texture, the same image loaded with different sampling parameters
+// results in different textures.
+type textureKey struct {
+	name       string
+	descriptor TextureDescriptor
+}
+
+// textureEntry is a cached texture
+type textureEntry struct {
+	resourceEntry
+	texture Texture
+}
+
+// modelEntry is a cached model and the GPU resources it owns. Copies share its meshes and textures.
+type modelEntry struct {
+	resourceEntry
+	node     *Node
+	meshes   []Mesh
+	textures []Texture
+}
+
+// programEntry is a cached program. Programs which failed to load are cached as fallback entries, which resolve
+// to the fallback program until they are reloaded.
+type programEntry struct {
+	resourceEntry
+	program  Program
+	fallback bool
+}
+
+// newModelEntry collects the meshes and textures of a freshly loaded model. This must run before the model is
+// copied, copies share material textures and may be given textures the model doesn't own.
+func newModelEntry(node *Node) *modelEntry {
+	entry := &modelEntry{node: node}
+	seen := make(map[Texture]bool)
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if m := n.Mesh(); m != nil {
+			entry.meshes = append(entry.meshes, m)
+		}
+		for _, t := range n.materialData.Textures() {
+			if !seen[t] {
+				seen[t] = true
+				entry.textures = append(entry.textures, t)
+			}
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(node)
+	entry.updateSize()
+
+	return entry
+}
+
+// updateSize sums the size of the model's geometry and textures
+func (e *modelEntry) updateSize() {
+	e.size = 0
+	for _, m := range e.meshes {
+		e.size += len(m.Positions())*4 + len(m.Normals())*4 + len(m.Tangents())*4 +
+			len(m.Bitangents())*4 + len(m.TextureCoordinates())*4 + len(m.Indices())*2
+	}
+	for _, t := range e.textures {
+		e.size += t.Descriptor().StorageSize()
+	}
+}
+
+func (e *modelEntry) delete() {
+	for _, m := range e.meshes {
+		m.Delete()
+	}
+	for _, t := range e.textures {
+		t.Delete()
+	}
 }
 
 var (
@@ -47,12 +203,24 @@ var (
 )
 
 func init() {
-	resourceManager = &ResourceManager{
-		programs:        make(map[string]Program),
+	resourceManager = newResourceManager()
+}
+
+// newResourceManager returns an empty ResourceManager without a ResourceSystem
+func newResourceManager() *ResourceManager {
+	return &ResourceManager{
+		programs:        make(map[string]*programEntry),
 		states:          make(map[string]*protos.State),
-		models:          make(map[string]*Node),
+		materials:       make(map[string]*materialEntry),
+		models:          make(map[string]*modelEntry),
+		modelCopies:     make(map[*Node]string),
 		instancedModels: make(map[string]*Node),
-		textures:        make(map[string]Texture),
+		textures:        make(map[textureKey]*textureEntry),
+		textureKeys:     make(map[Texture]textureKey),
+		loader:          newAsyncLoader(),
+		programSources:  make(map[string]map[string]bool),
+		stateSources:    make(map[string]map[string]bool),
+		fallbacks:       fallbacks{program: DefaultFallbackProgram},
 	}
 }
 
@@ -63,58 +231,482 @@ func GetResourceManager() *ResourceManager {
 
 func (r *ResourceManager) start() {
 	glog.Info("Starting")
+	if r.system == nil {
+		glog.Fatal("No resource system set, create one and pass it to SetSystem before starting the application")
+	}
 	r.system.Start()
+	r.started = true
 	glog.Info("Started")
 }
 
 func (r *ResourceManager) stop() {
 	glog.Info("Stopping...")
 	r.system.Stop()
+	r.started = false
 	glog.Info("Stopped")
 }
 
-// SetSystem sets the resource manager's resource system
+// update runs pending main thread work for asynchronous loads, it is called once per frame.
+func (r *ResourceManager) update() {
+	r.frame++
+	r.reloadChanged()
+	r.loader.update()
+	r.trim()
+}
+
+// SetSystem sets the resource system resources are read from, it must be called from the main thread. Replacing
+// the system of a running application stops the previous one, starts the new one and reloads cached resources
+// from it. Asynchronous loads already in flight keep reading from the previous system.
 func (r *ResourceManager) SetSystem(s ResourceSystem) {
-	if r.system != nil {
-		log.Fatal("Can't replace previously registered resource system. Please make sure you're not importing twice")
-	}
+	previous := r.system
 	r.system = s
+	if !r.started {
+		return
+	}
+
+	previous.Stop()
+	s.Start()
+	r.ReloadAll()
 }
 
 // Model returns a scenegraph node with a subtree of nodes containing meshes which represent a complex model.
+// The returned node is a copy sharing the cached model's meshes and textures, and holds a reference to it which
+// must be dropped with ReleaseModel once the node is no longer used. If the model can't be loaded the error is
+// logged and a copy of the fallback model is returned.
 func (r *ResourceManager) Model(name string) *Node {
-	if r.models[name] == nil {
-		resource := r.system.Model(name)
-		r.models[name] = LoadModel(name, resource)
+	node, err := r.TryModel(name)
+	if err != nil {
+		glog.Error(err)
+		return r.fallbackModel().Copy()
 	}
-	return r.models[name].Copy()
+	return node
+}
+
+// TryModel is like Model but returns an error if the model can't be loaded.
+func (r *ResourceManager) TryModel(name string) (*Node, error) {
+	entry := r.models[name]
+	if entry == nil {
+		resource, err := r.system.Model(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load model %s: %v", name, err)
+		}
+		model, err := LoadModel(name, resource)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load model %s: %v", name, err)
+		}
+		entry = newModelEntry(model)
+		r.models[name] = entry
+	}
+
+	entry.acquire(r.frame)
+	node := entry.node.Copy()
+	r.modelCopies[node] = name
+	return node, nil
 }
 
-// Program returns a GPU program.
+// ReleaseModel drops the reference held by a node returned by Model, or the node of a ModelAsync handle. Nodes
+// which were not returned by the ResourceManager are ignored. The node must not be drawn once the model is
+// unloaded.
+func (r *ResourceManager) ReleaseModel(node *Node) {
+	name, ok := r.modelCopies[node]
+	if !ok {
+		return
+	}
+	delete(r.modelCopies, node)
+
+	entry := r.models[name]
+	entry.refs--
+	if entry.refs == 0 && r.budget == 0 {
+		r.unloadModel(name)
+	}
+	r.trim()
+}
+
+func (r *ResourceManager) unloadModel(name string) {
+	r.models[name].delete()
+	delete(r.models, name)
+}
+
+// Program returns a GPU program. This is a lookup meant for RenderSystems resolving the programs of states every
+// frame, it does not add a reference. Programs which were unloaded are loaded again. If the program can't be
+// loaded the error is logged once and the fallback program is returned until it is reloaded. Program variants are
+// requested by the name returned by ProgramVariant and compiled on first use.
 func (r *ResourceManager) Program(name string) Program {
-	if r.programs[name] == nil {
-		resource := r.system.Program(name)
-		r.programs[name] = renderSystem.NewProgram(name, resource)
+	if entry, ok := r.programs[name]; ok && entry.fallback {
+		entry.lastUsed = r.frame
+		return r.fallbackProgram()
+	}
+
+	program, err := r.TryProgram(name)
+	if err != nil {
+		glog.Error(err)
+		r.programs[name] = &programEntry{resourceEntry{lastUsed: r.frame}, nil, true}
+		return r.fallbackProgram()
+	}
+	return program
+}
+
+// TryProgram is like Program but returns an error if the program can't be loaded. Programs which previously
+// failed are loaded again.
+func (r *ResourceManager) TryProgram(name string) (Program, error) {
+	entry := r.programs[name]
+	if entry == nil || entry.fallback {
+		program, err := r.loadProgram(name)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			entry = &programEntry{}
+			r.programs[name] = entry
+		}
+		entry.program, entry.fallback = program, false
+	}
+
+	entry.lastUsed = r.frame
+	return entry.program, nil
+}
+
+// loadProgram creates a program or program variant, recording the program data it reads
+func (r *ResourceManager) loadProgram(name string) (Program, error) {
+	r.loadingProgram = name
+	defer func() { r.loadingProgram = "" }()
+
+	programName, keywords := SplitProgramVariant(name)
+	resource, err := r.system.Program(programName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load program %s: %v", name, err)
+	}
+
+	program, err := renderSystem.NewProgram(name, resource, keywords)
+	if err != nil {
+		return nil, err
+	}
+
+	if compileLog := program.CompileLog(); compileLog != "" {
+		glog.Warningf("Program %s compiled with warnings:\n%s", name, compileLog)
+	}
+	return program, nil
+}
+
+// AcquireProgram returns a GPU program and adds a reference to it, which must be dropped with ReleaseProgram.
+// States loaded with State hold a reference to their program.
+func (r *ResourceManager) AcquireProgram(name string) Program {
+	program := r.Program(name)
+	r.programs[name].acquire(r.frame)
+	return program
+}
+
+// ReleaseProgram drops a reference added by AcquireProgram. Programs are released by name, the program returned
+// by AcquireProgram may have been reloaded or replaced by the fallback program since.
+func (r *ResourceManager) ReleaseProgram(name string) {
+	entry, ok := r.programs[name]
+	if !ok || entry.refs == 0 {
+		return
+	}
+
+	entry.refs--
+	if entry.refs == 0 && r.budget == 0 {
+		r.unloadProgram(name)
+	}
+	r.trim()
+}
+
+func (r *ResourceManager) unloadProgram(name string) {
+	if entry := r.programs[name]; !entry.fallback {
+		entry.program.Delete()
 	}
-	return r.programs[name]
+	delete(r.programs, name)
 }
 
-// State returns a State
+// State returns a State. States are cached until released with ReleaseState and hold a reference to their
+// program while cached. If the state can't be loaded the error is logged and a state drawing with the fallback
+// program is cached in its place, reloading the state replaces it in place.
 func (r *ResourceManager) State(name string) *protos.State {
-	if r.states[name] == nil {
-		resource := r.system.State(name)
-		var state protos.State
-		if err := jsonpb.UnmarshalString(string(resource), &state); err != nil {
-			glog.Fatal("Cannot unmarshal state: ", err)
+	state, err := r.TryState(name)
+	if err != nil {
+		glog.Error(err)
+		state = r.fallbackState(name)
+		r.states[name] = state
+		r.AcquireProgram(StateProgram(state))
+	}
+	return state
+}
+
+// TryState is like State but returns an error if the state can't be loaded. Programs which fail to load are
+// replaced by the fallback program, see Program.
+func (r *ResourceManager) TryState(name string) (*protos.State, error) {
+	if state, ok := r.states[name]; ok {
+		return state, nil
+	}
+
+	state, err := r.readState(name)
+	if err != nil {
+		return nil, err
+	}
+	r.states[name] = state
+
+	if state.ProgramName != "" {
+		r.AcquireProgram(StateProgram(state))
+	}
+	return state, nil
+}
+
+// readState resolves and unmarshals a state, recording the states it is built from for reloading
+func (r *ResourceManager) readState(name string) (*protos.State, error) {
+	resolved, err := r.ResolveState(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var state protos.State
+	if err := jsonpb.UnmarshalString(string(resolved.JSON), &state); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal state %s: %v", name, err)
+	}
+	state.Name = name
+
+	for _, source := range resolved.Sources {
+		if source == name {
+			continue
+		}
+		if r.stateSources[source] == nil {
+			r.stateSources[source] = make(map[string]bool)
 		}
-		state.Name = name
-		r.states[name] = &state
+		r.stateSources[source][name] = true
 	}
-	return r.states[name]
+	return &state, nil
+}
+
+// ReleaseState removes a state from the cache and drops its program reference. Nodes still using the state keep
+// working, its program is loaded again if it was unloaded.
+func (r *ResourceManager) ReleaseState(name string) {
+	state, ok := r.states[name]
+	if !ok {
+		return
+	}
+	delete(r.states, name)
+
+	r.ReleaseProgram(StateProgram(state))
 }
 
 // ProgramData returns source file contents for a given program or subprogram
 // This is meant to be used by rendersystem implementations to load subresources for a program spec
-func (r *ResourceManager) ProgramData(name string) []byte {
+func (r *ResourceManager) ProgramData(name string) ([]byte, error) {
+	if r.loadingProgram != "" {
+		if r.programSources[name] == nil {
+			r.programSources[name] = make(map[string]bool)
+		}
+		r.programSources[name][r.loadingProgram] = true
+	}
 	return r.system.ProgramData(name)
 }
+
+// Texture returns a texture loaded from the named image resource and decoded with DecodeImage. Textures are cached
+// by name and descriptor so every caller shares one GPU texture. Each call adds a reference which must be dropped
+// with ReleaseTexture once the caller no longer uses the texture. If the texture can't be loaded the error is
+// logged and the fallback texture is returned.
+func (r *ResourceManager) Texture(name string, descriptor TextureDescriptor) Texture {
+	texture, err := r.TryTexture(name, descriptor)
+	if err != nil {
+		glog.Error(err)
+		return r.fallbackTexture()
+	}
+	return texture
+}
+
+// TryTexture is like Texture but returns an error if the texture can't be loaded.
+func (r *ResourceManager) TryTexture(name string, descriptor TextureDescriptor) (Texture, error) {
+	key := textureKey{name, descriptor}
+	if entry, ok := r.textures[key]; ok {
+		entry.acquire(r.frame)
+		return entry.texture, nil
+	}
+
+	resource, err := r.system.Texture(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load texture %s: %v", name, err)
+	}
+
+	img, err := DecodeImage(resource, descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode texture %s: %v", name, err)
+	}
+
+	return r.addTexture(key, renderSystem.NewTextureFromImage(img)), nil
+}
+
+// addTexture caches a texture with a single reference. If another load already cached the same key, the new
+// texture is deleted and the cached one is returned instead.
+func (r *ResourceManager) addTexture(key textureKey, texture Texture) Texture {
+	if entry, ok := r.textures[key]; ok {
+		texture.Delete()
+		entry.acquire(r.frame)
+		return entry.texture
+	}
+
+	entry := &textureEntry{resourceEntry{size: texture.Descriptor().StorageSize()}, texture}
+	entry.acquire(r.frame)
+	r.textures[key] = entry
+	r.textureKeys[texture] = key
+	return texture
+}
+
+// ReleaseTexture drops a reference to a texture returned by Texture or TextureAsync. Once unreferenced the
+// texture may be unloaded, freeing its GPU storage, see SetMemoryBudget. Textures which were not loaded through
+// the ResourceManager are ignored.
+func (r *ResourceManager) ReleaseTexture(texture Texture) {
+	key, ok := r.textureKeys[texture]
+	if !ok {
+		return
+	}
+
+	entry := r.textures[key]
+	if entry.refs == 0 {
+		return
+	}
+
+	entry.refs--
+	if entry.refs == 0 && r.budget == 0 {
+		r.unloadTexture(key)
+	}
+	r.trim()
+}
+
+func (r *ResourceManager) unloadTexture(key textureKey) {
+	texture := r.textures[key].texture
+	delete(r.textures, key)
+	delete(r.textureKeys, texture)
+	texture.Delete()
+}
+
+// TextureReferences returns the number of outstanding references to a cached texture, 0 if it isn't cached.
+func (r *ResourceManager) TextureReferences(texture Texture) int {
+	if key, ok := r.textureKeys[texture]; ok {
+		return r.textures[key].refs
+	}
+	return 0
+}
+
+// SetMemoryBudget sets the number of bytes of GPU memory cached resources may use. With a budget, unreferenced
+// resources stay cached and are unloaded least recently used first once resident resources exceed it. Without
+// one, the default, resources are unloaded as soon as their last reference is dropped.
+func (r *ResourceManager) SetMemoryBudget(bytes int) {
+	r.budget = bytes
+	r.trim()
+}
+
+// MemoryBudget returns the memory budget, 0 if there is none.
+func (r *ResourceManager) MemoryBudget() int {
+	return r.budget
+}
+
+// resident is a cached resource as seen by eviction and reports
+type resident struct {
+	kind   ResourceType
+	name   string
+	entry  *resourceEntry
+	unload func()
+}
+
+func (r *ResourceManager) residents() []resident {
+	var out []resident
+	for name, e := range r.models {
+		name := name
+		out = append(out, resident{ResourceTypeModel, name, &e.resourceEntry, func() { r.unloadModel(name) }})
+	}
+	for key, e := range r.textures {
+		key := key
+		out = append(out, resident{ResourceTypeTexture, key.name, &e.resourceEntry, func() { r.unloadTexture(key) }})
+	}
+	for name, e := range r.programs {
+		name := name
+		out = append(out, resident{ResourceTypeProgram, name, &e.resourceEntry, func() { r.unloadProgram(name) }})
+	}
+
+	// deterministic order for reports and eviction ties
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].kind != out[j].kind {
+			return out[i].kind < out[j].kind
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// ResidentSize returns the number of bytes used by cached resources.
+func (r *ResourceManager) ResidentSize() int {
+	size := 0
+	for _, res := range r.residents() {
+		size += res.entry.size
+	}
+	return size
+}
+
+// trim unloads unreferenced resources, least recently used first, until the resident size fits the budget
+func (r *ResourceManager) trim() {
+	if r.budget == 0 {
+		return
+	}
+
+	residents := r.residents()
+	size := 0
+	for _, res := range residents {
+		size += res.entry.size
+	}
+	if size <= r.budget {
+		return
+	}
+
+	sort.SliceStable(residents, func(i, j int) bool { return residents[i].entry.lastUsed < residents[j].entry.lastUsed })
+	for _, res := range residents {
+		if size <= r.budget {
+			break
+		}
+		if res.entry.refs > 0 || res.entry.size == 0 {
+			continue
+		}
+
+		glog.Infof("Evicting %s %s, %d bytes", res.kind, res.name, res.entry.size)
+		size -= res.entry.size
+		res.unload()
+	}
+}
+
+// UnloadUnused unloads every unreferenced resource regardless of the memory budget, typically after changing
+// levels. Programs used by states which are still cached are kept.
+func (r *ResourceManager) UnloadUnused() {
+	for _, res := range r.residents() {
+		if res.entry.refs == 0 {
+			res.unload()
+		}
+	}
+}
+
+// ResidentResource describes a cached resource in a residency report
+type ResidentResource struct {
+	Type ResourceType
+	Name string
+
+	// Refs is the number of outstanding references, unreferenced resources may be evicted.
+	Refs int
+
+	// Size is the estimated number of bytes of GPU memory used. Programs are reported with size 0, drivers do
+	// not expose their size.
+	Size int
+
+	// LastUsed is the frame the resource was last requested on.
+	LastUsed uint64
+}
+
+func (r ResidentResource) String() string {
+	return fmt.Sprintf("%-8s %-48s refs=%-4d size=%-10d lastUsed=%d", r.Type, r.Name, r.Refs, r.Size, r.LastUsed)
+}
+
+// ResidencyReport lists every cached resource, ordered by type and name. Loads in flight are not included.
+func (r *ResourceManager) ResidencyReport() []ResidentResource {
+	residents := r.residents()
+	report := make([]ResidentResource, len(residents))
+	for i, res := range residents {
+		report[i] = ResidentResource{res.kind, res.name, res.entry.refs, res.entry.size, res.entry.lastUsed}
+	}
+	return report
+}
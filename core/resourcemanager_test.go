@@ -0,0 +1,146 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTextureCache(t *testing.T) {
+	setupTestSystems()
+
+	testResources.Lock()
+	testResources.textures["cached.png"] = testPNG(t)
+	testResources.Unlock()
+
+	linear := TextureDescriptor{Filter: TextureFilterLinear}
+	nearest := TextureDescriptor{Filter: TextureFilterNearest}
+
+	a := resourceManager.Texture("cached.png", linear)
+	b := resourceManager.Texture("cached.png", linear)
+	c := resourceManager.Texture("cached.png", nearest)
+
+	if a != b {
+		t.Error("same name and descriptor should share a texture")
+	}
+	if a == c {
+		t.Error("different descriptors should not share a texture")
+	}
+
+	h := resourceManager.TextureAsync("cached.png", linear)
+	pumpUploads(t, h)
+	if h.Texture() != a {
+		t.Error("async loads should share cached textures")
+	}
+
+	if refs := resourceManager.TextureReferences(a); refs != 3 {
+		t.Errorf("expected 3 references, got %d", refs)
+	}
+
+	for i := 0; i < 3; i++ {
+		resourceManager.ReleaseTexture(a)
+	}
+
+	if !a.(*testTexture).deleted || resourceManager.TextureReferences(a) != 0 {
+		t.Error("texture should be deleted after its last release")
+	}
+	if c.(*testTexture).deleted {
+		t.Error("releasing one descriptor should not affect another")
+	}
+
+	if d := resourceManager.Texture("cached.png", linear); d == a {
+		t.Error("released textures should be reloaded")
+	}
+}
+
+func TestModelReferences(t *testing.T) {
+	setupTestSystems()
+
+	var buf bytes.Buffer
+	if err := WriteModel(&buf, testSubtree(t), ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	testResources.Lock()
+	testResources.models["refs.model"] = buf.Bytes()
+	testResources.Unlock()
+
+	a := resourceManager.Model("refs.model")
+	b := resourceManager.Model("refs.model")
+	mesh := a.Children()[0].Mesh().(*testMesh)
+	if b.Children()[0].Mesh() != mesh {
+		t.Error("model copies should share meshes")
+	}
+
+	resourceManager.ReleaseModel(a)
+	resourceManager.ReleaseModel(a)
+	if mesh.deleted {
+		t.Error("model should stay loaded while referenced")
+	}
+
+	resourceManager.ReleaseModel(b)
+	if !mesh.deleted {
+		t.Error("model meshes should be deleted after the last release")
+	}
+	for _, r := range resourceManager.ResidencyReport() {
+		if r.Type == ResourceTypeModel && r.Name == "refs.model" {
+			t.Error("unloaded model should not be reported")
+		}
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	setupTestSystems()
+
+	resourceManager.SetMemoryBudget(40)
+	defer resourceManager.SetMemoryBudget(0)
+
+	// 2x2 RGBA8 without mipmaps, 16 bytes each
+	names := []string{"budget-a.png", "budget-b.png", "budget-c.png"}
+	var textures []Texture
+	testResources.Lock()
+	for _, name := range names {
+		testResources.textures[name] = testPNG(t)
+	}
+	testResources.Unlock()
+	for _, name := range names {
+		textures = append(textures, resourceManager.Texture(name, TextureDescriptor{}))
+		resourceManager.update()
+	}
+
+	sizes := make(map[string]int)
+	for _, r := range resourceManager.ResidencyReport() {
+		if r.Type == ResourceTypeTexture {
+			sizes[r.Name] = r.Size
+		}
+	}
+	for _, name := range names {
+		if sizes[name] != 16 {
+			t.Errorf("%s: reported size %d, want 16", name, sizes[name])
+		}
+	}
+
+	// referenced textures are never evicted
+	if textures[0].(*testTexture).deleted {
+		t.Error("referenced texture should not be evicted")
+	}
+
+	for _, texture := range textures {
+		resourceManager.ReleaseTexture(texture)
+	}
+
+	if !textures[0].(*testTexture).deleted {
+		t.Error("least recently used texture should be evicted once over budget")
+	}
+	if textures[1].(*testTexture).deleted || textures[2].(*testTexture).deleted {
+		t.Error("textures within budget should stay cached")
+	}
+
+	if c := resourceManager.Texture("budget-c.png", TextureDescriptor{}); c != textures[2] {
+		t.Error("cached unreferenced textures should be reused")
+	}
+	resourceManager.ReleaseTexture(textures[2])
+
+	resourceManager.UnloadUnused()
+	if !textures[1].(*testTexture).deleted || !textures[2].(*testTexture).deleted {
+		t.Error("UnloadUnused should unload every unreferenced texture")
+	}
+}
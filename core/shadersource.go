@@ -0,0 +1,240 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fcvarela/gosg/protos"
+)
+
+// Shader sources are preprocessed before they are compiled. Sources may include other program data files by
+// resource name:
+//
+//	#include "common/brdf.glsl"
+//
+// Each file is included once per shader, later includes of the same file are skipped, so included files need no
+// include guards and may include each other. The preprocessor emits #line directives numbering every file, see
+// ShaderSource.MapLog for mapping compiler logs back to file names.
+//
+// Programs are compiled in variants selected by keywords. Each keyword of a variant is defined after the #version
+// directive, so sources can test for it:
+//
+//	#ifdef NORMAL_MAP
+//	    vec3 N = normalize(tbn * (texture(normalTex, tcoords0.st).rgb * 2.0 - 1.0));
+//	#else
+//	    vec3 N = normalize(tbn[2]);
+//	#endif
+//
+// States select a variant with programKeywords, materials add their own keywords to their state's. Variants are
+// named by ProgramVariant and loaded on first use like any other program.
+
+// Files under gosg/ are provided by the engine rather than read from the programs directory. gosg/camera.glsl
+// declares the cameraConstants block generated from CameraConstantsBlock, gosg/lights.glsl the camera's light
+// textures with the functions reading them, see LightsSampler:
+//
+//	#include "gosg/camera.glsl"
+//	#include "gosg/lights.glsl"
+
+// BuiltinShaderPrefix prefixes the names of included files which are provided by the engine
+const BuiltinShaderPrefix = "gosg/"
+
+// builtinShaders are the files provided by the engine, by name
+var builtinShaders = map[string]string{
+	BuiltinShaderPrefix + "camera.glsl": "// generated from core.CameraConstantsBlock\n" + CameraConstantsBlock.GLSL(),
+	BuiltinShaderPrefix + "lights.glsl": lightsGLSL(),
+	BuiltinShaderPrefix + "sky.glsl":    "// generated from core.SkyConstantsBlock\n" + SkyConstantsBlock.GLSL(),
+}
+
+// BuiltinShaders returns the names of the files provided by the engine, sorted
+func BuiltinShaders() []string {
+	names := make([]string, 0, len(builtinShaders))
+	for name := range builtinShaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShaderSource is a preprocessed shader
+type ShaderSource struct {
+	// Source is the preprocessed source
+	Source []byte
+
+	// Files are the files included in the source, indexed by the source string number of their #line directives.
+	// The first file is the shader itself.
+	Files []string
+}
+
+var (
+	shaderIncludeRegexp = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*(//.*)?$`)
+	shaderVersionRegexp = regexp.MustCompile(`^\s*#\s*version\b`)
+
+	// shaderLogRegexp matches locations in compiler logs, "0:12" and "0(12)" depending on the driver
+	shaderLogRegexp = regexp.MustCompile(`\b(\d+)(?::(\d+)|\((\d+)\))`)
+)
+
+// PreprocessShader reads a shader with ProgramData, resolves its includes and defines the given keywords. Programs
+// loading a shader are reloaded when any of the files it includes change.
+func (r *ResourceManager) PreprocessShader(name string, keywords []string) (ShaderSource, error) {
+	return preprocessShader(name, keywords, r.ProgramData)
+}
+
+// readShader reads a file provided by the engine, or reads it with read
+func readShader(name string, read func(string) ([]byte, error)) ([]byte, error) {
+	if strings.HasPrefix(name, BuiltinShaderPrefix) {
+		if source, ok := builtinShaders[name]; ok {
+			return []byte(source), nil
+		}
+		return nil, fmt.Errorf("no builtin file %s", name)
+	}
+	return read(name)
+}
+
+// preprocessShader implements PreprocessShader, reading files with read
+func preprocessShader(name string, keywords []string, read func(string) ([]byte, error)) (ShaderSource, error) {
+	p := &shaderPreprocessor{read: read, included: make(map[string]bool)}
+
+	for _, keyword := range programKeywords(keywords) {
+		p.defines = append(p.defines, fmt.Sprintf("#define %s 1\n", keyword)...)
+	}
+
+	if err := p.include(name, nil); err != nil {
+		return ShaderSource{}, err
+	}
+	if p.defines != nil {
+		// no #version directive, the defines go first
+		p.out = append(append(p.defines, "#line 1 0\n"...), p.out...)
+	}
+	return ShaderSource{p.out, p.files}, nil
+}
+
+// shaderPreprocessor holds the state of a preprocessed shader
+type shaderPreprocessor struct {
+	read     func(string) ([]byte, error)
+	included map[string]bool
+	files    []string
+	defines  []byte
+	out      []byte
+}
+
+// include appends a file to the output. chain holds the files including it, for error messages.
+func (p *shaderPreprocessor) include(name string, chain []string) error {
+	if p.included[name] {
+		return nil
+	}
+	p.included[name] = true
+
+	data, err := readShader(name, p.read)
+	if err != nil {
+		if len(chain) == 0 {
+			return fmt.Errorf("cannot load shader %s: %v", name, err)
+		}
+		return fmt.Errorf("%s: cannot include %s: %v", chain[len(chain)-1], name, err)
+	}
+
+	file := len(p.files)
+	p.files = append(p.files, name)
+	if file > 0 {
+		p.out = append(p.out, fmt.Sprintf("#line 1 %d\n", file)...)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, line := range lines {
+		location := fmt.Sprintf("%s:%d", name, i+1)
+
+		if shaderVersionRegexp.MatchString(line) {
+			if file > 0 {
+				return fmt.Errorf("%s: #version is only allowed in the shader, not in included files", location)
+			}
+			p.out = append(p.out, line+"\n"...)
+			if p.defines != nil {
+				p.out = append(p.out, p.defines...)
+				p.out = append(p.out, fmt.Sprintf("#line %d 0\n", i+2)...)
+				p.defines = nil
+			}
+			continue
+		}
+
+		if m := shaderIncludeRegexp.FindStringSubmatch(line); m != nil {
+			if err := p.include(m[1], append(chain, location)); err != nil {
+				return err
+			}
+			p.out = append(p.out, fmt.Sprintf("#line %d %d\n", i+2, file)...)
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(line), "#include") {
+			return fmt.Errorf("%s: malformed #include, expected #include \"file\"", location)
+		}
+
+		p.out = append(p.out, line+"\n"...)
+	}
+	return nil
+}
+
+// MapLog rewrites the locations of a compiler log from source string numbers to the names of the files they
+// refer to, so "0:12: error" becomes "ubershader.fs.glsl:12: error".
+func (s ShaderSource) MapLog(log string) string {
+	lines := strings.Split(log, "\n")
+	for i, line := range lines {
+		m := shaderLogRegexp.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+
+		file, err := strconv.Atoi(line[m[2]:m[3]])
+		if err != nil || file >= len(s.Files) {
+			continue
+		}
+
+		var lineNumber string
+		if m[4] >= 0 {
+			lineNumber = line[m[4]:m[5]]
+		} else {
+			lineNumber = line[m[6]:m[7]]
+		}
+		lines[i] = line[:m[0]] + s.Files[file] + ":" + lineNumber + line[m[1]:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ProgramVariant returns the name of the variant of a program compiled with the given keywords, the program name
+// itself if there are none. Keyword order and duplicates don't matter.
+func ProgramVariant(name string, keywords []string) string {
+	keywords = programKeywords(keywords)
+	if len(keywords) == 0 {
+		return name
+	}
+	return name + ":" + strings.Join(keywords, ",")
+}
+
+// SplitProgramVariant returns the program and keywords of a program variant name
+func SplitProgramVariant(variant string) (string, []string) {
+	i := strings.Index(variant, ":")
+	if i < 0 {
+		return variant, nil
+	}
+	return variant[:i], strings.Split(variant[i+1:], ",")
+}
+
+// StateProgram returns the name of the program variant a state draws with
+func StateProgram(state *protos.State) string {
+	return ProgramVariant(state.ProgramName, state.ProgramKeywords)
+}
+
+// programKeywords returns keywords sorted and without duplicates or empty keywords
+func programKeywords(keywords []string) []string {
+	set := make(map[string]bool, len(keywords))
+	sorted := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k != "" && !set[k] {
+			set[k] = true
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testShaderReader(files map[string]string) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("no such file")
+	}
+}
+
+func TestPreprocessShader(t *testing.T) {
+	read := testShaderReader(map[string]string{
+		"lit.fs.glsl":        "#version 410 core\n#include \"common/light.glsl\"\n#include \"common/camera.glsl\"\nvoid main() {}\n",
+		"common/light.glsl":  "#include \"common/camera.glsl\"\nvec4 light();\n",
+		"common/camera.glsl": "uniform mat4 vMatrix;\n",
+	})
+
+	source, err := preprocessShader("lit.fs.glsl", []string{"SHADOWS", "NORMAL_MAP", "SHADOWS"}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"#version 410 core",
+		"#define NORMAL_MAP 1",
+		"#define SHADOWS 1",
+		"#line 2 0",
+		"#line 1 1",
+		"#line 1 2",
+		"uniform mat4 vMatrix;",
+		"#line 2 1",
+		"vec4 light();",
+		"#line 3 0",
+		"#line 4 0",
+		"void main() {}",
+		"",
+	}, "\n")
+	if string(source.Source) != expected {
+		t.Errorf("unexpected source:\n%s\nexpected:\n%s", source.Source, expected)
+	}
+
+	files := []string{"lit.fs.glsl", "common/light.glsl", "common/camera.glsl"}
+	if !reflect.DeepEqual(source.Files, files) {
+		t.Errorf("files %v, expected %v", source.Files, files)
+	}
+
+	log := source.MapLog("ERROR: 2:1: 'vMatrix' : redefinition\n0(4) : error C0000: syntax error\n9:1: unknown file")
+	expectedLog := "ERROR: common/camera.glsl:1: 'vMatrix' : redefinition\nlit.fs.glsl:4 : error C0000: syntax error\n9:1: unknown file"
+	if log != expectedLog {
+		t.Errorf("mapped log:\n%s\nexpected:\n%s", log, expectedLog)
+	}
+}
+
+func TestPreprocessShaderErrors(t *testing.T) {
+	read := testShaderReader(map[string]string{
+		"missing.glsl":   "#version 410 core\n#include \"nothere.glsl\"\n",
+		"version.glsl":   "#include \"versioned.glsl\"\n",
+		"versioned.glsl": "#version 410 core\n",
+		"malformed.glsl": "#include <common.glsl>\n",
+		"builtin.glsl":   "#include \"gosg/nothere.glsl\"\n",
+	})
+
+	for name, expected := range map[string]string{
+		"missing.glsl":   "missing.glsl:2: cannot include nothere.glsl",
+		"version.glsl":   "versioned.glsl:1: #version is only allowed in the shader",
+		"malformed.glsl": "malformed.glsl:1: malformed #include",
+		"absent.glsl":    "cannot load shader absent.glsl",
+		"builtin.glsl":   "builtin.glsl:1: cannot include gosg/nothere.glsl",
+	} {
+		_, err := preprocessShader(name, nil, read)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q, got %v", name, expected, err)
+		}
+	}
+}
+
+func TestPreprocessShaderWithoutVersion(t *testing.T) {
+	read := testShaderReader(map[string]string{"plain.glsl": "void main() {}\n"})
+
+	source, err := preprocessShader("plain.glsl", []string{"SKINNED"}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "#define SKINNED 1\n#line 1 0\nvoid main() {}\n"; string(source.Source) != expected {
+		t.Errorf("unexpected source %q", source.Source)
+	}
+}
+
+func TestPreprocessShaderBuiltins(t *testing.T) {
+	read := testShaderReader(map[string]string{"lit.vs.glsl": "#include \"gosg/camera.glsl\"\n"})
+
+	source, err := preprocessShader("lit.vs.glsl", nil, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(source.Source), CameraConstantsBlock.GLSL()) {
+		t.Errorf("gosg/camera.glsl doesn't declare cameraConstants:\n%s", source.Source)
+	}
+	if !reflect.DeepEqual(source.Files, []string{"lit.vs.glsl", "gosg/camera.glsl"}) {
+		t.Errorf("unexpected files %v", source.Files)
+	}
+}
+
+func TestProgramVariant(t *testing.T) {
+	if name := ProgramVariant("ubershader", nil); name != "ubershader" {
+		t.Errorf("variant without keywords is %s", name)
+	}
+
+	name := ProgramVariant("ubershader", []string{"SHADOWS", "NORMAL_MAP", "SHADOWS"})
+	if name != "ubershader:NORMAL_MAP,SHADOWS" {
+		t.Errorf("unexpected variant name %s", name)
+	}
+
+	program, keywords := SplitProgramVariant(name)
+	if program != "ubershader" || !reflect.DeepEqual(keywords, []string{"NORMAL_MAP", "SHADOWS"}) {
+		t.Errorf("split %s into %s %v", name, program, keywords)
+	}
+}
+
+func TestProgramVariants(t *testing.T) {
+	setupTestSystems()
+
+	testResources.Lock()
+	testResources.programs["variants"] = []byte("uniform vec4 tint")
+	testResources.states["variants"] = []byte(`{"programName": "variants", "programKeywords": ["SKINNED", "NORMAL_MAP"]}`)
+	testResources.materials["variants"] = []byte(`{"state": "variants", "keywords": ["SHADOWS"]}`)
+	testResources.Unlock()
+
+	state := resourceManager.State("variants")
+	program := resourceManager.Program(StateProgram(state)).(*testProgram)
+	if program.Name() != "variants:NORMAL_MAP,SKINNED" || !reflect.DeepEqual(program.keywords, []string{"NORMAL_MAP", "SKINNED"}) {
+		t.Errorf("state drew with %s %v", program.Name(), program.keywords)
+	}
+
+	material := resourceManager.Material("variants")
+	variant := "variants:NORMAL_MAP,SHADOWS,SKINNED"
+	if StateProgram(material.State()) != variant {
+		t.Errorf("material drew with %s", StateProgram(material.State()))
+	}
+	if len(state.ProgramKeywords) != 2 {
+		t.Errorf("material keywords leaked into its state: %v", state.ProgramKeywords)
+	}
+	if refs := resourceManager.programs[variant].refs; refs != 1 {
+		t.Errorf("material variant has %d references, expected 1", refs)
+	}
+
+	resourceManager.ReleaseMaterial("variants")
+	if _, ok := resourceManager.programs[variant]; ok {
+		t.Error("material variant should be unloaded with the material")
+	}
+	if _, ok := resourceManager.programs[StateProgram(state)]; !ok {
+		t.Error("state variant should stay loaded")
+	}
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SkyMode is used to express how a sky is rendered
+type SkyMode int
+
+const (
+	// SkyModeCubemap samples a cubemap texture with the view direction.
+	SkyModeCubemap SkyMode = iota
+
+	// SkyModeEquirectangular samples a 2D texture holding a latitude/longitude panorama.
+	SkyModeEquirectangular
+
+	// SkyModeGradient blends between ground, horizon and zenith colors.
+	SkyModeGradient
+
+	// SkyModeAnalytic computes a daylight sky from the sun direction.
+	SkyModeAnalytic
+)
+
+const (
+	// EnvironmentCubemapSampler is the sampler name cubemap sky textures are exposed to programs with.
+	EnvironmentCubemapSampler = "environmentCubeTex"
+
+	// EnvironmentMapSampler is the sampler name equirectangular sky textures are exposed to programs with.
+	EnvironmentMapSampler = "environmentTex"
+)
+
+// SkyConstantsBlock is the layout of the skyConstants uniform block. Shaders declare it by including
+// gosg/sky.glsl. The x component of mode is the SkyMode, the w component of sunDirection the cosine of the sun
+// disk's angular radius.
+var SkyConstantsBlock = NewStd140Block("skyConstants",
+	Std140Member{Name: "mode", Type: UniformTypeVec4},
+	Std140Member{Name: "zenithColor", Type: UniformTypeVec4},
+	Std140Member{Name: "horizonColor", Type: UniformTypeVec4},
+	Std140Member{Name: "groundColor", Type: UniformTypeVec4},
+	Std140Member{Name: "sunDirection", Type: UniformTypeVec4},
+)
+
+var (
+	skyMode         = SkyConstantsBlock.Member("mode")
+	skyZenithColor  = SkyConstantsBlock.Member("zenithColor")
+	skyHorizonColor = SkyConstantsBlock.Member("horizonColor")
+	skyGroundColor  = SkyConstantsBlock.Member("groundColor")
+	skySunDirection = SkyConstantsBlock.Member("sunDirection")
+)
+
+// Sky is a camera background rendered after the opaque passes wherever nothing else was drawn. Cubemap and
+// equirectangular skies also expose their texture to the camera's programs for reflections, see
+// EnvironmentCubemapSampler and EnvironmentMapSampler.
+type Sky struct {
+	mode         SkyMode
+	texture      Texture
+	sunDirection mgl32.Vec4
+	constants    Std140Buffer
+	dirty        bool
+	node         *Node
+}
+
+// newSky creates the sky's fullscreen triangle. Every attribute is provided so mesh buffers stay aligned.
+func newSky(mode SkyMode, texture Texture) *Sky {
+	s := &Sky{mode: mode, texture: texture, constants: NewStd140Buffer(SkyConstantsBlock), dirty: true}
+	s.constants.SetVec4(skyMode, mgl32.Vec4{float32(mode), 0.0, 0.0, 0.0})
+	s.setSunDirection(mgl32.Vec4{0.0, 1.0, 0.0, 0.9995})
+
+	mesh := renderSystem.NewMesh()
+	mesh.SetName("sky")
+	mesh.SetPositions([]float32{-1.0, -1.0, 0.0, 3.0, -1.0, 0.0, -1.0, 3.0, 0.0})
+	mesh.SetNormals(make([]float32, 9))
+	mesh.SetTangents(make([]float32, 9))
+	mesh.SetBitangents(make([]float32, 9))
+	mesh.SetTextureCoordinates(make([]float32, 9))
+	mesh.SetIndices([]uint16{0, 1, 2})
+	mesh.SetPrimitiveType(PrimitiveTypeTriangles)
+
+	s.node = NewNode("sky")
+	s.node.SetMesh(mesh)
+	s.node.state = resourceManager.State("sky")
+
+	if texture != nil {
+		s.node.MaterialData().SetTexture(s.samplerName(), texture)
+	}
+
+	return s
+}
+
+// NewCubemapSky returns a sky which samples a cubemap texture.
+func NewCubemapSky(texture Texture) *Sky {
+	return newSky(SkyModeCubemap, texture)
+}
+
+// NewEquirectangularSky returns a sky which samples an equirectangular panorama, with the top row of the
+// image looking straight up.
+func NewEquirectangularSky(texture Texture) *Sky {
+	return newSky(SkyModeEquirectangular, texture)
+}
+
+// NewGradientSky returns a procedural sky which blends from the horizon color to the zenith color above the
+// horizon and to the ground color below it.
+func NewGradientSky(zenith, horizon, ground mgl32.Vec4) *Sky {
+	s := newSky(SkyModeGradient, nil)
+	s.SetColors(zenith, horizon, ground)
+	return s
+}
+
+// NewAnalyticSky returns a procedural daylight sky lit by a sun in the given direction, which points from the
+// scene towards the sun.
+func NewAnalyticSky(sunDirection mgl32.Vec3) *Sky {
+	s := newSky(SkyModeAnalytic, nil)
+	s.SetSunDirection(sunDirection)
+	return s
+}
+
+// Mode returns the sky's mode.
+func (s *Sky) Mode() SkyMode {
+	return s.mode
+}
+
+// Texture returns the sky's environment texture, or nil for procedural skies.
+func (s *Sky) Texture() Texture {
+	return s.texture
+}
+
+// SetSunDirection sets the direction towards the sun of analytic skies.
+func (s *Sky) SetSunDirection(d mgl32.Vec3) {
+	d = d.Normalize()
+	s.setSunDirection(mgl32.Vec4{d.X(), d.Y(), d.Z(), s.sunDirection.W()})
+}
+
+// SetSunSize sets the angular radius in radians of the sun disk of analytic skies.
+func (s *Sky) SetSunSize(radians float32) {
+	d := s.sunDirection
+	d[3] = float32(math.Cos(float64(radians)))
+	s.setSunDirection(d)
+}
+
+func (s *Sky) setSunDirection(d mgl32.Vec4) {
+	s.sunDirection = d
+	s.constants.SetVec4(skySunDirection, d)
+	s.dirty = true
+}
+
+// SetColors sets the zenith, horizon and ground colors of gradient skies.
+func (s *Sky) SetColors(zenith, horizon, ground mgl32.Vec4) {
+	s.constants.SetVec4(skyZenithColor, zenith)
+	s.constants.SetVec4(skyHorizonColor, horizon)
+	s.constants.SetVec4(skyGroundColor, ground)
+	s.dirty = true
+}
+
+// samplerName returns the sampler name for the sky's texture
+func (s *Sky) samplerName() string {
+	if s.mode == SkyModeCubemap {
+		return EnvironmentCubemapSampler
+	}
+	return EnvironmentMapSampler
+}
+
+// renderPass returns the pass which draws the sky, uploading its constants if they changed
+func (s *Sky) renderPass() RenderPass {
+	if s.dirty {
+		s.node.MaterialData().UniformBuffer(SkyConstantsBlock.Name()).Set(s.constants.Pointer(), len(s.constants))
+		s.dirty = false
+	}
+
+	return RenderPass{
+		State: s.node.state,
+		Name:  "Sky",
+		Nodes: []*Node{s.node},
+	}
+}
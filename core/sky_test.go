@@ -0,0 +1,77 @@
+package core
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fcvarela/gosg/protos"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSkyEnvironment(t *testing.T) {
+	setupTestSystems()
+
+	cubemap := renderSystem.NewTexture(TextureDescriptor{Target: TextureTargetCubemapXPositive}, nil)
+	panorama := renderSystem.NewTexture(TextureDescriptor{Target: TextureTarget2D}, nil)
+
+	a, b := NewCamera("a", PerspectiveProjection), NewCamera("b", PerspectiveProjection)
+	a.SetSky(NewCubemapSky(cubemap))
+	b.SetSky(NewEquirectangularSky(panorama))
+
+	// both cameras render the same node with their own environment
+	node := NewNode("shared")
+	buckets := map[*protos.State][]*Node{{}: {node}}
+	for _, c := range []*Camera{a, b} {
+		c.renderTechnique(c, buckets)
+	}
+	if len(node.MaterialData().Textures()) != 0 {
+		t.Error("environment textures should not be set on nodes")
+	}
+
+	if a.Constants().Textures()[EnvironmentCubemapSampler] != cubemap || b.Constants().Textures()[EnvironmentMapSampler] != panorama {
+		t.Error("cameras should expose their own sky's environment")
+	}
+	if _, ok := a.Constants().Textures()[EnvironmentMapSampler]; ok {
+		t.Error("cameras should only expose their sky's sampler")
+	}
+
+	a.SetSky(NewGradientSky(mgl32.Vec4{0, 0, 1, 1}, mgl32.Vec4{1, 1, 1, 1}, mgl32.Vec4{0, 0, 0, 1}))
+	if len(a.Constants().Textures()) != 0 {
+		t.Error("procedural skies should remove the previous environment")
+	}
+	b.SetSky(nil)
+	if len(b.Constants().Textures()) != 0 {
+		t.Error("removing the sky should remove its environment")
+	}
+}
+
+func TestSkyConstants(t *testing.T) {
+	setupTestSystems()
+
+	for name, offset := range map[string]int{"mode": 0, "zenithColor": 16, "horizonColor": 32, "groundColor": 48, "sunDirection": 64} {
+		if f := SkyConstantsBlock.Member(name); f.Offset != offset {
+			t.Errorf("%s has offset %d, expected %d", name, f.Offset, offset)
+		}
+	}
+
+	s := NewAnalyticSky(mgl32.Vec3{0, 0, 2})
+	s.SetSunSize(0)
+	s.renderPass()
+	if size := s.node.MaterialData().UniformBuffer(SkyConstantsBlock.Name()).(*testUniformBuffer).size; size != SkyConstantsBlock.Size() {
+		t.Errorf("uploaded %d bytes, expected %d", size, SkyConstantsBlock.Size())
+	}
+
+	sun := s.constants[skySunDirection.Offset:]
+	for i, expected := range []float32{0, 0, 1, 1} {
+		if v := math.Float32frombits(binary.LittleEndian.Uint32(sun[i*4:])); v != expected {
+			t.Errorf("sunDirection[%d] is %v, expected %v", i, v, expected)
+		}
+	}
+
+	source, err := preprocessShader("sky.fs.glsl", nil, testShaderReader(map[string]string{"sky.fs.glsl": "#include \"gosg/sky.glsl\"\n"}))
+	if err != nil || !strings.Contains(string(source.Source), SkyConstantsBlock.GLSL()) {
+		t.Errorf("gosg/sky.glsl doesn't declare skyConstants: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// State JSON files may build on other state files instead of repeating every field:
+//
+//	{
+//	    "extends": "opaque",
+//	    "fragments": ["fragments/alpha-blend"],
+//	    "programName": "ubershader",
+//	    "culling": false
+//	}
+//
+// The state named by extends is resolved first, the fragments are merged over it in order, and the state's own
+// fields are merged last. Fragments are partial state files resolved the same way, so they can extend and
+// include others too. Nested objects such as stencil faces are merged field by field, everything else is
+// replaced.
+
+const (
+	stateExtendsKey   = "extends"
+	stateFragmentsKey = "fragments"
+)
+
+// ResolvedState is a state with its extends and fragments chain merged, for inspecting how a state was built.
+type ResolvedState struct {
+	// Name is the name of the state
+	Name string
+
+	// Sources are the state files merged into the state in merge order, ending with the state itself
+	Sources []string
+
+	// JSON is the merged state, the state is unmarshalled from it
+	JSON []byte
+}
+
+// ResolveState reads a state and the states it extends and includes, and returns the merged result. It returns
+// an error if a state can't be read or the chain has a cycle.
+func (r *ResourceManager) ResolveState(name string) (ResolvedState, error) {
+	resolved := ResolvedState{Name: name}
+
+	fields, err := r.resolveStateFields(name, nil, &resolved.Sources)
+	if err != nil {
+		return resolved, err
+	}
+
+	resolved.JSON, err = json.MarshalIndent(fields, "", "    ")
+	return resolved, err
+}
+
+// resolveStateFields returns the merged fields of a state. chain holds the states being resolved, to detect
+// cycles, sources collects every state merged.
+func (r *ResourceManager) resolveStateFields(name string, chain []string, sources *[]string) (map[string]interface{}, error) {
+	for _, n := range chain {
+		if n == name {
+			return nil, fmt.Errorf("state inheritance cycle: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+	}
+	chain = append(chain, name)
+
+	resource, err := r.system.State(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load state %s: %v", name, err)
+	}
+
+	var own map[string]interface{}
+	if err := json.Unmarshal(resource, &own); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal state %s: %v", name, err)
+	}
+
+	var bases []string
+	if extends, ok := own[stateExtendsKey]; ok {
+		base, ok := extends.(string)
+		if !ok {
+			return nil, fmt.Errorf("state %s: %s must be a state name", name, stateExtendsKey)
+		}
+		bases = append(bases, base)
+	}
+	if fragments, ok := own[stateFragmentsKey]; ok {
+		list, ok := fragments.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("state %s: %s must be a list of state names", name, stateFragmentsKey)
+		}
+		for _, f := range list {
+			fragment, ok := f.(string)
+			if !ok {
+				return nil, fmt.Errorf("state %s: %s must be a list of state names", name, stateFragmentsKey)
+			}
+			bases = append(bases, fragment)
+		}
+	}
+	delete(own, stateExtendsKey)
+	delete(own, stateFragmentsKey)
+
+	merged := make(map[string]interface{})
+	for _, base := range bases {
+		fields, err := r.resolveStateFields(base, chain, sources)
+		if err != nil {
+			return nil, err
+		}
+		mergeStateFields(merged, fields)
+	}
+	mergeStateFields(merged, own)
+
+	*sources = append(*sources, name)
+	return merged, nil
+}
+
+// mergeStateFields merges src into dst. Keys are normalized to their camel case JSON names, which the state
+// unmarshaller accepts along with the original field names, so both spellings override each other.
+func mergeStateFields(dst, src map[string]interface{}) {
+	for key, value := range src {
+		key = StateFieldName(key)
+
+		srcObject, srcIsObject := value.(map[string]interface{})
+		dstObject, dstIsObject := dst[key].(map[string]interface{})
+		if srcIsObject && dstIsObject {
+			mergeStateFields(dstObject, srcObject)
+			continue
+		}
+		if srcIsObject {
+			object := make(map[string]interface{})
+			mergeStateFields(object, srcObject)
+			value = object
+		}
+
+		dst[key] = value
+	}
+}
+
+// StateFieldName returns the camel case JSON name of a state field, states are merged by these names so snake
+// and camel case keys override each other
+func StateFieldName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(c)))
+		} else {
+			b.WriteRune(c)
+		}
+		upper = false
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fcvarela/gosg/protos"
+)
+
+func TestStateExtends(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeState, "extends-base", []byte(`{"programName": "extends", "culling": true, "depthTest": true, "depthWrite": true, "stencilFront": {"ref": 1, "pass": "STENCIL_REPLACE"}}`))
+	testResources.change(ResourceTypeState, "extends-blend", []byte(`{"blending": true, "blend_src_mode": "BLEND_ONE"}`))
+	testResources.change(ResourceTypeState, "extends-child", []byte(`{"extends": "extends-base", "fragments": ["extends-blend"], "depthWrite": false, "blendSrcMode": "BLEND_ZERO", "stencilFront": {"ref": 2, "writeMask": 0}}`))
+
+	state := resourceManager.State("extends-child")
+	defer resourceManager.ReleaseState("extends-child")
+
+	if state.ProgramName != "extends" || !state.Culling || !state.DepthTest {
+		t.Errorf("base fields not inherited: %v", state)
+	}
+	if state.DepthWrite {
+		t.Error("fields set to false should override the base")
+	}
+	if !state.Blending || state.BlendSrcMode != protos.State_BLEND_ZERO {
+		t.Errorf("fragment fields not merged or not overridden: %v", state)
+	}
+	if state.StencilFront == nil || state.StencilFront.Ref != 2 || state.StencilFront.Pass != protos.State_STENCIL_REPLACE {
+		t.Errorf("nested fields not merged: %v", state.StencilFront)
+	}
+	if state.StencilFront.WriteMask == nil || *state.StencilFront.WriteMask != 0 || state.StencilFront.ReadMask != nil {
+		t.Errorf("stencil masks set to 0 should be kept apart from unset ones: %v", state.StencilFront)
+	}
+
+	resolved, err := resourceManager.ResolveState("extends-child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"extends-base", "extends-blend", "extends-child"}; !reflect.DeepEqual(resolved.Sources, expected) {
+		t.Errorf("sources %v, expected %v", resolved.Sources, expected)
+	}
+
+	// changing a base reloads the states built on it
+	testResources.change(ResourceTypeState, "extends-base", []byte(`{"programName": "extends", "culling": false}`))
+	resourceManager.update()
+	if state.Culling || state.DepthTest {
+		t.Errorf("state not reloaded after its base changed: %v", state)
+	}
+}
+
+func TestStateExtendsCycle(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeState, "cycle-a", []byte(`{"extends": "cycle-b"}`))
+	testResources.change(ResourceTypeState, "cycle-b", []byte(`{"fragments": ["cycle-a"]}`))
+
+	_, err := resourceManager.TryState("cycle-a")
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Uniform blocks shared between Go and GLSL are declared once, as a Std140Block. The block generates its GLSL
+// declaration, which shaders include, and locates its members in a Std140Buffer which is uploaded as is:
+//
+//	var block = NewStd140Block("sceneConstants",
+//		Std140Member{Name: "vpMatrix", Type: UniformTypeMat4},
+//		Std140Member{Name: "tints", Type: UniformTypeVec4, Length: 4},
+//	)
+//
+//	buffer := NewStd140Buffer(block)
+//	buffer.SetMat4(block.Member("vpMatrix"), vpMatrix)
+//	buffer.SetVec4(block.Member("tints").Index(2), tint)
+//	uniformBuffer.Set(buffer.Pointer(), len(buffer))
+//
+// Members are laid out with the std140 rules: scalars align to 4 bytes, vec2 to 8, vec3 and vec4 to 16. Matrices
+// are arrays of column vectors, elements of arrays and structs align to 16 bytes and arrays have a stride which is
+// a multiple of 16.
+
+// Std140Member declares a member of a std140 block or struct
+type Std140Member struct {
+	// Name is the member's GLSL name
+	Name string
+
+	// Type is the member's type, or the type of its elements for arrays. It is ignored for struct members.
+	Type UniformType
+
+	// Struct is the member's struct type, nil for members which are not structs
+	Struct *Std140Struct
+
+	// Length is the member's array length, 0 for members which are not arrays
+	Length int
+}
+
+// Std140Struct is a struct type laid out with the std140 rules
+type Std140Struct struct {
+	name    string
+	members []Std140Field
+	size    int
+}
+
+// Std140Block is a uniform block laid out with the std140 rules
+type Std140Block struct {
+	name    string
+	layout  *Std140Struct
+	structs []*Std140Struct
+}
+
+// Std140Field locates a member of a std140 block, or an element or struct member of one, in a Std140Buffer
+type Std140Field struct {
+	// Name is the name of the member the field is, or is an element of
+	Name string
+
+	// Type is the field's type, UniformTypeNone for structs
+	Type UniformType
+
+	// Struct is the field's struct type, nil for fields which are not structs
+	Struct *Std140Struct
+
+	// Length is the field's array length, 0 for fields which are not arrays
+	Length int
+
+	// Offset is the field's offset in bytes from the start of the block
+	Offset int
+
+	// ArrayStride is the distance in bytes between array elements, 0 for fields which are not arrays
+	ArrayStride int
+
+	// MatrixStride is the distance in bytes between matrix columns, 0 for fields which are not matrices
+	MatrixStride int
+}
+
+// std140Layout returns the base alignment, size and matrix stride of a value of a type outside of arrays
+func std140Layout(t UniformType) (align, size, matrixStride int) {
+	switch t {
+	case UniformTypeFloat, UniformTypeInt, UniformTypeUint:
+		return 4, 4, 0
+	case UniformTypeVec2:
+		return 8, 8, 0
+	case UniformTypeVec3:
+		return 16, 12, 0
+	case UniformTypeVec4:
+		return 16, 16, 0
+	case UniformTypeMat3:
+		return 16, 48, 16
+	case UniformTypeMat4:
+		return 16, 64, 16
+	}
+	panic(fmt.Sprintf("std140: %s members are not supported", t))
+}
+
+// roundUp rounds n up to a multiple of align
+func roundUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// layoutStd140 lays out members from offset 0 and returns their fields and the size of the layout, rounded up to
+// a multiple of 16
+func layoutStd140(members []Std140Member) ([]Std140Field, int) {
+	fields := make([]Std140Field, len(members))
+	offset := 0
+	for i, m := range members {
+		if m.Name == "" {
+			panic("std140: members need a name")
+		}
+		if m.Length < 0 {
+			panic(fmt.Sprintf("std140: %s has a negative length", m.Name))
+		}
+
+		f := Std140Field{Name: m.Name, Type: m.Type, Struct: m.Struct, Length: m.Length}
+		var align, size int
+		if m.Struct != nil {
+			f.Type = UniformTypeNone
+			align, size = 16, m.Struct.size
+		} else {
+			align, size, f.MatrixStride = std140Layout(m.Type)
+		}
+
+		if m.Length > 0 {
+			// array elements are padded to vec4s
+			align = 16
+			f.ArrayStride = roundUp(size, 16)
+			size = f.ArrayStride * m.Length
+		}
+
+		f.Offset = roundUp(offset, align)
+		offset = f.Offset + size
+		fields[i] = f
+	}
+	return fields, roundUp(offset, 16)
+}
+
+// NewStd140Struct returns a struct type with the given members. It panics if a member has an unsupported type.
+func NewStd140Struct(name string, members ...Std140Member) *Std140Struct {
+	fields, size := layoutStd140(members)
+	return &Std140Struct{name, fields, size}
+}
+
+// Name returns the struct's GLSL name
+func (s *Std140Struct) Name() string {
+	return s.name
+}
+
+// Size returns the struct's size in bytes
+func (s *Std140Struct) Size() int {
+	return s.size
+}
+
+// Members returns the struct's members, with offsets relative to the struct
+func (s *Std140Struct) Members() []Std140Field {
+	return s.members
+}
+
+// TexelAccessors returns GLSL functions reading the struct's members from a texture of RGBA32F texels, such as
+// one holding a Std140Buffer of structs, one per row. Each member is read by a function named by prefix and the
+// member's name, taking the row and, for arrays, the element index:
+//
+//	vec4 lightColor(int row);
+//	mat4 lightVpMatrix(int row, int i);
+//
+// The std140 layout is texel aligned: vec4s, matrix columns and array elements start on a texel.
+func (s *Std140Struct) TexelAccessors(prefix, sampler string) string {
+	var buf bytes.Buffer
+	for _, f := range s.members {
+		if f.Struct != nil {
+			panic(fmt.Sprintf("std140: cannot read struct member %s from texels", f.Name))
+		}
+
+		name := prefix + strings.ToUpper(f.Name[:1]) + f.Name[1:]
+		params := "int row"
+		if f.Length > 0 {
+			params += ", int i"
+		}
+		fetch := func(column int) string {
+			texel := fmt.Sprintf("%d", f.Offset/16+column)
+			if f.Length > 0 {
+				index := "i"
+				if stride := f.ArrayStride / 16; stride > 1 {
+					index = fmt.Sprintf("i * %d", stride)
+				}
+				if texel == "0" {
+					texel = index
+				} else {
+					texel = fmt.Sprintf("%s + %s", texel, index)
+				}
+			}
+			return fmt.Sprintf("texelFetch(%s, ivec2(%s, row), 0)", sampler, texel)
+		}
+
+		var value string
+		swizzle := "xyzw"[(f.Offset%16)/4:]
+		switch f.Type {
+		case UniformTypeMat3:
+			value = fmt.Sprintf("mat3(%s.xyz, %s.xyz, %s.xyz)", fetch(0), fetch(1), fetch(2))
+		case UniformTypeMat4:
+			value = fmt.Sprintf("mat4(%s, %s, %s, %s)", fetch(0), fetch(1), fetch(2), fetch(3))
+		case UniformTypeVec4:
+			value = fetch(0)
+		case UniformTypeInt:
+			value = fmt.Sprintf("floatBitsToInt(%s.%s)", fetch(0), swizzle[:1])
+		case UniformTypeUint:
+			value = fmt.Sprintf("floatBitsToUint(%s.%s)", fetch(0), swizzle[:1])
+		default:
+			value = fmt.Sprintf("%s.%s", fetch(0), swizzle[:f.Type.Components()])
+		}
+
+		fmt.Fprintf(&buf, "%s %s(%s) {\n    return %s;\n}\n\n", f.Type, name, params, value)
+	}
+	return buf.String()
+}
+
+// NewStd140Block returns a block with the given members. It panics if a member has an unsupported type.
+func NewStd140Block(name string, members ...Std140Member) *Std140Block {
+	b := &Std140Block{name: name, layout: NewStd140Struct(name, members...)}
+	b.addStructs(b.layout)
+	return b
+}
+
+// addStructs records the struct types used by s, dependencies first, for declaring them
+func (b *Std140Block) addStructs(s *Std140Struct) {
+	for _, f := range s.members {
+		if f.Struct == nil {
+			continue
+		}
+		b.addStructs(f.Struct)
+
+		declared := false
+		for _, d := range b.structs {
+			declared = declared || d == f.Struct
+		}
+		if !declared {
+			b.structs = append(b.structs, f.Struct)
+		}
+	}
+}
+
+// Name returns the block's GLSL name
+func (b *Std140Block) Name() string {
+	return b.name
+}
+
+// Size returns the block's size in bytes
+func (b *Std140Block) Size() int {
+	return b.layout.size
+}
+
+// Members returns the block's members
+func (b *Std140Block) Members() []Std140Field {
+	return b.layout.members
+}
+
+// Member returns the block member with the given name. It panics if there is no such member, blocks are declared
+// by the program using them.
+func (b *Std140Block) Member(name string) Std140Field {
+	for _, f := range b.layout.members {
+		if f.Name == name {
+			return f
+		}
+	}
+	panic(fmt.Sprintf("std140: block %s has no member %s", b.name, name))
+}
+
+// GLSL returns the block's GLSL declaration, preceded by the declarations of the structs it uses
+func (b *Std140Block) GLSL() string {
+	var buf bytes.Buffer
+	for _, s := range b.structs {
+		fmt.Fprintf(&buf, "struct %s {\n", s.name)
+		writeStd140Members(&buf, s.members)
+		buf.WriteString("};\n\n")
+	}
+
+	fmt.Fprintf(&buf, "layout (std140) uniform %s {\n", b.name)
+	writeStd140Members(&buf, b.layout.members)
+	buf.WriteString("};\n")
+	return buf.String()
+}
+
+// writeStd140Members writes the GLSL declarations of fields
+func writeStd140Members(buf *bytes.Buffer, fields []Std140Field) {
+	for _, f := range fields {
+		typeName := f.Type.String()
+		if f.Struct != nil {
+			typeName = f.Struct.name
+		}
+
+		if f.Length > 0 {
+			fmt.Fprintf(buf, "    %s %s[%d];\n", typeName, f.Name, f.Length)
+		} else {
+			fmt.Fprintf(buf, "    %s %s;\n", typeName, f.Name)
+		}
+	}
+}
+
+// Index returns element i of an array field. It panics if the field is not an array or i is out of range.
+func (f Std140Field) Index(i int) Std140Field {
+	if i < 0 || i >= f.Length {
+		panic(fmt.Sprintf("std140: index %d out of range for %s[%d]", i, f.Name, f.Length))
+	}
+
+	return Std140Field{
+		Name:         f.Name,
+		Type:         f.Type,
+		Struct:       f.Struct,
+		Offset:       f.Offset + i*f.ArrayStride,
+		MatrixStride: f.MatrixStride,
+	}
+}
+
+// Member returns a member of a struct field. It panics if the field is not a struct, is an array of structs or
+// the struct has no such member.
+func (f Std140Field) Member(name string) Std140Field {
+	if f.Struct == nil || f.Length > 0 {
+		panic(fmt.Sprintf("std140: %s is not a struct", f.Name))
+	}
+
+	for _, m := range f.Struct.members {
+		if m.Name == name {
+			m.Offset += f.Offset
+			return m
+		}
+	}
+	panic(fmt.Sprintf("std140: %s has no member %s", f.Name, name))
+}
+
+// Std140Buffer holds the data of a std140 block, ready for uploading to a UniformBuffer
+type Std140Buffer []byte
+
+// NewStd140Buffer returns a zeroed buffer for a block
+func NewStd140Buffer(b *Std140Block) Std140Buffer {
+	return make(Std140Buffer, b.Size())
+}
+
+// Pointer returns a pointer to the buffer's data for UniformBuffer.Set
+func (b Std140Buffer) Pointer() unsafe.Pointer {
+	return unsafe.Pointer(&b[0])
+}
+
+// check panics if a field has a different type than a value written to it, or is an array
+func (b Std140Buffer) check(f Std140Field, t UniformType) {
+	if f.Type != t || f.Length > 0 {
+		typeName := f.Type.String()
+		if f.Struct != nil {
+			typeName = f.Struct.name
+		}
+		if f.Length > 0 {
+			typeName = fmt.Sprintf("%s[%d]", typeName, f.Length)
+		}
+		panic(fmt.Sprintf("std140: %s is %s, cannot set a %s", f.Name, typeName, t))
+	}
+}
+
+// putFloats writes consecutive floats at an offset
+func (b Std140Buffer) putFloats(offset int, floats []float32) {
+	for i, v := range floats {
+		binary.LittleEndian.PutUint32(b[offset+i*4:], math.Float32bits(v))
+	}
+}
+
+// SetFloat sets a float field
+func (b Std140Buffer) SetFloat(f Std140Field, v float32) {
+	b.check(f, UniformTypeFloat)
+	b.putFloats(f.Offset, []float32{v})
+}
+
+// SetVec2 sets a vec2 field
+func (b Std140Buffer) SetVec2(f Std140Field, v mgl32.Vec2) {
+	b.check(f, UniformTypeVec2)
+	b.putFloats(f.Offset, v[:])
+}
+
+// SetVec3 sets a vec3 field
+func (b Std140Buffer) SetVec3(f Std140Field, v mgl32.Vec3) {
+	b.check(f, UniformTypeVec3)
+	b.putFloats(f.Offset, v[:])
+}
+
+// SetVec4 sets a vec4 field
+func (b Std140Buffer) SetVec4(f Std140Field, v mgl32.Vec4) {
+	b.check(f, UniformTypeVec4)
+	b.putFloats(f.Offset, v[:])
+}
+
+// SetMat3 sets a mat3 field
+func (b Std140Buffer) SetMat3(f Std140Field, m mgl32.Mat3) {
+	b.check(f, UniformTypeMat3)
+	for c := 0; c < 3; c++ {
+		b.putFloats(f.Offset+c*f.MatrixStride, m[c*3:c*3+3])
+	}
+}
+
+// SetMat4 sets a mat4 field
+func (b Std140Buffer) SetMat4(f Std140Field, m mgl32.Mat4) {
+	b.check(f, UniformTypeMat4)
+	for c := 0; c < 4; c++ {
+		b.putFloats(f.Offset+c*f.MatrixStride, m[c*4:c*4+4])
+	}
+}
+
+// SetInt sets an int field
+func (b Std140Buffer) SetInt(f Std140Field, v int32) {
+	b.check(f, UniformTypeInt)
+	binary.LittleEndian.PutUint32(b[f.Offset:], uint32(v))
+}
+
+// SetUint sets an uint field
+func (b Std140Buffer) SetUint(f Std140Field, v uint32) {
+	b.check(f, UniformTypeUint)
+	binary.LittleEndian.PutUint32(b[f.Offset:], v)
+}
+
+// SetValue sets a field to the first element of a uniform value. It panics if the value has a different type.
+func (b Std140Buffer) SetValue(f Std140Field, v UniformValue) {
+	b.check(f, v.Type())
+
+	switch v.Type() {
+	case UniformTypeInt, UniformTypeUint:
+		binary.LittleEndian.PutUint32(b[f.Offset:], uint32(v.Ints()[0]))
+	case UniformTypeMat3, UniformTypeMat4:
+		n := 3
+		if v.Type() == UniformTypeMat4 {
+			n = 4
+		}
+		floats := v.Floats()
+		for c := 0; c < n; c++ {
+			b.putFloats(f.Offset+c*f.MatrixStride, floats[c*n:c*n+n])
+		}
+	default:
+		b.putFloats(f.Offset, v.Floats()[:v.Type().Components()])
+	}
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestStd140Layout(t *testing.T) {
+	pair := NewStd140Struct("pair",
+		Std140Member{Name: "p", Type: UniformTypeVec3},
+		Std140Member{Name: "q", Type: UniformTypeFloat},
+	)
+	block := NewStd140Block("mixed",
+		Std140Member{Name: "a", Type: UniformTypeFloat},
+		Std140Member{Name: "b", Type: UniformTypeVec3},
+		Std140Member{Name: "c", Type: UniformTypeFloat},
+		Std140Member{Name: "d", Type: UniformTypeVec2},
+		Std140Member{Name: "e", Type: UniformTypeFloat, Length: 2},
+		Std140Member{Name: "m", Type: UniformTypeMat3},
+		Std140Member{Name: "s", Struct: pair},
+		Std140Member{Name: "i", Type: UniformTypeInt},
+	)
+
+	expected := map[string][2]int{
+		"a": {0, 0},
+		"b": {16, 0},
+		"c": {28, 0},
+		"d": {32, 0},
+		"e": {48, 16},
+		"m": {80, 0},
+		"s": {128, 0},
+		"i": {144, 0},
+	}
+	for name, e := range expected {
+		f := block.Member(name)
+		if f.Offset != e[0] || f.ArrayStride != e[1] {
+			t.Errorf("%s has offset %d and stride %d, expected %d and %d", name, f.Offset, f.ArrayStride, e[0], e[1])
+		}
+	}
+	if pair.Size() != 16 {
+		t.Errorf("pair has size %d, expected 16", pair.Size())
+	}
+	if block.Size() != 160 {
+		t.Errorf("block has size %d, expected 160", block.Size())
+	}
+	if q := block.Member("s").Member("q"); q.Offset != 140 {
+		t.Errorf("s.q has offset %d, expected 140", q.Offset)
+	}
+}
+
+func TestCameraConstantsLayout(t *testing.T) {
+	if LightStruct.Size() != 848 {
+		t.Errorf("light has size %d, expected 848", LightStruct.Size())
+	}
+	for _, m := range LightStruct.Members() {
+		expected := map[string]int{"vpMatrix": 0, "zCuts": 640, "position": 800, "color": 816, "radius": 832}[m.Name]
+		if m.Offset != expected {
+			t.Errorf("light.%s has offset %d, expected %d", m.Name, m.Offset, expected)
+		}
+	}
+	for name, offset := range map[string]int{"vMatrix": 0, "pMatrix": 64, "vpMatrix": 128, "lightCount": 192, "lightClusters": 208, "lightClusterDepth": 224} {
+		if f := CameraConstantsBlock.Member(name); f.Offset != offset {
+			t.Errorf("%s has offset %d, expected %d", name, f.Offset, offset)
+		}
+	}
+	if size := CameraConstantsBlock.Size(); size != 240 {
+		t.Errorf("cameraConstants has size %d, expected 240", size)
+	}
+
+	// every LightBlock field is packed
+	fields := reflect.TypeOf(LightBlock{})
+	if fields.NumField() != len(LightStruct.Members()) {
+		t.Fatalf("LightBlock has %d fields, light has %d members", fields.NumField(), len(LightStruct.Members()))
+	}
+	for i, m := range LightStruct.Members() {
+		if name := fields.Field(i).Name; !strings.EqualFold(name, m.Name) {
+			t.Errorf("LightBlock field %s is packed as %s", name, m.Name)
+		}
+	}
+}
+
+func TestStd140GLSL(t *testing.T) {
+	block := NewStd140Block("sceneConstants",
+		Std140Member{Name: "vpMatrix", Type: UniformTypeMat4},
+		Std140Member{Name: "lights", Struct: LightStruct, Length: 4},
+	)
+
+	expected := strings.Join([]string{
+		"struct light {",
+		"    mat4 vpMatrix[10];",
+		"    vec4 zCuts[10];",
+		"    vec4 position;",
+		"    vec4 color;",
+		"    float radius;",
+		"};",
+		"",
+		"layout (std140) uniform sceneConstants {",
+		"    mat4 vpMatrix;",
+		"    light lights[4];",
+		"};",
+		"",
+	}, "\n")
+	if glsl := block.GLSL(); glsl != expected {
+		t.Errorf("unexpected declaration:\n%s\nexpected:\n%s", glsl, expected)
+	}
+}
+
+func TestStd140TexelAccessors(t *testing.T) {
+	s := NewStd140Struct("probe",
+		Std140Member{Name: "transform", Type: UniformTypeMat4},
+		Std140Member{Name: "cascades", Type: UniformTypeMat3, Length: 2},
+		Std140Member{Name: "weights", Type: UniformTypeVec4, Length: 2},
+		Std140Member{Name: "offset", Type: UniformTypeVec2},
+		Std140Member{Name: "radius", Type: UniformTypeFloat},
+		Std140Member{Name: "index", Type: UniformTypeInt},
+	)
+
+	expected := strings.Join([]string{
+		"mat4 probeTransform(int row) {",
+		"    return mat4(texelFetch(probeTex, ivec2(0, row), 0), texelFetch(probeTex, ivec2(1, row), 0), texelFetch(probeTex, ivec2(2, row), 0), texelFetch(probeTex, ivec2(3, row), 0));",
+		"}",
+		"",
+		"mat3 probeCascades(int row, int i) {",
+		"    return mat3(texelFetch(probeTex, ivec2(4 + i * 3, row), 0).xyz, texelFetch(probeTex, ivec2(5 + i * 3, row), 0).xyz, texelFetch(probeTex, ivec2(6 + i * 3, row), 0).xyz);",
+		"}",
+		"",
+		"vec4 probeWeights(int row, int i) {",
+		"    return texelFetch(probeTex, ivec2(10 + i, row), 0);",
+		"}",
+		"",
+		"vec2 probeOffset(int row) {",
+		"    return texelFetch(probeTex, ivec2(12, row), 0).xy;",
+		"}",
+		"",
+		"float probeRadius(int row) {",
+		"    return texelFetch(probeTex, ivec2(12, row), 0).z;",
+		"}",
+		"",
+		"int probeIndex(int row) {",
+		"    return floatBitsToInt(texelFetch(probeTex, ivec2(12, row), 0).w);",
+		"}",
+		"",
+		"",
+	}, "\n")
+	if glsl := s.TexelAccessors("probe", "probeTex"); glsl != expected {
+		t.Errorf("unexpected accessors:\n%s\nexpected:\n%s", glsl, expected)
+	}
+}
+
+func TestStd140Buffer(t *testing.T) {
+	block := NewStd140Block("packed",
+		Std140Member{Name: "m", Type: UniformTypeMat3},
+		Std140Member{Name: "v", Type: UniformTypeVec4, Length: 2},
+		Std140Member{Name: "u", Type: UniformTypeUint},
+	)
+	buf := NewStd140Buffer(block)
+
+	buf.SetMat3(block.Member("m"), mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	buf.SetVec4(block.Member("v").Index(1), mgl32.Vec4{10, 11, 12, 13})
+	buf.SetUint(block.Member("u"), 14)
+
+	float := func(offset int) float32 {
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf[offset:]))
+	}
+
+	// matrix columns are padded to vec4s
+	for i, offset := range []int{0, 4, 8, 16, 20, 24, 32, 36, 40} {
+		if v := float(offset); v != float32(i+1) {
+			t.Errorf("m[%d] at %d is %v", i, offset, v)
+		}
+	}
+	if v := float(12); v != 0 {
+		t.Errorf("padding is %v", v)
+	}
+	for i := 0; i < 4; i++ {
+		if v := float(64 + i*4); v != float32(10+i) {
+			t.Errorf("v[1][%d] is %v", i, v)
+		}
+	}
+	if u := binary.LittleEndian.Uint32(buf[80:]); u != 14 {
+		t.Errorf("u is %d", u)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("setting a vec4 member to a mat4 should panic")
+		}
+	}()
+	buf.SetMat4(block.Member("v").Index(0), mgl32.Ident4())
+}
@@ -15,8 +15,16 @@ const (
 	TextureTargetCubemapYNegative
 	TextureTargetCubemapZPositive
 	TextureTargetCubemapZNegative
+	TextureTargetCubemap
+	TextureTarget3D
 )
 
+// CubemapFace returns the layer index of a cubemap face target, as used by Texture.SetLayer and layered
+// framebuffer attachments. Faces are ordered +X, -X, +Y, -Y, +Z, -Z.
+func (t TextureTarget) CubemapFace() int {
+	return int(t - TextureTargetCubemapXPositive)
+}
+
 type TextureFormat int
 
 const (
@@ -43,13 +51,28 @@ const (
 	TextureSizedFormatRGBA16F
 	TextureSizedFormatRGBA32F
 	TextureSizedFormatDEPTH32F
+
+	// block compressed formats, only usable when the RenderSystem supports them
+	TextureSizedFormatBC1
+	TextureSizedFormatBC2
+	TextureSizedFormatBC3
+	TextureSizedFormatBC4
+	TextureSizedFormatBC5
+	TextureSizedFormatBC6H
+	TextureSizedFormatBC7
 )
 
+// Compressed returns whether the format is block compressed
+func (f TextureSizedFormat) Compressed() bool {
+	return f >= TextureSizedFormatBC1
+}
+
 type TextureComponentType int
 
 const (
 	TextureComponentTypeUNSIGNEDBYTE TextureComponentType = iota
 	TextureComponentTypeFLOAT
+	TextureComponentTypeHALFFLOAT
 )
 
 type TextureWrapMode int
@@ -72,8 +95,13 @@ const (
 // It is used as input to texture creation functions and at runtime inside rendersystems
 // to setup samplers and memory allocation
 type TextureDescriptor struct {
-	Width         uint32
-	Height        uint32
+	Width  uint32
+	Height uint32
+
+	// Depth is the number of layers of 2D array textures and the depth of 3D textures. It is ignored
+	// for other targets, cubemaps always have six faces.
+	Depth uint32
+
 	Mipmaps       bool
 	Target        TextureTarget
 	Format        TextureFormat
@@ -83,12 +111,96 @@ type TextureDescriptor struct {
 	WrapMode      TextureWrapMode
 }
 
+// Layers returns the number of layers of each mip level of a texture with this descriptor: six for cubemaps,
+// the depth for 2D arrays and 3D textures, which is halved for each level of the latter, and one otherwise.
+func (d TextureDescriptor) Layers(level int) int {
+	switch d.Target {
+	case TextureTargetCubemap:
+		return 6
+	case TextureTarget2DArray:
+		return int(d.Depth)
+	case TextureTarget3D:
+		if depth := int(d.Depth >> uint(level)); depth > 1 {
+			return depth
+		}
+	}
+	return 1
+}
+
+// texelSizes is the size in bytes of a texel of each uncompressed sized format, and of a 4x4 block of each
+// compressed one
+var texelSizes = map[TextureSizedFormat]int{
+	TextureSizedFormatR8:       1,
+	TextureSizedFormatR16F:     2,
+	TextureSizedFormatR32F:     4,
+	TextureSizedFormatRG8:      2,
+	TextureSizedFormatRG16F:    4,
+	TextureSizedFormatRG32F:    8,
+	TextureSizedFormatRGB8:     3,
+	TextureSizedFormatRGB16F:   6,
+	TextureSizedFormatRGB32F:   12,
+	TextureSizedFormatRGBA8:    4,
+	TextureSizedFormatRGBA16F:  8,
+	TextureSizedFormatRGBA32F:  16,
+	TextureSizedFormatDEPTH32F: 4,
+	TextureSizedFormatBC1:      8,
+	TextureSizedFormatBC2:      16,
+	TextureSizedFormatBC3:      16,
+	TextureSizedFormatBC4:      8,
+	TextureSizedFormatBC5:      16,
+	TextureSizedFormatBC6H:     16,
+	TextureSizedFormatBC7:      16,
+}
+
+// StorageSize returns the number of bytes of texel storage of a texture with this descriptor, including every
+// layer and, when mipmapped, a full mip chain. Drivers may pad or compress storage, so this is an estimate.
+func (d TextureDescriptor) StorageSize() int {
+	size := 0
+	width, height := int(d.Width), int(d.Height)
+	for level := 0; ; level++ {
+		if d.SizedFormat.Compressed() {
+			size += ((width + 3) / 4) * ((height + 3) / 4) * texelSizes[d.SizedFormat] * d.Layers(level)
+		} else {
+			size += width * height * texelSizes[d.SizedFormat] * d.Layers(level)
+		}
+
+		last := width <= 1 && height <= 1 && (d.Target != TextureTarget3D || d.Layers(level) == 1)
+		if !d.Mipmaps || last {
+			return size
+		}
+		if width > 1 {
+			width /= 2
+		}
+		if height > 1 {
+			height /= 2
+		}
+	}
+}
+
 // Texture is an interface which wraps both a texture and settings for samplers sampling it
 type Texture interface {
 	Descriptor() TextureDescriptor
 
 	Handle() unsafe.Pointer
 
+	// SetLayer replaces the texels of a single layer of a mip level: a cubemap face, a 2D array layer or
+	// a 3D texture slice. The data must match the descriptor's format and the level's dimensions.
+	SetLayer(level, layer int, data []byte)
+
+	// GenerateMipmaps regenerates all mip levels from the first one, typically after SetLayer.
+	GenerateMipmaps()
+
+	// SetImage replaces the texture's storage and texels with a decoded image, which may have a different size
+	// or format. The texture keeps its identity, so materials using it sample the new image.
+	SetImage(img *DecodedImage)
+
+	// ImageData returns the encoded image (png, jpeg, etc) this texture was created from,
+	// or nil if it was created from raw texel data.
+	ImageData() []byte
+
+	// Delete frees the texture's GPU storage. The texture must not be used afterwards.
+	Delete()
+
 	// Lt is used for sorting
 	Lt(Texture) bool
 
@@ -1,24 +1,396 @@
 package core
 
-import "unsafe"
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+)
 
 // Uniform is an interface which wraps program Uniforms. This will be replaced by PushConstants.
 type Uniform interface {
 
 	// Value returns the Uniform's value
-	Value() interface{}
+	Value() UniformValue
 
 	// Set sets the Uniform's value
-	Set(interface{})
+	Set(UniformValue)
 
 	// Copy returns a copy of the Uniform
 	Copy() Uniform
 }
 
+// UniformType is the type of a uniform, or of the elements of an array uniform
+type UniformType int
+
+const (
+	// UniformTypeNone is the type of unset values and of uniforms of types which can't be set
+	UniformTypeNone UniformType = iota
+
+	// UniformTypeFloat is a float
+	UniformTypeFloat
+
+	// UniformTypeVec2 is a vec2
+	UniformTypeVec2
+
+	// UniformTypeVec3 is a vec3
+	UniformTypeVec3
+
+	// UniformTypeVec4 is a vec4
+	UniformTypeVec4
+
+	// UniformTypeMat3 is a mat3
+	UniformTypeMat3
+
+	// UniformTypeMat4 is a mat4
+	UniformTypeMat4
+
+	// UniformTypeInt is an int, samplers are set as ints too
+	UniformTypeInt
+
+	// UniformTypeUint is an uint
+	UniformTypeUint
+)
+
+func (t UniformType) String() string {
+	switch t {
+	case UniformTypeNone:
+		return "none"
+	case UniformTypeFloat:
+		return "float"
+	case UniformTypeVec2:
+		return "vec2"
+	case UniformTypeVec3:
+		return "vec3"
+	case UniformTypeVec4:
+		return "vec4"
+	case UniformTypeMat3:
+		return "mat3"
+	case UniformTypeMat4:
+		return "mat4"
+	case UniformTypeInt:
+		return "int"
+	case UniformTypeUint:
+		return "uint"
+	}
+	return fmt.Sprintf("UniformType(%d)", int(t))
+}
+
+// Components returns the number of scalars in a value of the type
+func (t UniformType) Components() int {
+	switch t {
+	case UniformTypeFloat, UniformTypeInt, UniformTypeUint:
+		return 1
+	case UniformTypeVec2:
+		return 2
+	case UniformTypeVec3:
+		return 3
+	case UniformTypeVec4:
+		return 4
+	case UniformTypeMat3:
+		return 9
+	case UniformTypeMat4:
+		return 16
+	}
+	return 0
+}
+
+// UniformValue is a typed uniform value, a single value or an array. Values are immutable, create them with the
+// Uniform* functions.
+type UniformValue struct {
+	typ    UniformType
+	length int
+	floats []float32
+	ints   []int32
+}
+
+// NewUniformValue returns a value of the given type from its components, matrices in column major order. The
+// number of components sets the array length. Components of int and uint values are truncated.
+func NewUniformValue(t UniformType, components []float32) (UniformValue, error) {
+	n := t.Components()
+	if n == 0 {
+		return UniformValue{}, fmt.Errorf("cannot create %s uniform values", t)
+	}
+	if len(components) == 0 || len(components)%n != 0 {
+		return UniformValue{}, fmt.Errorf("%s uniforms need a multiple of %d components, got %d", t, n, len(components))
+	}
+
+	v := UniformValue{typ: t, length: len(components) / n}
+	switch t {
+	case UniformTypeInt, UniformTypeUint:
+		v.ints = make([]int32, len(components))
+		for i, c := range components {
+			if t == UniformTypeUint {
+				v.ints[i] = int32(uint32(c))
+			} else {
+				v.ints[i] = int32(c)
+			}
+		}
+	default:
+		v.floats = append([]float32(nil), components...)
+	}
+	return v, nil
+}
+
+func uniformFloats(t UniformType, floats ...float32) UniformValue {
+	return UniformValue{typ: t, length: len(floats) / t.Components(), floats: floats}
+}
+
+// UniformFloat returns a float uniform value
+func UniformFloat(f float32) UniformValue {
+	return uniformFloats(UniformTypeFloat, f)
+}
+
+// UniformVec2 returns a vec2 uniform value
+func UniformVec2(v mgl64.Vec2) UniformValue {
+	f := Vec2DoubleToFloat(v)
+	return uniformFloats(UniformTypeVec2, f[:]...)
+}
+
+// UniformVec3 returns a vec3 uniform value
+func UniformVec3(v mgl64.Vec3) UniformValue {
+	f := Vec3DoubleToFloat(v)
+	return uniformFloats(UniformTypeVec3, f[:]...)
+}
+
+// UniformVec4 returns a vec4 uniform value
+func UniformVec4(v mgl64.Vec4) UniformValue {
+	f := Vec4DoubleToFloat(v)
+	return uniformFloats(UniformTypeVec4, f[:]...)
+}
+
+// UniformMat3 returns a mat3 uniform value
+func UniformMat3(m mgl64.Mat3) UniformValue {
+	f := make([]float32, 9)
+	for i := range m {
+		f[i] = float32(m[i])
+	}
+	return uniformFloats(UniformTypeMat3, f...)
+}
+
+// UniformMat4 returns a mat4 uniform value
+func UniformMat4(m mgl64.Mat4) UniformValue {
+	f := Mat4DoubleToFloat(m)
+	return uniformFloats(UniformTypeMat4, f[:]...)
+}
+
+// UniformInt returns an int uniform value
+func UniformInt(i int32) UniformValue {
+	return UniformValue{typ: UniformTypeInt, length: 1, ints: []int32{i}}
+}
+
+// UniformUint returns an uint uniform value
+func UniformUint(u uint32) UniformValue {
+	return UniformValue{typ: UniformTypeUint, length: 1, ints: []int32{int32(u)}}
+}
+
+// UniformFloatArray returns a float array uniform value
+func UniformFloatArray(f []float32) UniformValue {
+	return uniformFloats(UniformTypeFloat, append([]float32(nil), f...)...)
+}
+
+// UniformVec2Array returns a vec2 array uniform value
+func UniformVec2Array(v []mgl64.Vec2) UniformValue {
+	f := make([]float32, 0, len(v)*2)
+	for _, e := range v {
+		f = append(f, float32(e[0]), float32(e[1]))
+	}
+	return uniformFloats(UniformTypeVec2, f...)
+}
+
+// UniformVec3Array returns a vec3 array uniform value
+func UniformVec3Array(v []mgl64.Vec3) UniformValue {
+	f := make([]float32, 0, len(v)*3)
+	for _, e := range v {
+		f = append(f, float32(e[0]), float32(e[1]), float32(e[2]))
+	}
+	return uniformFloats(UniformTypeVec3, f...)
+}
+
+// UniformVec4Array returns a vec4 array uniform value
+func UniformVec4Array(v []mgl64.Vec4) UniformValue {
+	f := make([]float32, 0, len(v)*4)
+	for _, e := range v {
+		f = append(f, float32(e[0]), float32(e[1]), float32(e[2]), float32(e[3]))
+	}
+	return uniformFloats(UniformTypeVec4, f...)
+}
+
+// UniformMat4Array returns a mat4 array uniform value
+func UniformMat4Array(m []mgl64.Mat4) UniformValue {
+	f := make([]float32, 0, len(m)*16)
+	for _, e := range m {
+		m32 := Mat4DoubleToFloat(e)
+		f = append(f, m32[:]...)
+	}
+	return uniformFloats(UniformTypeMat4, f...)
+}
+
+// UniformIntArray returns an int array uniform value
+func UniformIntArray(i []int32) UniformValue {
+	return UniformValue{typ: UniformTypeInt, length: len(i), ints: append([]int32(nil), i...)}
+}
+
+// UniformValueOf converts a Go value to a uniform value. Supported values are float32, float64, int, int32, uint32,
+// mgl32 and mgl64 vectors and 3x3 and 4x4 matrices, and slices of float32, int32, mgl32.Vec2 and mgl64 vectors and
+// 4x4 matrices.
+func UniformValueOf(value interface{}) (UniformValue, error) {
+	switch v := value.(type) {
+	case UniformValue:
+		return v, nil
+	case float32:
+		return UniformFloat(v), nil
+	case float64:
+		return UniformFloat(float32(v)), nil
+	case int:
+		return UniformInt(int32(v)), nil
+	case int32:
+		return UniformInt(v), nil
+	case uint32:
+		return UniformUint(v), nil
+	case mgl32.Vec2:
+		return uniformFloats(UniformTypeVec2, v[:]...), nil
+	case mgl32.Vec3:
+		return uniformFloats(UniformTypeVec3, v[:]...), nil
+	case mgl32.Vec4:
+		return uniformFloats(UniformTypeVec4, v[:]...), nil
+	case mgl32.Mat3:
+		return uniformFloats(UniformTypeMat3, v[:]...), nil
+	case mgl32.Mat4:
+		return uniformFloats(UniformTypeMat4, v[:]...), nil
+	case mgl64.Vec2:
+		return UniformVec2(v), nil
+	case mgl64.Vec3:
+		return UniformVec3(v), nil
+	case mgl64.Vec4:
+		return UniformVec4(v), nil
+	case mgl64.Mat3:
+		return UniformMat3(v), nil
+	case mgl64.Mat4:
+		return UniformMat4(v), nil
+	case []float32:
+		return UniformFloatArray(v), nil
+	case []int32:
+		return UniformIntArray(v), nil
+	case []mgl32.Vec2:
+		f := make([]float32, 0, len(v)*2)
+		for _, e := range v {
+			f = append(f, e[0], e[1])
+		}
+		return uniformFloats(UniformTypeVec2, f...), nil
+	case []mgl64.Vec2:
+		return UniformVec2Array(v), nil
+	case []mgl64.Vec3:
+		return UniformVec3Array(v), nil
+	case []mgl64.Vec4:
+		return UniformVec4Array(v), nil
+	case []mgl64.Mat4:
+		return UniformMat4Array(v), nil
+	}
+	return UniformValue{}, fmt.Errorf("unsupported uniform value type %T", value)
+}
+
+// Type returns the type of the value, or of its elements for arrays. Unset values have UniformTypeNone.
+func (v UniformValue) Type() UniformType {
+	return v.typ
+}
+
+// Len returns the number of elements of the value, 1 for single values and 0 for unset values.
+func (v UniformValue) Len() int {
+	return v.length
+}
+
+// Floats returns the components of float, vector and matrix values, matrices in column major order. It must not
+// be modified.
+func (v UniformValue) Floats() []float32 {
+	return v.floats
+}
+
+// Ints returns the components of int and uint values, uints as their bit pattern. It must not be modified.
+func (v UniformValue) Ints() []int32 {
+	return v.ints
+}
+
+// Equal returns whether two values have the same type and components
+func (v UniformValue) Equal(o UniformValue) bool {
+	if v.typ != o.typ || v.length != o.length || len(v.floats) != len(o.floats) || len(v.ints) != len(o.ints) {
+		return false
+	}
+	for i := range v.floats {
+		if v.floats[i] != o.floats[i] {
+			return false
+		}
+	}
+	for i := range v.ints {
+		if v.ints[i] != o.ints[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v UniformValue) String() string {
+	if v.typ == UniformTypeNone {
+		return "none"
+	}
+	if v.floats != nil {
+		return fmt.Sprintf("%s[%d]%v", v.typ, v.length, v.floats)
+	}
+	return fmt.Sprintf("%s[%d]%v", v.typ, v.length, v.ints)
+}
+
+// UniformInfo describes an active uniform of a program
+type UniformInfo struct {
+	// Name is the name of the uniform, without array subscripts
+	Name string
+
+	// Type is the type of the uniform, or of its elements for arrays
+	Type UniformType
+
+	// Size is the array length, 1 for uniforms which are not arrays
+	Size int
+
+	// PerInstance is set for uniforms set per node in instanced draws. Nodes set a single element of these arrays.
+	PerInstance bool
+}
+
+// Validate returns an error if the value can't be assigned to the uniform
+func (u UniformInfo) Validate(v UniformValue) error {
+	if u.Type == UniformTypeNone {
+		return fmt.Errorf("uniform %s has a type which can't be set", u.Name)
+	}
+	if v.Type() != u.Type {
+		return fmt.Errorf("uniform %s is a %s, got a %s", u.Name, u.Type, v.Type())
+	}
+
+	size := u.Size
+	if u.PerInstance {
+		size = 1
+	}
+	if v.Len() > size {
+		return fmt.Errorf("uniform %s holds %d values, got %d", u.Name, size, v.Len())
+	}
+	return nil
+}
+
+// ValidateUniform returns an error if a value can't be assigned to the named uniform of the program, because the
+// program has no such active uniform or its type differs.
+func ValidateUniform(p Program, name string, v UniformValue) error {
+	info, ok := p.Uniform(name)
+	if !ok {
+		return fmt.Errorf("program %s has no active uniform %s", p.Name(), name)
+	}
+	return info.Validate(v)
+}
+
 // UniformBuffer is an interface which wraps program UniformBuffers. This will be replaced by ConstantBuffers.
 type UniformBuffer interface {
 	Set(unsafe.Pointer, int)
 
+	// Delete frees the buffer's GPU storage. The buffer must not be used afterwards.
+	Delete()
+
 	// Lt is used for sorting
 	Lt(UniformBuffer) bool
 
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestUniformValues(t *testing.T) {
+	v := UniformVec3(mgl64.Vec3{1, 2, 3})
+	if v.Type() != UniformTypeVec3 || v.Len() != 1 || len(v.Floats()) != 3 {
+		t.Errorf("unexpected vec3 %v", v)
+	}
+
+	m := UniformMat4Array([]mgl64.Mat4{mgl64.Ident4(), mgl64.Ident4()})
+	if m.Type() != UniformTypeMat4 || m.Len() != 2 || m.Floats()[16] != 1 {
+		t.Errorf("unexpected mat4 array %v", m)
+	}
+
+	if _, err := NewUniformValue(UniformTypeVec4, []float32{1, 2, 3}); err == nil {
+		t.Error("values with a partial element should be rejected")
+	}
+	u, err := NewUniformValue(UniformTypeUint, []float32{7})
+	if err != nil || u.Ints()[0] != 7 {
+		t.Errorf("unexpected uint %v: %v", u, err)
+	}
+
+	converted, err := UniformValueOf(mgl64.Vec4{1, 0, 0, 1})
+	if err != nil || !converted.Equal(UniformVec4(mgl64.Vec4{1, 0, 0, 1})) {
+		t.Errorf("unexpected conversion %v: %v", converted, err)
+	}
+	if _, err := UniformValueOf("red"); err == nil {
+		t.Error("unsupported go values should be rejected")
+	}
+}
+
+func TestUniformValidation(t *testing.T) {
+	setupTestSystems()
+
+	testResources.change(ResourceTypeProgram, "uniforms", []byte("uniform float roughness\nuniform int mode"))
+	testResources.change(ResourceTypeState, "uniforms", []byte(`{"programName": "uniforms"}`))
+	node := NewNode("uniforms")
+	node.state = resourceManager.State("uniforms")
+	defer resourceManager.ReleaseState("uniforms")
+
+	if err := node.SetUniform("roughness", UniformFloat(0.5)); err != nil {
+		t.Error(err)
+	}
+	if err := node.SetUniform("roughness", UniformVec2(mgl64.Vec2{})); err == nil || !strings.Contains(err.Error(), "is a float") {
+		t.Errorf("expected a type mismatch, got %v", err)
+	}
+	if err := node.SetUniform("mode", UniformFloatArray([]float32{1, 2})); err == nil {
+		t.Error("expected a type mismatch")
+	}
+	if err := node.SetUniform("missing", UniformFloat(1)); err == nil {
+		t.Error("expected an error for a missing uniform")
+	}
+	if v := node.MaterialData().Uniform("roughness").Value(); !v.Equal(UniformFloat(0.5)) {
+		t.Errorf("rejected values should not be set, got %v", v)
+	}
+}
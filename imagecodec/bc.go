@@ -0,0 +1,154 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Decompress expands BC1 to BC3 images to RGBA8, BC4 to R8 and BC5 to RG8, for render systems which cannot
+// sample those formats directly. All levels and layers are converted. Uncompressed images are returned as is.
+func Decompress(img *Image) (*Image, error) {
+	if !img.Format.Compressed() {
+		return img, nil
+	}
+
+	var format Format
+	var decode func(block []byte, out *[16][4]byte)
+	switch img.Format {
+	case FormatBC1:
+		format, decode = FormatRGBA8, decodeBC1
+	case FormatBC2:
+		format, decode = FormatRGBA8, decodeBC2
+	case FormatBC3:
+		format, decode = FormatRGBA8, decodeBC3
+	case FormatBC4:
+		format, decode = FormatR8, decodeBC4
+	case FormatBC5:
+		format, decode = FormatRG8, decodeBC5
+	default:
+		return nil, fmt.Errorf("cannot decompress %s images", img.Format)
+	}
+
+	out := &Image{
+		Width:   img.Width,
+		Height:  img.Height,
+		Format:  format,
+		Layers:  img.Layers,
+		Cubemap: img.Cubemap,
+		Levels:  make([][]byte, len(img.Levels)),
+	}
+
+	blockSize := img.Format.texelSize()
+	channels := format.texelSize()
+	var texels [16][4]byte
+	for level := range img.Levels {
+		w, h := img.LevelDimensions(level)
+		blocksX, blocksY := (w+3)/4, (h+3)/4
+		out.Levels[level] = make([]byte, format.LevelSize(w, h)*img.Layers)
+
+		for layer := 0; layer < img.Layers; layer++ {
+			src := img.Layer(level, layer)
+			dst := out.Layer(level, layer)
+			for by := 0; by < blocksY; by++ {
+				for bx := 0; bx < blocksX; bx++ {
+					block := (by*blocksX + bx) * blockSize
+					decode(src[block:block+blockSize], &texels)
+
+					// blocks on the edges of non multiple of 4 levels are clipped
+					for ty := 0; ty < 4 && by*4+ty < h; ty++ {
+						for tx := 0; tx < 4 && bx*4+tx < w; tx++ {
+							p := ((by*4+ty)*w + bx*4 + tx) * channels
+							copy(dst[p:p+channels], texels[ty*4+tx][:channels])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// rgb565 expands a 5:6:5 color to 8 bits per channel
+func rgb565(c uint16) [4]byte {
+	r, g, b := c>>11&0x1f, c>>5&0x3f, c&0x1f
+	return [4]byte{byte(r<<3 | r>>2), byte(g<<2 | g>>4), byte(b<<3 | b>>2), 255}
+}
+
+// decodeColorBlock decodes the 8 byte color part of BC1-3 blocks. Only BC1 supports the three color mode
+// with transparent black.
+func decodeColorBlock(block []byte, out *[16][4]byte, bc1 bool) {
+	c0, c1 := binary.LittleEndian.Uint16(block), binary.LittleEndian.Uint16(block[2:])
+	var palette [4][4]byte
+	palette[0], palette[1] = rgb565(c0), rgb565(c1)
+
+	for i := 0; i < 3; i++ {
+		a, b := int(palette[0][i]), int(palette[1][i])
+		if c0 > c1 || !bc1 {
+			palette[2][i] = byte((2*a + b) / 3)
+			palette[3][i] = byte((a + 2*b) / 3)
+		} else {
+			palette[2][i] = byte((a + b) / 2)
+		}
+	}
+	palette[2][3] = 255
+	if c0 > c1 || !bc1 {
+		palette[3][3] = 255
+	}
+
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := 0; i < 16; i++ {
+		out[i] = palette[indices>>uint(2*i)&0x3]
+	}
+}
+
+// decodeAlphaBlock decodes an 8 byte BC3/BC4 style interpolated channel into the given channel of out
+func decodeAlphaBlock(block []byte, out *[16][4]byte, channel int) {
+	var palette [8]byte
+	a0, a1 := int(block[0]), int(block[1])
+	palette[0], palette[1] = block[0], block[1]
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = byte(((7-i)*a0 + i*a1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = byte(((5-i)*a0 + i*a1) / 5)
+		}
+		palette[6], palette[7] = 0, 255
+	}
+
+	var indices uint64
+	for i := 0; i < 6; i++ {
+		indices |= uint64(block[2+i]) << uint(8*i)
+	}
+	for i := 0; i < 16; i++ {
+		out[i][channel] = palette[indices>>uint(3*i)&0x7]
+	}
+}
+
+func decodeBC1(block []byte, out *[16][4]byte) {
+	decodeColorBlock(block, out, true)
+}
+
+func decodeBC2(block []byte, out *[16][4]byte) {
+	decodeColorBlock(block[8:], out, false)
+	for i := 0; i < 16; i++ {
+		a := block[i/2] >> uint(4*(i%2)) & 0xf
+		out[i][3] = a<<4 | a
+	}
+}
+
+func decodeBC3(block []byte, out *[16][4]byte) {
+	decodeColorBlock(block[8:], out, false)
+	decodeAlphaBlock(block, out, 3)
+}
+
+func decodeBC4(block []byte, out *[16][4]byte) {
+	decodeAlphaBlock(block, out, 0)
+}
+
+func decodeBC5(block []byte, out *[16][4]byte) {
+	decodeAlphaBlock(block, out, 0)
+	decodeAlphaBlock(block[8:], out, 1)
+}
@@ -0,0 +1,45 @@
+package imagecodec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecompressBC1(t *testing.T) {
+	// red and blue endpoints, index 0 for the top row, 1 for the next, 2 and 3 for the rest
+	block := []byte{0x00, 0xf8, 0x1f, 0x00, 0x00, 0x55, 0xaa, 0xff}
+	img := &Image{Width: 4, Height: 4, Format: FormatBC1, Layers: 1, Levels: [][]byte{block}}
+
+	out, err := Decompress(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Format != FormatRGBA8 || len(out.Levels[0]) != 64 {
+		t.Fatalf("unexpected image %s with %d bytes", out.Format, len(out.Levels[0]))
+	}
+
+	rows := [][]byte{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}}
+	for y, want := range rows {
+		if got := out.Levels[0][y*16 : y*16+4]; !bytes.Equal(got, want) {
+			t.Errorf("row %d: got %v, expected %v", y, got, want)
+		}
+	}
+}
+
+func TestDecompressBC4Clipped(t *testing.T) {
+	// 2x2 level of a single block, only the top left texels survive
+	block := []byte{200, 100, 0, 0, 0, 0, 0, 0}
+	img := &Image{Width: 2, Height: 2, Format: FormatBC4, Layers: 1, Levels: [][]byte{block}}
+
+	out, err := Decompress(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Format != FormatR8 || !bytes.Equal(out.Levels[0], []byte{200, 200, 200, 200}) {
+		t.Errorf("unexpected image %s %v", out.Format, out.Levels[0])
+	}
+
+	if _, err := Decompress(&Image{Width: 4, Height: 4, Format: FormatBC7, Layers: 1, Levels: [][]byte{make([]byte, 16)}}); err == nil {
+		t.Error("expected error for bc7")
+	}
+}
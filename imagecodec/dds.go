@@ -0,0 +1,237 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+var ddsMagic = []byte("DDS ")
+
+// dds header flags
+const (
+	ddsPixelFormatAlphaPixels = 0x1
+	ddsPixelFormatFourCC      = 0x4
+	ddsPixelFormatRGB         = 0x40
+	ddsPixelFormatLuminance   = 0x20000
+
+	ddsCaps2Cubemap    = 0x200
+	ddsCaps2AllFaces   = 0xfc00
+	ddsCaps2Volume     = 0x200000
+	ddsDX10MiscCubemap = 0x4
+	ddsDX10Texture3D   = 4
+)
+
+// ddsFourCCFormats maps legacy four character codes and D3DFORMAT values to formats
+var ddsFourCCFormats = map[uint32]Format{
+	fourCC("DXT1"): FormatBC1,
+	fourCC("DXT2"): FormatBC2,
+	fourCC("DXT3"): FormatBC2,
+	fourCC("DXT4"): FormatBC3,
+	fourCC("DXT5"): FormatBC3,
+	fourCC("ATI1"): FormatBC4,
+	fourCC("BC4U"): FormatBC4,
+	fourCC("ATI2"): FormatBC5,
+	fourCC("BC5U"): FormatBC5,
+	111:            FormatR16F,
+	112:            FormatRG16F,
+	113:            FormatRGBA16F,
+	114:            FormatR32F,
+	115:            FormatRG32F,
+	116:            FormatRGBA32F,
+}
+
+// dxgiFormats maps DXGI_FORMAT values from the DX10 header to formats
+var dxgiFormats = map[uint32]Format{
+	2:  FormatRGBA32F,
+	6:  FormatRGB32F,
+	10: FormatRGBA16F,
+	16: FormatRG32F,
+	28: FormatRGBA8,
+	29: FormatRGBA8,
+	34: FormatRG16F,
+	41: FormatR32F,
+	49: FormatRG8,
+	54: FormatR16F,
+	61: FormatR8,
+	71: FormatBC1,
+	72: FormatBC1,
+	74: FormatBC2,
+	75: FormatBC2,
+	77: FormatBC3,
+	78: FormatBC3,
+	80: FormatBC4,
+	83: FormatBC5,
+	95: FormatBC6H,
+	98: FormatBC7,
+	99: FormatBC7,
+}
+
+// dxgiFormatB8G8R8A8 is converted to RGBA8 on load
+const dxgiFormatB8G8R8A8 = 87
+
+func fourCC(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+// DecodeDDS decodes a DirectDraw Surface file. Mip chains, cubemaps and texture arrays are kept, block
+// compressed and float payloads are passed through untouched. Uncompressed RGB(A) and luminance surfaces
+// described by bit masks are converted to RGBA8. Volume textures are not supported.
+func DecodeDDS(data []byte) (*Image, error) {
+	if len(data) < 128 || string(data[:4]) != string(ddsMagic) {
+		return nil, errors.New("not a dds file")
+	}
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+
+	if u32(4) != 124 {
+		return nil, errors.New("invalid dds header size")
+	}
+
+	img := &Image{
+		Width:  int(u32(16)),
+		Height: int(u32(12)),
+		Layers: 1,
+	}
+
+	levels := int(u32(28))
+	if levels == 0 {
+		levels = 1
+	}
+	if err := checkHeader(img.Width, img.Height, levels); err != nil {
+		return nil, err
+	}
+
+	pfFlags, pfFourCC := u32(80), u32(84)
+	caps2 := u32(112)
+	if caps2&ddsCaps2Volume != 0 {
+		return nil, errors.New("dds volume textures are not supported")
+	}
+
+	offset := 128
+	var convert func([]byte) []byte
+	switch {
+	case pfFlags&ddsPixelFormatFourCC != 0 && pfFourCC == fourCC("DX10"):
+		if len(data) < 148 {
+			return nil, errors.New("truncated dds dx10 header")
+		}
+		dxgiFormat, dimension, misc, arraySize := u32(128), u32(132), u32(136), u32(140)
+		offset = 148
+
+		if dimension == ddsDX10Texture3D {
+			return nil, errors.New("dds volume textures are not supported")
+		}
+		if arraySize > 1 {
+			img.Layers = int(arraySize)
+		}
+		if misc&ddsDX10MiscCubemap != 0 {
+			img.Cubemap = true
+			img.Layers *= 6
+		}
+
+		if format, ok := dxgiFormats[dxgiFormat]; ok {
+			img.Format = format
+		} else if dxgiFormat == dxgiFormatB8G8R8A8 {
+			img.Format = FormatRGBA8
+			convert = func(b []byte) []byte { return maskedToRGBA(b, 32, 0xff0000, 0xff00, 0xff, 0xff000000) }
+		} else {
+			return nil, fmt.Errorf("unsupported dxgi format %d", dxgiFormat)
+		}
+	case pfFlags&ddsPixelFormatFourCC != 0:
+		format, ok := ddsFourCCFormats[pfFourCC]
+		if !ok {
+			return nil, fmt.Errorf("unsupported dds four cc 0x%08x", pfFourCC)
+		}
+		img.Format = format
+	case pfFlags&(ddsPixelFormatRGB|ddsPixelFormatLuminance) != 0:
+		bitCount := int(u32(88))
+		if bitCount != 8 && bitCount != 16 && bitCount != 24 && bitCount != 32 {
+			return nil, fmt.Errorf("unsupported dds bit count %d", bitCount)
+		}
+		r, g, b, a := u32(92), u32(96), u32(100), u32(104)
+		if pfFlags&ddsPixelFormatAlphaPixels == 0 {
+			a = 0
+		}
+		if pfFlags&ddsPixelFormatLuminance != 0 {
+			g, b = r, r
+		}
+		img.Format = FormatRGBA8
+		convert = func(p []byte) []byte { return maskedToRGBA(p, bitCount, r, g, b, a) }
+	default:
+		return nil, errors.New("unsupported dds pixel format")
+	}
+
+	if !img.Cubemap && caps2&ddsCaps2Cubemap != 0 {
+		if caps2&ddsCaps2AllFaces != ddsCaps2AllFaces {
+			return nil, errors.New("partial dds cubemaps are not supported")
+		}
+		img.Cubemap = true
+		img.Layers = 6
+	}
+
+	// source texel size for masked formats differs from the converted one
+	sourceSize := func(w, h int) int {
+		if pfFlags&ddsPixelFormatFourCC == 0 {
+			return w * h * int(u32(88)) / 8
+		}
+		return img.Format.LevelSize(w, h)
+	}
+
+	// file order is every level of a layer, then the next layer
+	img.Levels = make([][]byte, levels)
+	for layer := 0; layer < img.Layers; layer++ {
+		for level := 0; level < levels; level++ {
+			w, h := img.LevelDimensions(level)
+			size := sourceSize(w, h)
+			if offset+size > len(data) {
+				return nil, errors.New("truncated dds data")
+			}
+
+			texels := data[offset : offset+size]
+			if convert != nil {
+				texels = convert(texels)
+			}
+			img.Levels[level] = append(img.Levels[level], texels...)
+			offset += size
+		}
+	}
+
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// maskedToRGBA converts little endian pixels whose channels are described by bit masks to RGBA8. A zero alpha
+// mask produces opaque pixels.
+func maskedToRGBA(src []byte, bitCount int, r, g, b, a uint32) []byte {
+	pixelSize := bitCount / 8
+	count := len(src) / pixelSize
+	dst := make([]byte, count*4)
+
+	channel := func(v, mask uint32) byte {
+		if mask == 0 {
+			return 0
+		}
+		shift := uint(bits.TrailingZeros32(mask))
+		c, max := uint64((v&mask)>>shift), uint64(mask>>shift)
+		return byte((c*255 + max/2) / max)
+	}
+
+	for i := 0; i < count; i++ {
+		var v uint32
+		for j := 0; j < pixelSize; j++ {
+			v |= uint32(src[i*pixelSize+j]) << uint(8*j)
+		}
+
+		dst[i*4+0] = channel(v, r)
+		dst[i*4+1] = channel(v, g)
+		dst[i*4+2] = channel(v, b)
+		dst[i*4+3] = 255
+		if a != 0 {
+			dst[i*4+3] = channel(v, a)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,106 @@
+package imagecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ddsFile builds a dds file with the given pixel format and optional dx10 header
+func ddsFile(width, height, levels int, pfFlags, fourcc, bitCount uint32, masks [4]uint32, caps2 uint32, dx10 []uint32, payload []byte) []byte {
+	header := make([]byte, 128)
+	copy(header, ddsMagic)
+	u32 := func(offset int, v uint32) { binary.LittleEndian.PutUint32(header[offset:], v) }
+	u32(4, 124)
+	u32(12, uint32(height))
+	u32(16, uint32(width))
+	u32(28, uint32(levels))
+	u32(76, 32)
+	u32(80, pfFlags)
+	u32(84, fourcc)
+	u32(88, bitCount)
+	for i, m := range masks {
+		u32(92+i*4, m)
+	}
+	u32(112, caps2)
+
+	for _, v := range dx10 {
+		header = append(header, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(header[len(header)-4:], v)
+	}
+	return append(header, payload...)
+}
+
+func TestDecodeDDSCompressedMips(t *testing.T) {
+	// 8x8 dxt1 with 4 levels: 4 blocks, then 1 block for each of 4x4, 2x2 and 1x1
+	payload := make([]byte, 7*8)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	data := ddsFile(8, 8, 4, ddsPixelFormatFourCC, fourCC("DXT1"), 0, [4]uint32{}, 0, nil, payload)
+
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != FormatBC1 || len(img.Levels) != 4 || img.Layers != 1 {
+		t.Fatalf("unexpected image %s with %d levels and %d layers", img.Format, len(img.Levels), img.Layers)
+	}
+	if !bytes.Equal(img.Levels[0], payload[:32]) || !bytes.Equal(img.Levels[3], payload[48:]) {
+		t.Error("level data does not match payload")
+	}
+
+	if _, err := DecodeDDS(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestDecodeDDSCubemap(t *testing.T) {
+	// dx10 rgba8 cubemap, one 1x1 level per face, faces stored one after the other
+	var payload []byte
+	for face := 0; face < 6; face++ {
+		payload = append(payload, byte(face), 0, 0, 255)
+	}
+	dx10 := []uint32{28, 3, ddsDX10MiscCubemap, 1, 0}
+	data := ddsFile(1, 1, 1, ddsPixelFormatFourCC, fourCC("DX10"), 0, [4]uint32{}, 0, dx10, payload)
+
+	img, err := DecodeDDS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !img.Cubemap || img.Layers != 6 || img.Format != FormatRGBA8 {
+		t.Fatalf("unexpected image %s, cubemap %v with %d layers", img.Format, img.Cubemap, img.Layers)
+	}
+	for face := 0; face < 6; face++ {
+		if img.Layer(0, face)[0] != byte(face) {
+			t.Errorf("face %d has wrong texels %v", face, img.Layer(0, face))
+		}
+	}
+}
+
+func TestDecodeDDSMasked(t *testing.T) {
+	// legacy A8R8G8B8, stored as BGRA bytes
+	masks := [4]uint32{0xff0000, 0xff00, 0xff, 0xff000000}
+	data := ddsFile(2, 1, 1, ddsPixelFormatRGB|ddsPixelFormatAlphaPixels, 0, 32, masks, 0, nil,
+		[]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	img, err := DecodeDDS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{3, 2, 1, 4, 7, 6, 5, 8}
+	if !bytes.Equal(img.Levels[0], want) {
+		t.Errorf("got %v, expected %v", img.Levels[0], want)
+	}
+}
+
+func TestDecodeDDSMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"oversized":       ddsFile(1<<20, 1<<20, 1, ddsPixelFormatFourCC, fourCC("DXT1"), 0, [4]uint32{}, 0, nil, make([]byte, 8)),
+		"too many levels": ddsFile(4, 4, 1<<30, ddsPixelFormatFourCC, fourCC("DXT1"), 0, [4]uint32{}, 0, nil, make([]byte, 8)),
+	} {
+		if _, err := DecodeDDS(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
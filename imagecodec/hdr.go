@@ -0,0 +1,180 @@
+package imagecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DecodeHDR decodes a Radiance RGBE (.hdr) file into RGB32F texels. Both flat and run length encoded
+// scanlines are supported. Only the standard -Y +X orientation is accepted.
+func DecodeHDR(data []byte) (*Image, error) {
+	r := &hdrReader{data: data}
+
+	magic := r.line()
+	if magic != "#?RADIANCE" && magic != "#?RGBE" {
+		return nil, errors.New("not a radiance hdr file")
+	}
+
+	// header lines until an empty one
+	for {
+		if r.pos >= len(data) {
+			return nil, errors.New("truncated hdr header")
+		}
+		line := r.line()
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported hdr format %s", line[7:])
+		}
+	}
+
+	var width, height int
+	if _, err := fmt.Sscanf(r.line(), "-Y %d +X %d", &height, &width); err != nil {
+		return nil, fmt.Errorf("unsupported hdr resolution line: %v", err)
+	}
+	if err := checkHeader(width, height, 1); err != nil {
+		return nil, err
+	}
+
+	// every scanline takes at least one pixel or run length header
+	if (len(data)-r.pos)/4 < height {
+		return nil, errors.New("truncated hdr pixel data")
+	}
+
+	texels := make([]byte, width*height*12)
+	scanline := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		if err := r.scanline(scanline); err != nil {
+			return nil, fmt.Errorf("scanline %d: %v", y, err)
+		}
+
+		out := texels[y*width*12:]
+		for x := 0; x < width; x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			var scale float64
+			if rgbe[3] != 0 {
+				scale = math.Ldexp(1.0, int(rgbe[3])-(128+8))
+			}
+			for c := 0; c < 3; c++ {
+				v := float32(float64(rgbe[c]) * scale)
+				binary.LittleEndian.PutUint32(out[x*12+c*4:], math.Float32bits(v))
+			}
+		}
+	}
+
+	img := &Image{Width: width, Height: height, Format: FormatRGB32F, Layers: 1, Levels: [][]byte{texels}}
+	return img, nil
+}
+
+type hdrReader struct {
+	data []byte
+	pos  int
+}
+
+// line returns the next newline terminated line without the terminator
+func (r *hdrReader) line() string {
+	end := bytes.IndexByte(r.data[r.pos:], '\n')
+	if end < 0 {
+		end = len(r.data) - r.pos
+	}
+	line := string(r.data[r.pos : r.pos+end])
+	r.pos += end + 1
+	if r.pos > len(r.data) {
+		r.pos = len(r.data)
+	}
+	return strings.TrimRight(line, "\r")
+}
+
+// scanline reads one scanline of RGBE pixels into dst
+func (r *hdrReader) scanline(dst []byte) error {
+	width := len(dst) / 4
+	if r.pos+4 > len(r.data) {
+		return errors.New("truncated pixel data")
+	}
+
+	// new style run length encoding: 2, 2, width high byte, width low byte, then each channel separately
+	header := r.data[r.pos : r.pos+4]
+	if width < 8 || width > 0x7fff || header[0] != 2 || header[1] != 2 || header[2]&0x80 != 0 {
+		return r.flatScanline(dst)
+	}
+	if int(header[2])<<8|int(header[3]) != width {
+		return errors.New("scanline width mismatch")
+	}
+	r.pos += 4
+
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			if r.pos >= len(r.data) {
+				return errors.New("truncated run length data")
+			}
+			count := int(r.data[r.pos])
+			r.pos++
+
+			if count > 128 {
+				// run
+				count -= 128
+				if count > width-x || r.pos >= len(r.data) {
+					return errors.New("bad run length")
+				}
+				v := r.data[r.pos]
+				r.pos++
+				for ; count > 0; count-- {
+					dst[x*4+c] = v
+					x++
+				}
+			} else {
+				// literal
+				if count == 0 || count > width-x || r.pos+count > len(r.data) {
+					return errors.New("bad literal length")
+				}
+				for i := 0; i < count; i++ {
+					dst[x*4+c] = r.data[r.pos+i]
+					x++
+				}
+				r.pos += count
+			}
+		}
+	}
+
+	return nil
+}
+
+// flatScanline reads uncompressed pixels, handling old style runs where a 1, 1, 1, n pixel repeats the
+// previous one
+func (r *hdrReader) flatScanline(dst []byte) error {
+	width := len(dst) / 4
+	shift := uint(0)
+	for x := 0; x < width; {
+		if r.pos+4 > len(r.data) {
+			return errors.New("truncated pixel data")
+		}
+		p := r.data[r.pos : r.pos+4]
+		r.pos += 4
+
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			if x == 0 {
+				return errors.New("run without previous pixel")
+			}
+			count := int(p[3]) << shift
+			if count > width-x {
+				return errors.New("bad run length")
+			}
+			for ; count > 0; count-- {
+				copy(dst[x*4:x*4+4], dst[(x-1)*4:x*4])
+				x++
+			}
+			shift += 8
+			continue
+		}
+
+		copy(dst[x*4:x*4+4], p)
+		shift = 0
+		x++
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func hdrFile(width, height int, scanlines []byte) []byte {
+	header := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y " + itoa(height) + " +X " + itoa(width) + "\n"
+	return append([]byte(header), scanlines...)
+}
+
+func itoa(i int) string {
+	if i < 10 {
+		return string(rune('0' + i))
+	}
+	return itoa(i/10) + string(rune('0'+i%10))
+}
+
+func rgbAt(img *Image, x, y int) [3]float32 {
+	var rgb [3]float32
+	for c := range rgb {
+		rgb[c] = math.Float32frombits(binary.LittleEndian.Uint32(img.Levels[0][(y*img.Width+x)*12+c*4:]))
+	}
+	return rgb
+}
+
+func TestDecodeHDRFlat(t *testing.T) {
+	// exponent 129 scales mantissas by 2^(129-136) = 1/128
+	data := hdrFile(2, 1, []byte{128, 64, 0, 129, 0, 0, 0, 0})
+
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Width != 2 || img.Height != 1 || img.Format != FormatRGB32F {
+		t.Fatalf("unexpected image %dx%d %s", img.Width, img.Height, img.Format)
+	}
+	if rgb := rgbAt(img, 0, 0); rgb != [3]float32{1.0, 0.5, 0} {
+		t.Errorf("unexpected first pixel %v", rgb)
+	}
+	if rgb := rgbAt(img, 1, 0); rgb != [3]float32{0, 0, 0} {
+		t.Errorf("unexpected second pixel %v", rgb)
+	}
+}
+
+func TestDecodeHDRRunLength(t *testing.T) {
+	// 8 pixels wide, every channel is a run except blue which is literal
+	scanline := []byte{2, 2, 0, 8}
+	scanline = append(scanline, 128+8, 128)
+	scanline = append(scanline, 128+8, 64)
+	scanline = append(scanline, 8, 0, 128, 0, 128, 0, 128, 0, 128)
+	scanline = append(scanline, 128+8, 129)
+
+	img, err := DecodeHDR(hdrFile(8, 1, scanline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 8; x++ {
+		want := [3]float32{1.0, 0.5, float32(x%2) * 1.0}
+		if rgb := rgbAt(img, x, 0); rgb != want {
+			t.Errorf("pixel %d: got %v, expected %v", x, rgb, want)
+		}
+	}
+
+	if _, err := DecodeHDR(hdrFile(8, 2, scanline)); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestDecodeHDRMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"oversized":           []byte("#?RADIANCE\n\n-Y 2 +X 2000000000"),
+		"overflowing":         []byte("#?RADIANCE\n\n-Y 2000000000 +X 2000000000\n"),
+		"more rows than data": hdrFile(1, 1000, []byte{128, 64, 0, 129}),
+		"missing resolution":  []byte("#?RADIANCE\n\n"),
+		"negative":            []byte("#?RADIANCE\n\n-Y -1 +X 1\n"),
+	} {
+		if _, err := DecodeHDR(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package imagecodec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // registers jpeg handler
+	_ "image/png"  // registers png handler
+)
+
+// Format describes the layout of decoded texel data.
+type Format int
+
+// Supported texel formats. Float formats hold little endian IEEE 754 values, 16F formats are half floats.
+const (
+	FormatR8 Format = iota
+	FormatRG8
+	FormatRGB8
+	FormatRGBA8
+	FormatR16F
+	FormatRG16F
+	FormatRGBA16F
+	FormatR32F
+	FormatRG32F
+	FormatRGB32F
+	FormatRGBA32F
+
+	// block compressed formats, 4x4 texel blocks
+	FormatBC1
+	FormatBC2
+	FormatBC3
+	FormatBC4
+	FormatBC5
+	FormatBC6H
+	FormatBC7
+)
+
+var formatNames = map[Format]string{
+	FormatR8:      "R8",
+	FormatRG8:     "RG8",
+	FormatRGB8:    "RGB8",
+	FormatRGBA8:   "RGBA8",
+	FormatR16F:    "R16F",
+	FormatRG16F:   "RG16F",
+	FormatRGBA16F: "RGBA16F",
+	FormatR32F:    "R32F",
+	FormatRG32F:   "RG32F",
+	FormatRGB32F:  "RGB32F",
+	FormatRGBA32F: "RGBA32F",
+	FormatBC1:     "BC1",
+	FormatBC2:     "BC2",
+	FormatBC3:     "BC3",
+	FormatBC4:     "BC4",
+	FormatBC5:     "BC5",
+	FormatBC6H:    "BC6H",
+	FormatBC7:     "BC7",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Compressed returns whether the format is block compressed.
+func (f Format) Compressed() bool {
+	return f >= FormatBC1
+}
+
+// texelSize returns the size in bytes of a texel for uncompressed formats, or of a 4x4 block for
+// compressed formats.
+func (f Format) texelSize() int {
+	switch f {
+	case FormatR8:
+		return 1
+	case FormatRG8, FormatR16F:
+		return 2
+	case FormatRGB8:
+		return 3
+	case FormatRGBA8, FormatRG16F, FormatR32F:
+		return 4
+	case FormatRGBA16F, FormatRG32F:
+		return 8
+	case FormatRGB32F:
+		return 12
+	case FormatRGBA32F:
+		return 16
+	case FormatBC1, FormatBC4:
+		return 8
+	case FormatBC2, FormatBC3, FormatBC5, FormatBC6H, FormatBC7:
+		return 16
+	}
+	return 0
+}
+
+// LevelSize returns the size in bytes of a single layer of a width x height level in this format.
+func (f Format) LevelSize(width, height int) int {
+	if f.Compressed() {
+		return ((width + 3) / 4) * ((height + 3) / 4) * f.texelSize()
+	}
+	return width * height * f.texelSize()
+}
+
+// Image is a decoded image. Cubemaps have six layers in +X, -X, +Y, -Y, +Z, -Z order.
+type Image struct {
+	Width   int
+	Height  int
+	Format  Format
+	Layers  int
+	Cubemap bool
+
+	// Levels holds one entry per mip level, starting at the full size image. Each entry has the texels
+	// of every layer for that level, one layer after the other.
+	Levels [][]byte
+}
+
+// LevelDimensions returns the width and height of a mip level.
+func (img *Image) LevelDimensions(level int) (int, int) {
+	w, h := img.Width>>uint(level), img.Height>>uint(level)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Layer returns the texels of a single layer at a mip level.
+func (img *Image) Layer(level, layer int) []byte {
+	w, h := img.LevelDimensions(level)
+	size := img.Format.LevelSize(w, h)
+	return img.Levels[level][layer*size : (layer+1)*size]
+}
+
+// maxDimension is the largest width or height accepted from file headers, larger ones are treated as corrupt
+const maxDimension = 1 << 16
+
+// maxLevels is the number of levels of a full mip chain of maxDimension
+const maxLevels = 17
+
+// checkHeader checks dimensions and level counts read from file headers before anything is allocated for them
+func checkHeader(width, height, levels int) error {
+	if width <= 0 || height <= 0 || width > maxDimension || height > maxDimension {
+		return fmt.Errorf("invalid image dimensions %dx%d", width, height)
+	}
+	if levels > maxLevels {
+		return fmt.Errorf("invalid level count %d", levels)
+	}
+	return nil
+}
+
+// validate checks level sizes against dimensions and format
+func (img *Image) validate() error {
+	if img.Width <= 0 || img.Height <= 0 || img.Layers <= 0 {
+		return fmt.Errorf("invalid image dimensions %dx%d with %d layers", img.Width, img.Height, img.Layers)
+	}
+	if len(img.Levels) == 0 {
+		return errors.New("image has no levels")
+	}
+	for level := range img.Levels {
+		w, h := img.LevelDimensions(level)
+		if want := img.Format.LevelSize(w, h) * img.Layers; len(img.Levels[level]) != want {
+			return fmt.Errorf("level %d has %d bytes, expected %d", level, len(img.Levels[level]), want)
+		}
+	}
+	return nil
+}
+
+// Decode detects the format of an image file and decodes it. DDS, KTX and Radiance HDR files are recognized
+// by their signatures, then any format registered with the image package is tried and TGA, which has no
+// signature, is tried last. Formats decoded with the image package are converted to RGBA8.
+func Decode(data []byte) (*Image, error) {
+	switch {
+	case len(data) == 0:
+		return nil, errors.New("empty image data")
+	case bytes.HasPrefix(data, ddsMagic):
+		return DecodeDDS(data)
+	case bytes.HasPrefix(data, ktxIdentifier):
+		return DecodeKTX(data)
+	case bytes.HasPrefix(data, []byte("#?RADIANCE")) || bytes.HasPrefix(data, []byte("#?RGBE")):
+		return DecodeHDR(data)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err == nil {
+		return fromImage(img), nil
+	}
+
+	if tga, tgaErr := DecodeTGA(data); tgaErr == nil {
+		return tga, nil
+	}
+
+	return nil, err
+}
+
+// fromImage converts a standard library image to an RGBA8 Image
+func fromImage(img image.Image) *Image {
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != rgba.Rect.Size().X*4 || rgba.Rect.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+
+	return &Image{
+		Width:  rgba.Rect.Dx(),
+		Height: rgba.Rect.Dy(),
+		Format: FormatRGBA8,
+		Layers: 1,
+		Levels: [][]byte{rgba.Pix},
+	}
+}
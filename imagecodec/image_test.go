@@ -0,0 +1,23 @@
+package imagecodec
+
+import "testing"
+
+// FuzzDecode checks that corrupt files fail to decode instead of panicking or allocating beyond their contents
+func FuzzDecode(f *testing.F) {
+	f.Add(append(tgaHeader(tgaRLETrueColor, 3, 1, 32, 0x28), 0x81, 1, 2, 3, 4, 0x00, 5, 6, 7, 8))
+	f.Add(append(tgaHeader(tgaGrayscale, 2, 1, 8, 0x20), 10, 20))
+	f.Add(hdrFile(2, 1, []byte{128, 64, 0, 129, 0, 0, 0, 0}))
+	f.Add(hdrFile(8, 1, []byte{2, 2, 0, 8, 128 + 8, 128, 128 + 8, 64, 128 + 8, 0, 128 + 8, 129}))
+	f.Add(ddsFile(4, 4, 1, ddsPixelFormatFourCC, fourCC("DXT1"), 0, [4]uint32{}, 0, nil, make([]byte, 8)))
+	f.Add(ktxFile(0x8058, 1, 1, 0, 1, 1, nil, ktxLevel(4, []byte{1, 2, 3, 4})))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		img, err := Decode(data)
+		if err != nil {
+			return
+		}
+		if err := img.validate(); err != nil {
+			t.Errorf("decoded an invalid image: %v", err)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ktxIdentifier = []byte{0xab, 'K', 'T', 'X', ' ', '1', '1', 0xbb, '\r', '\n', 0x1a, '\n'}
+
+// ktxFormats maps OpenGL internal formats to formats
+var ktxFormats = map[uint32]Format{
+	0x8229: FormatR8,      // GL_R8
+	0x822b: FormatRG8,     // GL_RG8
+	0x8051: FormatRGB8,    // GL_RGB8
+	0x8058: FormatRGBA8,   // GL_RGBA8
+	0x822d: FormatR16F,    // GL_R16F
+	0x822f: FormatRG16F,   // GL_RG16F
+	0x881a: FormatRGBA16F, // GL_RGBA16F
+	0x822e: FormatR32F,    // GL_R32F
+	0x8230: FormatRG32F,   // GL_RG32F
+	0x8815: FormatRGB32F,  // GL_RGB32F
+	0x8814: FormatRGBA32F, // GL_RGBA32F
+	0x83f0: FormatBC1,     // GL_COMPRESSED_RGB_S3TC_DXT1_EXT
+	0x83f1: FormatBC1,     // GL_COMPRESSED_RGBA_S3TC_DXT1_EXT
+	0x83f2: FormatBC2,     // GL_COMPRESSED_RGBA_S3TC_DXT3_EXT
+	0x83f3: FormatBC3,     // GL_COMPRESSED_RGBA_S3TC_DXT5_EXT
+	0x8dbb: FormatBC4,     // GL_COMPRESSED_RED_RGTC1
+	0x8dbd: FormatBC5,     // GL_COMPRESSED_RG_RGTC2
+	0x8e8f: FormatBC6H,    // GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT
+	0x8e8c: FormatBC7,     // GL_COMPRESSED_RGBA_BPTC_UNORM
+}
+
+// DecodeKTX decodes a KTX 1.1 file. Mip chains, cubemaps and texture arrays are kept, block compressed and float
+// payloads are passed through untouched. Texels are in the order OpenGL expects, KTX does not flip rows.
+// Volume textures are not supported.
+func DecodeKTX(data []byte) (*Image, error) {
+	if len(data) < 64 || string(data[:12]) != string(ktxIdentifier) {
+		return nil, errors.New("not a ktx file")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	switch binary.LittleEndian.Uint32(data[12:]) {
+	case 0x04030201:
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid ktx endianness")
+	}
+	u32 := func(offset int) uint32 { return order.Uint32(data[offset:]) }
+
+	glTypeSize := int(u32(20))
+	internalFormat := u32(28)
+	width, height, depth := int(u32(36)), int(u32(40)), int(u32(44))
+	arrayElements, faces, levels := int(u32(48)), int(u32(52)), int(u32(56))
+	keyValueBytes := int(u32(60))
+
+	format, ok := ktxFormats[internalFormat]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ktx internal format 0x%04x", internalFormat)
+	}
+	if depth > 1 {
+		return nil, errors.New("ktx volume textures are not supported")
+	}
+	if height == 0 {
+		return nil, errors.New("ktx 1D textures are not supported")
+	}
+	if faces != 1 && faces != 6 {
+		return nil, fmt.Errorf("invalid ktx face count %d", faces)
+	}
+	if levels == 0 {
+		levels = 1
+	}
+	if err := checkHeader(width, height, levels); err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		Width:   width,
+		Height:  height,
+		Format:  format,
+		Layers:  faces,
+		Cubemap: faces == 6,
+		Levels:  make([][]byte, levels),
+	}
+	if arrayElements > 0 {
+		img.Layers *= arrayElements
+	}
+
+	offset := 64 + keyValueBytes
+	for level := 0; level < levels; level++ {
+		if offset+4 > len(data) {
+			return nil, errors.New("truncated ktx data")
+		}
+		offset += 4 // imageSize, recomputed from dimensions
+
+		w, h := img.LevelDimensions(level)
+		size := format.LevelSize(w, h)
+		for layer := 0; layer < img.Layers; layer++ {
+			if offset+size > len(data) {
+				return nil, errors.New("truncated ktx data")
+			}
+			texels := data[offset : offset+size]
+			if order == binary.BigEndian && glTypeSize > 1 {
+				texels = swapBytes(texels, glTypeSize)
+			}
+			img.Levels[level] = append(img.Levels[level], texels...)
+
+			// non array cubemap faces are padded individually
+			offset += size
+			if img.Cubemap && arrayElements == 0 {
+				offset += pad4(size)
+			}
+		}
+		offset += pad4(offset)
+	}
+
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// pad4 returns the padding needed to align n to 4 bytes
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
+
+// swapBytes converts elements of the given size to little endian
+func swapBytes(src []byte, size int) []byte {
+	dst := make([]byte, len(src))
+	for i := 0; i+size <= len(src); i += size {
+		for j := 0; j < size; j++ {
+			dst[i+j] = src[i+size-1-j]
+		}
+	}
+	return dst
+}
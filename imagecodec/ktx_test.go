@@ -0,0 +1,82 @@
+package imagecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func ktxFile(internalFormat uint32, width, height, arrayElements, faces, levels int, keyValues, body []byte) []byte {
+	data := append([]byte{}, ktxIdentifier...)
+	for _, v := range []uint32{0x04030201, 0, 1, 0, internalFormat, 0, uint32(width), uint32(height), 0,
+		uint32(arrayElements), uint32(faces), uint32(levels), uint32(len(keyValues))} {
+		data = append(data, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[len(data)-4:], v)
+	}
+	data = append(data, keyValues...)
+	return append(data, body...)
+}
+
+func ktxLevel(size int, faces ...[]byte) []byte {
+	level := []byte{byte(size), 0, 0, 0}
+	for _, f := range faces {
+		level = append(level, f...)
+	}
+	return level
+}
+
+func TestDecodeKTXCubemap(t *testing.T) {
+	// 2x2 rgba8 cubemap with two levels
+	var body []byte
+	var faces [][]byte
+	for face := 0; face < 6; face++ {
+		faces = append(faces, bytes.Repeat([]byte{byte(face)}, 16))
+	}
+	body = append(body, ktxLevel(16, faces...)...)
+	faces = faces[:0]
+	for face := 0; face < 6; face++ {
+		faces = append(faces, []byte{byte(10 + face), 0, 0, 0})
+	}
+	body = append(body, ktxLevel(4, faces...)...)
+
+	img, err := Decode(ktxFile(0x8058, 2, 2, 0, 6, 2, make([]byte, 8), body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !img.Cubemap || img.Layers != 6 || len(img.Levels) != 2 || img.Format != FormatRGBA8 {
+		t.Fatalf("unexpected image %s, cubemap %v with %d layers and %d levels", img.Format, img.Cubemap, img.Layers, len(img.Levels))
+	}
+	for face := 0; face < 6; face++ {
+		if img.Layer(0, face)[0] != byte(face) || img.Layer(1, face)[0] != byte(10+face) {
+			t.Errorf("face %d has wrong texels", face)
+		}
+	}
+}
+
+func TestDecodeKTXPadding(t *testing.T) {
+	// 1x1 r8 with a single level, rows are padded to 4 bytes and the next level follows the padding
+	body := ktxLevel(1, []byte{42, 0, 0, 0})
+	img, err := DecodeKTX(ktxFile(0x8229, 1, 1, 0, 1, 1, nil, body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != FormatR8 || !bytes.Equal(img.Levels[0], []byte{42}) {
+		t.Errorf("unexpected image %s %v", img.Format, img.Levels[0])
+	}
+
+	if _, err := DecodeKTX(ktxFile(0x1234, 1, 1, 0, 1, 1, nil, body)); err == nil {
+		t.Error("expected error for unsupported internal format")
+	}
+}
+
+func TestDecodeKTXMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"oversized":        ktxFile(0x8058, 1<<20, 1<<20, 0, 1, 1, nil, ktxLevel(4, []byte{1, 2, 3, 4})),
+		"too many levels":  ktxFile(0x8058, 1, 1, 0, 1, 1<<30, nil, ktxLevel(4, []byte{1, 2, 3, 4})),
+		"missing key data": ktxFile(0x8058, 1, 1, 0, 1, 1, make([]byte, 4), nil),
+	} {
+		if _, err := DecodeKTX(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package imagecodec decodes image files into texel data ready for GPU upload. Besides the formats registered
+// with the standard image package it reads Radiance HDR, TGA, DDS and KTX files, keeping float texels, mip chains,
+// cube faces and block compressed payloads intact. It has no dependencies outside the standard library and does
+// not know about any render system.
+package imagecodec
@@ -0,0 +1,179 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// tga image types
+const (
+	tgaColorMapped    = 1
+	tgaTrueColor      = 2
+	tgaGrayscale      = 3
+	tgaRLEColorMapped = 9
+	tgaRLETrueColor   = 10
+	tgaRLEGrayscale   = 11
+)
+
+// DecodeTGA decodes a Truevision TGA file into RGBA8 texels, top row first. Uncompressed and run length
+// encoded true color, grayscale and color mapped images with 8, 15, 16, 24 or 32 bits per pixel are supported.
+func DecodeTGA(data []byte) (*Image, error) {
+	if len(data) < 18 {
+		return nil, errors.New("truncated tga header")
+	}
+
+	idLength := int(data[0])
+	colorMapType := data[1]
+	imageType := data[2]
+	mapFirst := int(binary.LittleEndian.Uint16(data[3:]))
+	mapLength := int(binary.LittleEndian.Uint16(data[5:]))
+	mapEntryBits := int(data[7])
+	width := int(binary.LittleEndian.Uint16(data[12:]))
+	height := int(binary.LittleEndian.Uint16(data[14:]))
+	bits := int(data[16])
+	descriptor := data[17]
+
+	switch imageType {
+	case tgaColorMapped, tgaRLEColorMapped:
+		if colorMapType != 1 || (bits != 8 && bits != 16) {
+			return nil, errors.New("invalid tga color map")
+		}
+	case tgaTrueColor, tgaRLETrueColor:
+		if colorMapType > 1 || (bits != 15 && bits != 16 && bits != 24 && bits != 32) {
+			return nil, fmt.Errorf("unsupported tga true color depth %d", bits)
+		}
+	case tgaGrayscale, tgaRLEGrayscale:
+		if colorMapType > 1 || (bits != 8 && bits != 16) {
+			return nil, fmt.Errorf("unsupported tga grayscale depth %d", bits)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tga image type %d", imageType)
+	}
+	if width == 0 || height == 0 {
+		return nil, errors.New("invalid tga dimensions")
+	}
+
+	pos := 18 + idLength
+
+	// color map, converted to rgba up front
+	var palette [][4]byte
+	if colorMapType == 1 {
+		if mapEntryBits != 15 && mapEntryBits != 16 && mapEntryBits != 24 && mapEntryBits != 32 {
+			return nil, fmt.Errorf("unsupported tga color map entry size %d", mapEntryBits)
+		}
+		entrySize := (mapEntryBits + 7) / 8
+		if pos+mapLength*entrySize > len(data) {
+			return nil, errors.New("truncated tga color map")
+		}
+		palette = make([][4]byte, mapFirst+mapLength)
+		for i := 0; i < mapLength; i++ {
+			palette[mapFirst+i] = tgaColor(data[pos+i*entrySize:], mapEntryBits, false, false)
+		}
+		pos += mapLength * entrySize
+	}
+
+	pixelSize := (bits + 7) / 8
+	grayscale := imageType == tgaGrayscale || imageType == tgaRLEGrayscale
+	alpha := descriptor&0x0f != 0
+	convert := func(p []byte) ([4]byte, error) {
+		switch {
+		case palette != nil && imageType != tgaTrueColor && imageType != tgaRLETrueColor:
+			index := int(p[0])
+			if pixelSize == 2 {
+				index = int(binary.LittleEndian.Uint16(p))
+			}
+			if index >= len(palette) {
+				return [4]byte{}, errors.New("tga color index out of range")
+			}
+			return palette[index], nil
+		default:
+			return tgaColor(p, bits, grayscale, alpha), nil
+		}
+	}
+
+	// every pixel takes pixelSize bytes, or at best a run packet covers 128 of them
+	rle := imageType >= tgaRLEColorMapped
+	available := len(data) - pos
+	if rle {
+		available = available / (pixelSize + 1) * 128 * pixelSize
+	}
+	if available < width*height*pixelSize {
+		return nil, errors.New("truncated tga pixel data")
+	}
+
+	// pixels in file order
+	pixels := make([][4]byte, 0, width*height)
+	for len(pixels) < width*height {
+		count, repeat := 1, false
+		if rle {
+			if pos >= len(data) {
+				return nil, errors.New("truncated tga pixel data")
+			}
+			count = int(data[pos]&0x7f) + 1
+			repeat = data[pos]&0x80 != 0
+			pos++
+		}
+		if count > width*height-len(pixels) {
+			count = width*height - len(pixels)
+		}
+
+		for i := 0; i < count; i++ {
+			if pos+pixelSize > len(data) {
+				return nil, errors.New("truncated tga pixel data")
+			}
+			c, err := convert(data[pos : pos+pixelSize])
+			if err != nil {
+				return nil, err
+			}
+			pixels = append(pixels, c)
+			if !repeat || i == count-1 {
+				pos += pixelSize
+			}
+		}
+	}
+
+	// origin is bottom left unless bit 5 is set, bit 4 flips horizontally
+	bottomUp := descriptor&0x20 == 0
+	rightToLeft := descriptor&0x10 != 0
+
+	texels := make([]byte, width*height*4)
+	for y := 0; y < height; y++ {
+		srcY := y
+		if bottomUp {
+			srcY = height - 1 - y
+		}
+		for x := 0; x < width; x++ {
+			srcX := x
+			if rightToLeft {
+				srcX = width - 1 - x
+			}
+			copy(texels[(y*width+x)*4:], pixels[srcY*width+srcX][:])
+		}
+	}
+
+	return &Image{Width: width, Height: height, Format: FormatRGBA8, Layers: 1, Levels: [][]byte{texels}}, nil
+}
+
+// tgaColor converts a little endian BGR(A) or grayscale pixel to rgba. The attribute bit of 16 bit pixels is
+// only used as alpha when the image declares alpha bits, many writers leave it unset.
+func tgaColor(p []byte, bits int, grayscale, alpha bool) [4]byte {
+	switch {
+	case grayscale && bits == 8:
+		return [4]byte{p[0], p[0], p[0], 255}
+	case grayscale:
+		return [4]byte{p[0], p[0], p[0], p[1]}
+	case bits == 15 || bits == 16:
+		v := binary.LittleEndian.Uint16(p)
+		expand := func(c uint16) byte { return byte(c<<3 | c>>2) }
+		a := byte(255)
+		if bits == 16 && alpha && v&0x8000 == 0 {
+			a = 0
+		}
+		return [4]byte{expand(v >> 10 & 0x1f), expand(v >> 5 & 0x1f), expand(v & 0x1f), a}
+	case bits == 24:
+		return [4]byte{p[2], p[1], p[0], 255}
+	default:
+		return [4]byte{p[2], p[1], p[0], p[3]}
+	}
+}
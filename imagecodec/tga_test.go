@@ -0,0 +1,111 @@
+package imagecodec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func tgaHeader(imageType byte, width, height int, bits, descriptor byte) []byte {
+	return []byte{0, 0, imageType, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		byte(width), byte(width >> 8), byte(height), byte(height >> 8), bits, descriptor}
+}
+
+func TestDecodeTGA(t *testing.T) {
+	// 2x2 bottom up BGR, bottom row red green, top row blue white
+	data := tgaHeader(tgaTrueColor, 2, 2, 24, 0)
+	data = append(data, 0, 0, 255, 0, 255, 0)
+	data = append(data, 255, 0, 0, 255, 255, 255)
+
+	img, err := DecodeTGA(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0, 0, 255, 255, 255, 255, 255, 255,
+		255, 0, 0, 255, 0, 255, 0, 255,
+	}
+	if !bytes.Equal(img.Levels[0], want) {
+		t.Errorf("got %v, expected %v", img.Levels[0], want)
+	}
+}
+
+func TestDecodeTGARunLength(t *testing.T) {
+	// 3x1 top down BGRA, a run of two followed by one raw pixel
+	data := tgaHeader(tgaRLETrueColor, 3, 1, 32, 0x28)
+	data = append(data, 0x81, 1, 2, 3, 4)
+	data = append(data, 0x00, 5, 6, 7, 8)
+
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{3, 2, 1, 4, 3, 2, 1, 4, 7, 6, 5, 8}
+	if !bytes.Equal(img.Levels[0], want) {
+		t.Errorf("got %v, expected %v", img.Levels[0], want)
+	}
+
+	if _, err := DecodeTGA(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestDecodeTGAGrayscale(t *testing.T) {
+	data := tgaHeader(tgaGrayscale, 2, 1, 8, 0x20)
+	data = append(data, 10, 20)
+
+	img, err := DecodeTGA(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{10, 10, 10, 255, 20, 20, 20, 255}
+	if !bytes.Equal(img.Levels[0], want) {
+		t.Errorf("got %v, expected %v", img.Levels[0], want)
+	}
+}
+
+func TestDecodeTGAColorMapped(t *testing.T) {
+	// two 24 bit BGR palette entries starting at index 1, pixels index them
+	data := tgaHeader(tgaColorMapped, 2, 1, 8, 0x20)
+	data[1], data[3], data[5], data[7] = 1, 1, 2, 24
+	data = append(data, 0, 0, 255, 255, 0, 0)
+	data = append(data, 2, 1)
+
+	img, err := DecodeTGA(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 0, 255, 255, 255, 0, 0, 255}
+	if !bytes.Equal(img.Levels[0], want) {
+		t.Errorf("got %v, expected %v", img.Levels[0], want)
+	}
+}
+
+func TestDecodeTGAMalformed(t *testing.T) {
+	// color mapped with two 8 bit palette entries, which can't hold a color
+	colorMapped := tgaHeader(tgaColorMapped, 1, 1, 8, 0)
+	colorMapped[1], colorMapped[5], colorMapped[7] = 1, 2, 8
+	colorMapped = append(colorMapped, 1, 2, 0)
+
+	noEntryBits := append([]byte{}, colorMapped...)
+	noEntryBits[7] = 0
+
+	// 65535x65535 true color header with 18 bytes of data
+	huge := tgaHeader(tgaTrueColor, 0xffff, 0xffff, 32, 0)
+	huge = append(huge, make([]byte, 18)...)
+	hugeRLE := tgaHeader(tgaRLETrueColor, 0xffff, 0xffff, 32, 0)
+	hugeRLE = append(hugeRLE, make([]byte, 18)...)
+
+	for name, data := range map[string][]byte{
+		"8 bit map entries":   colorMapped,
+		"0 bit map entries":   noEntryBits,
+		"oversized":           huge,
+		"oversized rle":       hugeRLE,
+		"id beyond the data":  append([]byte{255}, tgaHeader(tgaGrayscale, 1, 1, 8, 0)[1:]...),
+		"truncated header":    tgaHeader(tgaTrueColor, 1, 1, 24, 0)[:17],
+		"missing pixel bytes": append(tgaHeader(tgaTrueColor, 2, 1, 24, 0), 1, 2, 3, 4),
+	} {
+		if _, err := DecodeTGA(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
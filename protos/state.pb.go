@@ -40,20 +40,56 @@ func (State_Cullface) EnumDescriptor() ([]byte, []int) { return fileDescriptor1,
 type State_BlendMode int32
 
 const (
-	State_BLEND_SRC_ALPHA           State_BlendMode = 0
-	State_BLEND_ONE_MINUS_SRC_ALPHA State_BlendMode = 1
-	State_BLEND_ONE                 State_BlendMode = 2
+	State_BLEND_SRC_ALPHA                State_BlendMode = 0
+	State_BLEND_ONE_MINUS_SRC_ALPHA      State_BlendMode = 1
+	State_BLEND_ONE                      State_BlendMode = 2
+	State_BLEND_ZERO                     State_BlendMode = 3
+	State_BLEND_SRC_COLOR                State_BlendMode = 4
+	State_BLEND_ONE_MINUS_SRC_COLOR      State_BlendMode = 5
+	State_BLEND_DST_COLOR                State_BlendMode = 6
+	State_BLEND_ONE_MINUS_DST_COLOR      State_BlendMode = 7
+	State_BLEND_DST_ALPHA                State_BlendMode = 8
+	State_BLEND_ONE_MINUS_DST_ALPHA      State_BlendMode = 9
+	State_BLEND_CONSTANT_COLOR           State_BlendMode = 10
+	State_BLEND_ONE_MINUS_CONSTANT_COLOR State_BlendMode = 11
+	State_BLEND_CONSTANT_ALPHA           State_BlendMode = 12
+	State_BLEND_ONE_MINUS_CONSTANT_ALPHA State_BlendMode = 13
+	State_BLEND_SRC_ALPHA_SATURATE       State_BlendMode = 14
 )
 
 var State_BlendMode_name = map[int32]string{
-	0: "BLEND_SRC_ALPHA",
-	1: "BLEND_ONE_MINUS_SRC_ALPHA",
-	2: "BLEND_ONE",
+	0:  "BLEND_SRC_ALPHA",
+	1:  "BLEND_ONE_MINUS_SRC_ALPHA",
+	2:  "BLEND_ONE",
+	3:  "BLEND_ZERO",
+	4:  "BLEND_SRC_COLOR",
+	5:  "BLEND_ONE_MINUS_SRC_COLOR",
+	6:  "BLEND_DST_COLOR",
+	7:  "BLEND_ONE_MINUS_DST_COLOR",
+	8:  "BLEND_DST_ALPHA",
+	9:  "BLEND_ONE_MINUS_DST_ALPHA",
+	10: "BLEND_CONSTANT_COLOR",
+	11: "BLEND_ONE_MINUS_CONSTANT_COLOR",
+	12: "BLEND_CONSTANT_ALPHA",
+	13: "BLEND_ONE_MINUS_CONSTANT_ALPHA",
+	14: "BLEND_SRC_ALPHA_SATURATE",
 }
 var State_BlendMode_value = map[string]int32{
-	"BLEND_SRC_ALPHA":           0,
-	"BLEND_ONE_MINUS_SRC_ALPHA": 1,
-	"BLEND_ONE":                 2,
+	"BLEND_SRC_ALPHA":                0,
+	"BLEND_ONE_MINUS_SRC_ALPHA":      1,
+	"BLEND_ONE":                      2,
+	"BLEND_ZERO":                     3,
+	"BLEND_SRC_COLOR":                4,
+	"BLEND_ONE_MINUS_SRC_COLOR":      5,
+	"BLEND_DST_COLOR":                6,
+	"BLEND_ONE_MINUS_DST_COLOR":      7,
+	"BLEND_DST_ALPHA":                8,
+	"BLEND_ONE_MINUS_DST_ALPHA":      9,
+	"BLEND_CONSTANT_COLOR":           10,
+	"BLEND_ONE_MINUS_CONSTANT_COLOR": 11,
+	"BLEND_CONSTANT_ALPHA":           12,
+	"BLEND_ONE_MINUS_CONSTANT_ALPHA": 13,
+	"BLEND_SRC_ALPHA_SATURATE":       14,
 }
 
 func (x State_BlendMode) String() string {
@@ -64,17 +100,26 @@ func (State_BlendMode) EnumDescriptor() ([]byte, []int) { return fileDescriptor1
 type State_BlendEquation int32
 
 const (
-	State_BLEND_FUNC_ADD State_BlendEquation = 0
-	State_BLEND_FUNC_MAX State_BlendEquation = 1
+	State_BLEND_FUNC_ADD              State_BlendEquation = 0
+	State_BLEND_FUNC_MAX              State_BlendEquation = 1
+	State_BLEND_FUNC_MIN              State_BlendEquation = 2
+	State_BLEND_FUNC_SUBTRACT         State_BlendEquation = 3
+	State_BLEND_FUNC_REVERSE_SUBTRACT State_BlendEquation = 4
 )
 
 var State_BlendEquation_name = map[int32]string{
 	0: "BLEND_FUNC_ADD",
 	1: "BLEND_FUNC_MAX",
+	2: "BLEND_FUNC_MIN",
+	3: "BLEND_FUNC_SUBTRACT",
+	4: "BLEND_FUNC_REVERSE_SUBTRACT",
 }
 var State_BlendEquation_value = map[string]int32{
-	"BLEND_FUNC_ADD": 0,
-	"BLEND_FUNC_MAX": 1,
+	"BLEND_FUNC_ADD":              0,
+	"BLEND_FUNC_MAX":              1,
+	"BLEND_FUNC_MIN":              2,
+	"BLEND_FUNC_SUBTRACT":         3,
+	"BLEND_FUNC_REVERSE_SUBTRACT": 4,
 }
 
 func (x State_BlendEquation) String() string {
@@ -85,20 +130,35 @@ func (State_BlendEquation) EnumDescriptor() ([]byte, []int) { return fileDescrip
 type State_DepthFunc int32
 
 const (
-	State_DEPTH_LESS_EQUAL State_DepthFunc = 0
-	State_DEPTH_LESS       State_DepthFunc = 1
-	State_DEPTH_EQUAL      State_DepthFunc = 2
+	State_DEPTH_LESS_EQUAL    State_DepthFunc = 0
+	State_DEPTH_LESS          State_DepthFunc = 1
+	State_DEPTH_EQUAL         State_DepthFunc = 2
+	State_DEPTH_GREATER       State_DepthFunc = 3
+	State_DEPTH_GREATER_EQUAL State_DepthFunc = 4
+	State_DEPTH_NOT_EQUAL     State_DepthFunc = 5
+	State_DEPTH_ALWAYS        State_DepthFunc = 6
+	State_DEPTH_NEVER         State_DepthFunc = 7
 )
 
 var State_DepthFunc_name = map[int32]string{
 	0: "DEPTH_LESS_EQUAL",
 	1: "DEPTH_LESS",
 	2: "DEPTH_EQUAL",
+	3: "DEPTH_GREATER",
+	4: "DEPTH_GREATER_EQUAL",
+	5: "DEPTH_NOT_EQUAL",
+	6: "DEPTH_ALWAYS",
+	7: "DEPTH_NEVER",
 }
 var State_DepthFunc_value = map[string]int32{
-	"DEPTH_LESS_EQUAL": 0,
-	"DEPTH_LESS":       1,
-	"DEPTH_EQUAL":      2,
+	"DEPTH_LESS_EQUAL":    0,
+	"DEPTH_LESS":          1,
+	"DEPTH_EQUAL":         2,
+	"DEPTH_GREATER":       3,
+	"DEPTH_GREATER_EQUAL": 4,
+	"DEPTH_NOT_EQUAL":     5,
+	"DEPTH_ALWAYS":        6,
+	"DEPTH_NEVER":         7,
 }
 
 func (x State_DepthFunc) String() string {
@@ -106,20 +166,136 @@ func (x State_DepthFunc) String() string {
 }
 func (State_DepthFunc) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 3} }
 
+type State_StencilFunc int32
+
+const (
+	State_STENCIL_ALWAYS        State_StencilFunc = 0
+	State_STENCIL_NEVER         State_StencilFunc = 1
+	State_STENCIL_LESS          State_StencilFunc = 2
+	State_STENCIL_LESS_EQUAL    State_StencilFunc = 3
+	State_STENCIL_EQUAL         State_StencilFunc = 4
+	State_STENCIL_GREATER       State_StencilFunc = 5
+	State_STENCIL_GREATER_EQUAL State_StencilFunc = 6
+	State_STENCIL_NOT_EQUAL     State_StencilFunc = 7
+)
+
+var State_StencilFunc_name = map[int32]string{
+	0: "STENCIL_ALWAYS",
+	1: "STENCIL_NEVER",
+	2: "STENCIL_LESS",
+	3: "STENCIL_LESS_EQUAL",
+	4: "STENCIL_EQUAL",
+	5: "STENCIL_GREATER",
+	6: "STENCIL_GREATER_EQUAL",
+	7: "STENCIL_NOT_EQUAL",
+}
+var State_StencilFunc_value = map[string]int32{
+	"STENCIL_ALWAYS":        0,
+	"STENCIL_NEVER":         1,
+	"STENCIL_LESS":          2,
+	"STENCIL_LESS_EQUAL":    3,
+	"STENCIL_EQUAL":         4,
+	"STENCIL_GREATER":       5,
+	"STENCIL_GREATER_EQUAL": 6,
+	"STENCIL_NOT_EQUAL":     7,
+}
+
+func (x State_StencilFunc) String() string {
+	return proto.EnumName(State_StencilFunc_name, int32(x))
+}
+func (State_StencilFunc) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 4} }
+
+type State_StencilOp int32
+
+const (
+	State_STENCIL_KEEP      State_StencilOp = 0
+	State_STENCIL_ZERO      State_StencilOp = 1
+	State_STENCIL_REPLACE   State_StencilOp = 2
+	State_STENCIL_INCR      State_StencilOp = 3
+	State_STENCIL_INCR_WRAP State_StencilOp = 4
+	State_STENCIL_DECR      State_StencilOp = 5
+	State_STENCIL_DECR_WRAP State_StencilOp = 6
+	State_STENCIL_INVERT    State_StencilOp = 7
+)
+
+var State_StencilOp_name = map[int32]string{
+	0: "STENCIL_KEEP",
+	1: "STENCIL_ZERO",
+	2: "STENCIL_REPLACE",
+	3: "STENCIL_INCR",
+	4: "STENCIL_INCR_WRAP",
+	5: "STENCIL_DECR",
+	6: "STENCIL_DECR_WRAP",
+	7: "STENCIL_INVERT",
+}
+var State_StencilOp_value = map[string]int32{
+	"STENCIL_KEEP":      0,
+	"STENCIL_ZERO":      1,
+	"STENCIL_REPLACE":   2,
+	"STENCIL_INCR":      3,
+	"STENCIL_INCR_WRAP": 4,
+	"STENCIL_DECR":      5,
+	"STENCIL_DECR_WRAP": 6,
+	"STENCIL_INVERT":    7,
+}
+
+func (x State_StencilOp) String() string {
+	return proto.EnumName(State_StencilOp_name, int32(x))
+}
+func (State_StencilOp) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 5} }
+
+type State_PolygonMode int32
+
+const (
+	State_POLYGON_FILL  State_PolygonMode = 0
+	State_POLYGON_LINE  State_PolygonMode = 1
+	State_POLYGON_POINT State_PolygonMode = 2
+)
+
+var State_PolygonMode_name = map[int32]string{
+	0: "POLYGON_FILL",
+	1: "POLYGON_LINE",
+	2: "POLYGON_POINT",
+}
+var State_PolygonMode_value = map[string]int32{
+	"POLYGON_FILL":  0,
+	"POLYGON_LINE":  1,
+	"POLYGON_POINT": 2,
+}
+
+func (x State_PolygonMode) String() string {
+	return proto.EnumName(State_PolygonMode_name, int32(x))
+}
+func (State_PolygonMode) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 6} }
+
 type State struct {
-	Name          string              `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	ProgramName   string              `protobuf:"bytes,2,opt,name=programName" json:"programName,omitempty"`
-	Culling       bool                `protobuf:"varint,3,opt,name=culling" json:"culling,omitempty"`
-	CullFace      State_Cullface      `protobuf:"varint,4,opt,name=cullFace,enum=protos.State_Cullface" json:"cullFace,omitempty"`
-	Blending      bool                `protobuf:"varint,5,opt,name=blending" json:"blending,omitempty"`
-	BlendSrcMode  State_BlendMode     `protobuf:"varint,6,opt,name=blend_src_mode,json=blendSrcMode,enum=protos.State_BlendMode" json:"blend_src_mode,omitempty"`
-	BlendDstMode  State_BlendMode     `protobuf:"varint,7,opt,name=blend_dst_mode,json=blendDstMode,enum=protos.State_BlendMode" json:"blend_dst_mode,omitempty"`
-	BlendEquation State_BlendEquation `protobuf:"varint,8,opt,name=blend_equation,json=blendEquation,enum=protos.State_BlendEquation" json:"blend_equation,omitempty"`
-	DepthTest     bool                `protobuf:"varint,9,opt,name=depth_test,json=depthTest" json:"depth_test,omitempty"`
-	DepthWrite    bool                `protobuf:"varint,10,opt,name=depth_write,json=depthWrite" json:"depth_write,omitempty"`
-	DepthFunc     State_DepthFunc     `protobuf:"varint,11,opt,name=depth_func,json=depthFunc,enum=protos.State_DepthFunc" json:"depth_func,omitempty"`
-	ColorWrite    bool                `protobuf:"varint,12,opt,name=color_write,json=colorWrite" json:"color_write,omitempty"`
-	ScissorTest   bool                `protobuf:"varint,13,opt,name=scissor_test,json=scissorTest" json:"scissor_test,omitempty"`
+	Name                string              `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	ProgramName         string              `protobuf:"bytes,2,opt,name=programName" json:"programName,omitempty"`
+	ProgramKeywords     []string            `protobuf:"bytes,27,rep,name=program_keywords,json=programKeywords" json:"program_keywords,omitempty"`
+	Culling             bool                `protobuf:"varint,3,opt,name=culling" json:"culling,omitempty"`
+	CullFace            State_Cullface      `protobuf:"varint,4,opt,name=cullFace,enum=protos.State_Cullface" json:"cullFace,omitempty"`
+	Blending            bool                `protobuf:"varint,5,opt,name=blending" json:"blending,omitempty"`
+	BlendSrcMode        State_BlendMode     `protobuf:"varint,6,opt,name=blend_src_mode,json=blendSrcMode,enum=protos.State_BlendMode" json:"blend_src_mode,omitempty"`
+	BlendDstMode        State_BlendMode     `protobuf:"varint,7,opt,name=blend_dst_mode,json=blendDstMode,enum=protos.State_BlendMode" json:"blend_dst_mode,omitempty"`
+	BlendEquation       State_BlendEquation `protobuf:"varint,8,opt,name=blend_equation,json=blendEquation,enum=protos.State_BlendEquation" json:"blend_equation,omitempty"`
+	BlendSeparateAlpha  bool                `protobuf:"varint,14,opt,name=blend_separate_alpha,json=blendSeparateAlpha" json:"blend_separate_alpha,omitempty"`
+	BlendSrcAlphaMode   State_BlendMode     `protobuf:"varint,15,opt,name=blend_src_alpha_mode,json=blendSrcAlphaMode,enum=protos.State_BlendMode" json:"blend_src_alpha_mode,omitempty"`
+	BlendDstAlphaMode   State_BlendMode     `protobuf:"varint,16,opt,name=blend_dst_alpha_mode,json=blendDstAlphaMode,enum=protos.State_BlendMode" json:"blend_dst_alpha_mode,omitempty"`
+	BlendAlphaEquation  State_BlendEquation `protobuf:"varint,17,opt,name=blend_alpha_equation,json=blendAlphaEquation,enum=protos.State_BlendEquation" json:"blend_alpha_equation,omitempty"`
+	BlendColor          []float32           `protobuf:"fixed32,18,rep,packed,name=blend_color,json=blendColor" json:"blend_color,omitempty"`
+	DepthTest           bool                `protobuf:"varint,9,opt,name=depth_test,json=depthTest" json:"depth_test,omitempty"`
+	DepthWrite          bool                `protobuf:"varint,10,opt,name=depth_write,json=depthWrite" json:"depth_write,omitempty"`
+	DepthFunc           State_DepthFunc     `protobuf:"varint,11,opt,name=depth_func,json=depthFunc,enum=protos.State_DepthFunc" json:"depth_func,omitempty"`
+	ColorWrite          bool                `protobuf:"varint,12,opt,name=color_write,json=colorWrite" json:"color_write,omitempty"`
+	ColorMask           *State_ColorMask    `protobuf:"bytes,19,opt,name=color_mask,json=colorMask" json:"color_mask,omitempty"`
+	ScissorTest         bool                `protobuf:"varint,13,opt,name=scissor_test,json=scissorTest" json:"scissor_test,omitempty"`
+	StencilTest         bool                `protobuf:"varint,20,opt,name=stencil_test,json=stencilTest" json:"stencil_test,omitempty"`
+	StencilFront        *State_StencilFace  `protobuf:"bytes,21,opt,name=stencil_front,json=stencilFront" json:"stencil_front,omitempty"`
+	StencilBack         *State_StencilFace  `protobuf:"bytes,22,opt,name=stencil_back,json=stencilBack" json:"stencil_back,omitempty"`
+	PolygonOffset       bool                `protobuf:"varint,23,opt,name=polygon_offset,json=polygonOffset" json:"polygon_offset,omitempty"`
+	PolygonOffsetFactor float32             `protobuf:"fixed32,24,opt,name=polygon_offset_factor,json=polygonOffsetFactor" json:"polygon_offset_factor,omitempty"`
+	PolygonOffsetUnits  float32             `protobuf:"fixed32,25,opt,name=polygon_offset_units,json=polygonOffsetUnits" json:"polygon_offset_units,omitempty"`
+	PolygonMode         State_PolygonMode   `protobuf:"varint,26,opt,name=polygon_mode,json=polygonMode,enum=protos.State_PolygonMode" json:"polygon_mode,omitempty"`
 }
 
 func (m *State) Reset()                    { *m = State{} }
@@ -127,46 +303,147 @@ func (m *State) String() string            { return proto.CompactTextString(m) }
 func (*State) ProtoMessage()               {}
 func (*State) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
 
+func (m *State) GetColorMask() *State_ColorMask {
+	if m != nil {
+		return m.ColorMask
+	}
+	return nil
+}
+
+func (m *State) GetStencilFront() *State_StencilFace {
+	if m != nil {
+		return m.StencilFront
+	}
+	return nil
+}
+
+func (m *State) GetStencilBack() *State_StencilFace {
+	if m != nil {
+		return m.StencilBack
+	}
+	return nil
+}
+
+type State_ColorMask struct {
+	Red   bool `protobuf:"varint,1,opt,name=red" json:"red,omitempty"`
+	Green bool `protobuf:"varint,2,opt,name=green" json:"green,omitempty"`
+	Blue  bool `protobuf:"varint,3,opt,name=blue" json:"blue,omitempty"`
+	Alpha bool `protobuf:"varint,4,opt,name=alpha" json:"alpha,omitempty"`
+}
+
+func (m *State_ColorMask) Reset()                    { *m = State_ColorMask{} }
+func (m *State_ColorMask) String() string            { return proto.CompactTextString(m) }
+func (*State_ColorMask) ProtoMessage()               {}
+func (*State_ColorMask) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 0} }
+
+type State_StencilFace struct {
+	Func      State_StencilFunc `protobuf:"varint,1,opt,name=func,enum=protos.State_StencilFunc" json:"func,omitempty"`
+	Ref       uint32            `protobuf:"varint,2,opt,name=ref" json:"ref,omitempty"`
+	ReadMask  *uint32           `protobuf:"varint,3,opt,name=read_mask,json=readMask,proto3,oneof" json:"read_mask,omitempty"`
+	WriteMask *uint32           `protobuf:"varint,4,opt,name=write_mask,json=writeMask,proto3,oneof" json:"write_mask,omitempty"`
+	Fail      State_StencilOp   `protobuf:"varint,5,opt,name=fail,enum=protos.State_StencilOp" json:"fail,omitempty"`
+	DepthFail State_StencilOp   `protobuf:"varint,6,opt,name=depth_fail,json=depthFail,enum=protos.State_StencilOp" json:"depth_fail,omitempty"`
+	Pass      State_StencilOp   `protobuf:"varint,7,opt,name=pass,enum=protos.State_StencilOp" json:"pass,omitempty"`
+}
+
+func (m *State_StencilFace) Reset()                    { *m = State_StencilFace{} }
+func (m *State_StencilFace) String() string            { return proto.CompactTextString(m) }
+func (*State_StencilFace) ProtoMessage()               {}
+func (*State_StencilFace) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0, 1} }
+
 func init() {
 	proto.RegisterType((*State)(nil), "protos.State")
+	proto.RegisterType((*State_ColorMask)(nil), "protos.State.ColorMask")
+	proto.RegisterType((*State_StencilFace)(nil), "protos.State.StencilFace")
 	proto.RegisterEnum("protos.State_Cullface", State_Cullface_name, State_Cullface_value)
 	proto.RegisterEnum("protos.State_BlendMode", State_BlendMode_name, State_BlendMode_value)
 	proto.RegisterEnum("protos.State_BlendEquation", State_BlendEquation_name, State_BlendEquation_value)
 	proto.RegisterEnum("protos.State_DepthFunc", State_DepthFunc_name, State_DepthFunc_value)
+	proto.RegisterEnum("protos.State_StencilFunc", State_StencilFunc_name, State_StencilFunc_value)
+	proto.RegisterEnum("protos.State_StencilOp", State_StencilOp_name, State_StencilOp_value)
+	proto.RegisterEnum("protos.State_PolygonMode", State_PolygonMode_name, State_PolygonMode_value)
 }
 
 func init() { proto.RegisterFile("state.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 466 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x53, 0xcf, 0x8f, 0xd2, 0x4e,
-	0x14, 0xa7, 0x7c, 0x77, 0x59, 0xfa, 0x0a, 0x6c, 0xf3, 0xbe, 0x46, 0xab, 0x66, 0x23, 0xf6, 0xc4,
-	0x89, 0xc3, 0x9a, 0xa8, 0x17, 0x0f, 0xfd, 0x45, 0x30, 0x96, 0xb2, 0xb6, 0x10, 0xbd, 0x4d, 0x4a,
-	0x3b, 0xbb, 0x92, 0x94, 0x16, 0x3b, 0xd3, 0xf8, 0x4f, 0xf9, 0x47, 0x9a, 0x99, 0x81, 0xc2, 0x9a,
-	0x3d, 0x78, 0xe2, 0x7d, 0x7e, 0xce, 0xcc, 0x23, 0x05, 0x83, 0xf1, 0x94, 0xd3, 0xe9, 0xbe, 0xae,
-	0x78, 0x85, 0x3d, 0xf9, 0xc3, 0xec, 0xdf, 0x3d, 0xb8, 0x4c, 0x04, 0x8f, 0x08, 0x17, 0x65, 0xba,
-	0xa3, 0x96, 0x36, 0xd6, 0x26, 0x7a, 0x2c, 0x67, 0x1c, 0x83, 0xb1, 0xaf, 0xab, 0x87, 0x3a, 0xdd,
-	0x45, 0x42, 0xea, 0x4a, 0xe9, 0x9c, 0x42, 0x0b, 0xae, 0xb2, 0xa6, 0x28, 0xb6, 0xe5, 0x83, 0xf5,
-	0xdf, 0x58, 0x9b, 0xf4, 0xe3, 0x23, 0xc4, 0x5b, 0xe8, 0x8b, 0x71, 0x96, 0x66, 0xd4, 0xba, 0x18,
-	0x6b, 0x93, 0xd1, 0xed, 0x73, 0x75, 0x36, 0x9b, 0xca, 0x03, 0xa7, 0x5e, 0x53, 0x14, 0xf7, 0x69,
-	0x46, 0xe3, 0xd6, 0x87, 0xaf, 0xa0, 0xbf, 0x29, 0x68, 0x99, 0x8b, 0xba, 0x4b, 0x59, 0xd7, 0x62,
-	0xfc, 0x04, 0x23, 0x39, 0x13, 0x56, 0x67, 0x64, 0x57, 0xe5, 0xd4, 0xea, 0xc9, 0xd6, 0x17, 0x8f,
-	0x5b, 0x5d, 0xe1, 0x59, 0x54, 0x39, 0x8d, 0x07, 0xd2, 0x9e, 0xd4, 0x99, 0x40, 0xa7, 0x78, 0xce,
-	0xb8, 0x8a, 0x5f, 0xfd, 0x4b, 0xdc, 0x67, 0x5c, 0xc6, 0xdd, 0x63, 0x9c, 0xfe, 0x6c, 0x52, 0xbe,
-	0xad, 0x4a, 0xab, 0x2f, 0xe3, 0xaf, 0x9f, 0x88, 0x07, 0x07, 0x4b, 0x3c, 0xdc, 0x9c, 0x43, 0xbc,
-	0x01, 0xc8, 0xe9, 0x9e, 0xff, 0x20, 0x9c, 0x32, 0x6e, 0xe9, 0xf2, 0x7d, 0xba, 0x64, 0x56, 0x94,
-	0x71, 0x7c, 0x03, 0x86, 0x92, 0x7f, 0xd5, 0x5b, 0x4e, 0x2d, 0x90, 0xba, 0x4a, 0x7c, 0x13, 0x0c,
-	0xbe, 0x3f, 0xe6, 0xef, 0x9b, 0x32, 0xb3, 0x8c, 0xa7, 0xae, 0xef, 0x0b, 0x7d, 0xd6, 0x94, 0xd9,
-	0xa1, 0x58, 0x8c, 0xa2, 0x38, 0xab, 0x8a, 0xaa, 0x3e, 0x14, 0x0f, 0x54, 0xb1, 0xa4, 0x54, 0xf1,
-	0x5b, 0x18, 0xb0, 0x6c, 0xcb, 0x58, 0x55, 0xab, 0xab, 0x0d, 0xa5, 0xc3, 0x38, 0x70, 0xe2, 0x72,
-	0xf6, 0x47, 0xe8, 0x1f, 0xff, 0x2f, 0x1c, 0x82, 0xee, 0xad, 0xc3, 0x90, 0xb8, 0x8e, 0xf7, 0xc5,
-	0xec, 0xe0, 0x08, 0x40, 0xc2, 0x59, 0xbc, 0x8c, 0x56, 0xa6, 0x76, 0x92, 0x97, 0xab, 0xb9, 0xd9,
-	0xb5, 0x23, 0xd0, 0xdb, 0xa5, 0xe2, 0xff, 0x70, 0xed, 0x86, 0x41, 0xe4, 0x93, 0x24, 0xf6, 0x88,
-	0x13, 0xde, 0xcd, 0x1d, 0xb3, 0x83, 0x37, 0xf0, 0x52, 0x91, 0xcb, 0x28, 0x20, 0x8b, 0xcf, 0xd1,
-	0x3a, 0x39, 0x93, 0x65, 0x5f, 0x2b, 0x9b, 0x5d, 0xfb, 0x03, 0x0c, 0x1f, 0x6d, 0x19, 0x11, 0x46,
-	0x4a, 0x9f, 0xad, 0x23, 0x8f, 0x38, 0xbe, 0x6f, 0x76, 0xfe, 0xe2, 0x16, 0xce, 0x77, 0x53, 0xb3,
-	0x5d, 0xd0, 0xdb, 0xf5, 0xe0, 0x33, 0x30, 0xfd, 0xe0, 0x6e, 0x35, 0x27, 0x61, 0x90, 0x24, 0x24,
-	0xf8, 0xba, 0x76, 0x42, 0xf5, 0x94, 0x13, 0x6b, 0x6a, 0x78, 0x0d, 0x86, 0xc2, 0xca, 0xd0, 0xdd,
-	0xa8, 0xcf, 0xe6, 0xdd, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x77, 0xda, 0xfa, 0xc2, 0x4c, 0x03,
+	// 1234 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xed, 0x6e, 0xdb, 0x36,
+	0x1b, 0xb5, 0xfc, 0xad, 0x47, 0xb6, 0xc3, 0x30, 0x1f, 0x55, 0xd2, 0xb7, 0x6f, 0x3d, 0x03, 0x03,
+	0x3c, 0x14, 0x2b, 0x86, 0x0c, 0x18, 0xf6, 0x63, 0x1b, 0x20, 0xcb, 0x72, 0x63, 0x44, 0x91, 0x3c,
+	0x4a, 0x6e, 0xd6, 0x01, 0x83, 0xa0, 0xc8, 0x72, 0x6a, 0xc4, 0xb1, 0x3c, 0x49, 0x46, 0xd1, 0x0b,
+	0x18, 0xb0, 0x0b, 0xd8, 0x35, 0xec, 0xc7, 0xae, 0x60, 0xff, 0x77, 0x61, 0x1b, 0x48, 0xea, 0xcb,
+	0x86, 0x9b, 0xf6, 0x57, 0xc8, 0xf3, 0x9c, 0x73, 0x78, 0x1e, 0x9a, 0xa4, 0x02, 0x52, 0x14, 0xbb,
+	0xb1, 0xff, 0x72, 0x1d, 0x06, 0x71, 0x80, 0xeb, 0xec, 0x4f, 0xd4, 0xfb, 0xf7, 0x14, 0x6a, 0x16,
+	0xc5, 0x31, 0x86, 0xea, 0xca, 0x7d, 0xf0, 0x65, 0xa1, 0x2b, 0xf4, 0x45, 0xc2, 0xc6, 0xb8, 0x0b,
+	0xd2, 0x3a, 0x0c, 0xee, 0x42, 0xf7, 0xc1, 0xa0, 0xa5, 0x32, 0x2b, 0x15, 0x21, 0xfc, 0x05, 0xa0,
+	0x64, 0xea, 0xdc, 0xfb, 0xef, 0xdf, 0x05, 0xe1, 0x2c, 0x92, 0x9f, 0x76, 0x2b, 0x7d, 0x91, 0x1c,
+	0x24, 0xf8, 0x55, 0x02, 0x63, 0x19, 0x1a, 0xde, 0x66, 0xb9, 0x5c, 0xac, 0xee, 0xe4, 0x4a, 0x57,
+	0xe8, 0x37, 0x49, 0x3a, 0xc5, 0x17, 0xd0, 0xa4, 0xc3, 0x91, 0xeb, 0xf9, 0x72, 0xb5, 0x2b, 0xf4,
+	0x3b, 0x17, 0xa7, 0x3c, 0x66, 0xf4, 0x92, 0x65, 0x7b, 0xa9, 0x6e, 0x96, 0xcb, 0xb9, 0xeb, 0xf9,
+	0x24, 0xe3, 0xe1, 0x73, 0x68, 0xde, 0x2e, 0xfd, 0xd5, 0x8c, 0xda, 0xd5, 0x98, 0x5d, 0x36, 0xc7,
+	0xdf, 0x43, 0x87, 0x8d, 0x9d, 0x28, 0xf4, 0x9c, 0x87, 0x60, 0xe6, 0xcb, 0x75, 0xe6, 0xfa, 0x64,
+	0xdb, 0x75, 0x40, 0x39, 0xd7, 0xc1, 0xcc, 0x27, 0x2d, 0x46, 0xb7, 0x42, 0x8f, 0xce, 0x72, 0xf9,
+	0x2c, 0x8a, 0xb9, 0xbc, 0xf1, 0x29, 0xf2, 0x61, 0x14, 0x33, 0xf9, 0x20, 0x95, 0xfb, 0xbf, 0x6e,
+	0xdc, 0x78, 0x11, 0xac, 0xe4, 0x26, 0x93, 0x3f, 0xdd, 0x23, 0xd7, 0x12, 0x0a, 0x69, 0xdf, 0x16,
+	0xa7, 0xf8, 0x2b, 0x38, 0x4e, 0x3a, 0xf0, 0xd7, 0x6e, 0xe8, 0xc6, 0xbe, 0xe3, 0x2e, 0xd7, 0x6f,
+	0x5d, 0xb9, 0xc3, 0x3a, 0xc5, 0x3c, 0x6e, 0x52, 0x52, 0x68, 0x05, 0x5f, 0x66, 0x8a, 0xd0, 0xe3,
+	0x64, 0x1e, 0xfd, 0xe0, 0xf1, 0xe8, 0x87, 0x69, 0xe7, 0xcc, 0x85, 0xe5, 0xcf, 0x9c, 0x68, 0xfb,
+	0x05, 0x27, 0xf4, 0x29, 0x4e, 0xc3, 0x28, 0xce, 0x9d, 0xae, 0x53, 0x27, 0xee, 0x92, 0xed, 0xc7,
+	0xe1, 0xc7, 0xf7, 0x83, 0xb7, 0xc8, 0xac, 0xb2, 0x4d, 0x79, 0x0e, 0x12, 0xb7, 0xf3, 0x82, 0x65,
+	0x10, 0xca, 0xb8, 0x5b, 0xe9, 0x97, 0x09, 0x30, 0x48, 0xa5, 0x08, 0x7e, 0x06, 0x30, 0xf3, 0xd7,
+	0xf1, 0x5b, 0x27, 0xf6, 0xa3, 0x58, 0x16, 0xd9, 0x5e, 0x89, 0x0c, 0xb1, 0xfd, 0x28, 0xa6, 0x7a,
+	0x5e, 0x7e, 0x17, 0x2e, 0x62, 0x5f, 0x06, 0x56, 0xe7, 0x8a, 0x1b, 0x8a, 0xe0, 0x6f, 0x52, 0xfd,
+	0x7c, 0xb3, 0xf2, 0x64, 0x69, 0x5f, 0xbf, 0x43, 0x5a, 0x1f, 0x6d, 0x56, 0x5e, 0x62, 0x4c, 0x87,
+	0xd4, 0x98, 0x45, 0x4a, 0x8c, 0x5b, 0xdc, 0x98, 0x41, 0x99, 0x31, 0x27, 0x3c, 0xb8, 0xd1, 0xbd,
+	0x7c, 0xd4, 0x15, 0xfa, 0xd2, 0xae, 0x31, 0xeb, 0xe0, 0xda, 0x8d, 0xee, 0x89, 0xe8, 0xa5, 0x43,
+	0xfc, 0x19, 0xb4, 0x22, 0x6f, 0x11, 0x45, 0x41, 0xc8, 0x5b, 0x6a, 0x33, 0x67, 0x29, 0xc1, 0x58,
+	0x53, 0x94, 0x12, 0xfb, 0x2b, 0x6f, 0xb1, 0xe4, 0x94, 0xe3, 0x84, 0xc2, 0x31, 0x46, 0xf9, 0x01,
+	0xda, 0x29, 0x65, 0x1e, 0x06, 0xab, 0x58, 0x3e, 0x61, 0x01, 0xce, 0xb6, 0x03, 0x58, 0x9c, 0x42,
+	0x2f, 0x17, 0x49, 0x2d, 0x47, 0x94, 0x8e, 0xbf, 0xcb, 0x97, 0xb8, 0x75, 0xbd, 0x7b, 0xf9, 0xf4,
+	0x63, 0xf2, 0x74, 0xf5, 0x81, 0xeb, 0xdd, 0xe3, 0xcf, 0xa1, 0xb3, 0x0e, 0x96, 0xef, 0xef, 0x82,
+	0x95, 0x13, 0xcc, 0xe7, 0x91, 0x1f, 0xcb, 0x4f, 0x58, 0xc4, 0x76, 0x82, 0x9a, 0x0c, 0xc4, 0x17,
+	0x70, 0xb2, 0x4d, 0x73, 0xe6, 0xae, 0x17, 0x07, 0xa1, 0x2c, 0x77, 0x85, 0x7e, 0x99, 0x1c, 0x6d,
+	0xb1, 0x47, 0xac, 0x44, 0x6f, 0xc9, 0x8e, 0x66, 0xb3, 0x5a, 0xc4, 0x91, 0x7c, 0xc6, 0x24, 0x78,
+	0x4b, 0x32, 0xa5, 0x15, 0xda, 0x4a, 0xaa, 0x60, 0x67, 0xfa, 0x9c, 0xfd, 0xc6, 0x3b, 0xad, 0x4c,
+	0x38, 0x83, 0x9d, 0x6a, 0x69, 0x9d, 0x4f, 0xce, 0x7f, 0x01, 0x31, 0xfb, 0x99, 0x30, 0x82, 0x4a,
+	0xe8, 0xcf, 0xd8, 0x73, 0xd9, 0x24, 0x74, 0x88, 0x8f, 0xa1, 0x76, 0x17, 0xfa, 0xfe, 0x8a, 0xbd,
+	0x93, 0x4d, 0xc2, 0x27, 0xf4, 0x5d, 0xbd, 0x5d, 0x6e, 0xfc, 0xe4, 0xcd, 0x63, 0x63, 0xca, 0xe4,
+	0xf7, 0xb9, 0xca, 0x99, 0x6c, 0x72, 0xfe, 0x4f, 0x19, 0xa4, 0xc2, 0x36, 0xe2, 0x2f, 0xa1, 0xca,
+	0x0e, 0xa2, 0xb0, 0x2f, 0x64, 0x4a, 0xa4, 0x47, 0x91, 0xd1, 0x78, 0xa0, 0x39, 0x5b, 0xbc, 0x4d,
+	0x03, 0xcd, 0x71, 0x17, 0xc4, 0xd0, 0x77, 0x67, 0xfc, 0xd4, 0xd1, 0xf5, 0xdb, 0x97, 0x25, 0xd2,
+	0xa4, 0x10, 0xed, 0xe0, 0x77, 0x41, 0xc0, 0x3d, 0x00, 0x76, 0x66, 0x39, 0xa5, 0xca, 0x28, 0x02,
+	0x11, 0x19, 0x96, 0x72, 0x5e, 0x40, 0x75, 0xee, 0x2e, 0x96, 0x72, 0x6d, 0xdf, 0x7d, 0x48, 0x62,
+	0x98, 0x6b, 0xc2, 0x48, 0x85, 0x2b, 0x44, 0x25, 0xf5, 0xc7, 0x25, 0xc9, 0x15, 0xa2, 0xba, 0x17,
+	0x50, 0x5d, 0xbb, 0x51, 0x24, 0x37, 0x1e, 0x57, 0x30, 0xd2, 0xa0, 0x05, 0xe0, 0x64, 0x8d, 0x0d,
+	0xda, 0x20, 0x39, 0x79, 0x13, 0xbd, 0x6f, 0xa1, 0x99, 0x7e, 0x2e, 0x70, 0x1b, 0x44, 0x75, 0xaa,
+	0xeb, 0xce, 0x40, 0x51, 0xaf, 0x50, 0x09, 0x77, 0x00, 0xd8, 0x74, 0x44, 0x4c, 0xc3, 0x46, 0x42,
+	0x5e, 0x36, 0xed, 0x4b, 0x54, 0xee, 0xfd, 0x51, 0x01, 0x31, 0x7b, 0xcf, 0xf0, 0x11, 0x1c, 0x0c,
+	0x74, 0xcd, 0x18, 0x3a, 0x16, 0x51, 0x1d, 0x45, 0x9f, 0x5c, 0x2a, 0xa8, 0x84, 0x9f, 0xc1, 0x19,
+	0x07, 0x4d, 0x43, 0x73, 0xae, 0xc7, 0xc6, 0xd4, 0x2a, 0x94, 0x99, 0x61, 0x56, 0x46, 0x65, 0xba,
+	0x1e, 0x9f, 0xfe, 0xac, 0x11, 0x13, 0x55, 0xb6, 0x2d, 0x55, 0x53, 0x37, 0x09, 0xaa, 0x7e, 0xc8,
+	0x92, 0x97, 0x6b, 0xb9, 0x66, 0x68, 0xd9, 0x09, 0x58, 0xdf, 0xa7, 0xc9, 0xcb, 0x8d, 0x6d, 0x0d,
+	0xcf, 0xd6, 0xfc, 0x90, 0x86, 0x97, 0x45, 0x2c, 0xc3, 0x31, 0x2f, 0xab, 0xa6, 0x61, 0xd9, 0x8a,
+	0x91, 0xba, 0x01, 0xee, 0xc1, 0xff, 0x77, 0x85, 0x3b, 0x1c, 0x69, 0x8f, 0x9a, 0xfb, 0xb6, 0x1e,
+	0x55, 0x73, 0x4e, 0x1b, 0xff, 0x0f, 0xe4, 0x9d, 0xad, 0x76, 0x2c, 0xc5, 0x9e, 0x12, 0xc5, 0xd6,
+	0x50, 0xa7, 0xf7, 0x9b, 0x00, 0xed, 0xad, 0x8f, 0x03, 0xc6, 0xd0, 0xe1, 0xfc, 0xd1, 0xd4, 0x50,
+	0x1d, 0x65, 0x38, 0x44, 0xa5, 0x1d, 0xec, 0x5a, 0xf9, 0x09, 0x09, 0xbb, 0xd8, 0xd8, 0x40, 0x65,
+	0xfc, 0x04, 0x8e, 0x0a, 0x98, 0x35, 0x1d, 0xd8, 0x44, 0x51, 0x6d, 0x54, 0xc1, 0xcf, 0xe1, 0x69,
+	0xa1, 0x40, 0xb4, 0xd7, 0x1a, 0xb1, 0xb4, 0x9c, 0x50, 0xed, 0xfd, 0x29, 0x80, 0x98, 0x3d, 0xff,
+	0xf8, 0x18, 0xd0, 0x50, 0x9b, 0xd8, 0x97, 0x8e, 0xae, 0x59, 0x96, 0xa3, 0xfd, 0x38, 0x55, 0x74,
+	0x7e, 0xc2, 0x72, 0x14, 0x09, 0xf8, 0x00, 0x24, 0x3e, 0xe7, 0x84, 0x32, 0x3e, 0x84, 0x36, 0x07,
+	0x5e, 0x11, 0x4d, 0xb1, 0x35, 0x82, 0x2a, 0x34, 0xd1, 0x16, 0x94, 0x70, 0xab, 0xf4, 0x67, 0xe4,
+	0x05, 0xc3, 0xb4, 0x13, 0xb0, 0x86, 0x11, 0xb4, 0x38, 0xa8, 0xe8, 0x37, 0xca, 0x1b, 0x0b, 0xd5,
+	0xf3, 0x35, 0x0c, 0x9a, 0x19, 0x35, 0x7a, 0x7f, 0x0b, 0xf9, 0x3b, 0x42, 0xa3, 0x62, 0xe8, 0x58,
+	0xb6, 0x66, 0xa8, 0x63, 0x3d, 0x15, 0x95, 0x68, 0x8e, 0x14, 0xe3, 0x32, 0x81, 0x3a, 0xa7, 0x10,
+	0x4b, 0x5f, 0xc6, 0xa7, 0x80, 0x8b, 0x48, 0x92, 0xa1, 0x52, 0x14, 0x17, 0xb2, 0xa6, 0x50, 0xda,
+	0x59, 0x0d, 0x9f, 0xc1, 0xc9, 0x0e, 0x98, 0xf0, 0xeb, 0xf8, 0x04, 0x0e, 0xb3, 0xf5, 0xb3, 0xee,
+	0x1a, 0xbd, 0xbf, 0x04, 0x10, 0xb3, 0xdb, 0x5e, 0x4c, 0x74, 0xa5, 0x69, 0x13, 0x54, 0x2a, 0x22,
+	0xec, 0x4e, 0x09, 0xc5, 0x85, 0x89, 0x36, 0xd1, 0x15, 0x95, 0x5e, 0xbc, 0x02, 0x6d, 0x6c, 0xa8,
+	0x74, 0x93, 0x0b, 0xeb, 0x51, 0xc4, 0xb9, 0x21, 0xca, 0x04, 0x55, 0x8b, 0xc4, 0xa1, 0xa6, 0xd2,
+	0xcc, 0x05, 0xe2, 0x50, 0x4b, 0x89, 0xf5, 0xe2, 0x1e, 0x8e, 0x8d, 0xd7, 0x1a, 0xb1, 0x51, 0xa3,
+	0x37, 0x04, 0xa9, 0xf0, 0xa9, 0xa0, 0x5e, 0x13, 0x53, 0x7f, 0xf3, 0xca, 0x34, 0x9c, 0xd1, 0x58,
+	0xd7, 0x51, 0xa9, 0x88, 0xe8, 0x63, 0x43, 0x43, 0x02, 0xdd, 0xb9, 0x14, 0x99, 0x98, 0x63, 0xc3,
+	0x46, 0xe5, 0x5b, 0xfe, 0x9f, 0xf8, 0xd7, 0xff, 0x0d, 0x00, 0xae, 0x41, 0x7d, 0x88, 0x9f, 0x0b,
 	0x00, 0x00,
 }
@@ -0,0 +1,63 @@
+package opengl
+
+import (
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/golang/glog"
+)
+
+// leaked holds GL objects of resources which were garbage collected without being deleted. Finalizers run on
+// their own goroutine where GL can't be called, so objects are queued and deleted on the main thread at the
+// start of the next frame.
+var leaked struct {
+	sync.Mutex
+	textures []uint32
+	programs []uint32
+}
+
+func textureCleanup(t *Texture) {
+	if t.id == 0 {
+		return
+	}
+
+	glog.Warningf("Texture %d was garbage collected without being deleted", t.id)
+	leaked.Lock()
+	leaked.textures = append(leaked.textures, t.id)
+	leaked.Unlock()
+}
+
+func programCleanup(p *Program) {
+	if p.id == 0 {
+		return
+	}
+
+	glog.Warningf("Program %s was garbage collected without being deleted", p.name)
+	leaked.Lock()
+	leaked.programs = append(leaked.programs, p.id)
+	leaked.Unlock()
+}
+
+// deleteLeaked deletes the GL objects queued by finalizers, it must be called from the main thread
+func deleteLeaked() {
+	leaked.Lock()
+	textures, programs := leaked.textures, leaked.programs
+	leaked.textures, leaked.programs = nil, nil
+	leaked.Unlock()
+
+	for _, id := range textures {
+		for unit, bound := range textureUnitBindings {
+			if bound == id {
+				delete(textureUnitBindings, unit)
+			}
+		}
+		gl.DeleteTextures(1, &id)
+	}
+
+	for _, id := range programs {
+		if currentProgram == id {
+			currentProgram = 0
+		}
+		gl.DeleteProgram(id)
+	}
+}
@@ -10,7 +10,9 @@ import (
 type Framebuffer struct {
 	fbo              uint32
 	depthAttachment  core.Texture
+	depthLayer       int
 	colorAttachments map[int]core.Texture
+	colorLayers      map[int]int
 }
 
 var (
@@ -19,7 +21,7 @@ var (
 
 // NewFramebuffer implements the core.RenderSystem interface
 func (r *RenderSystem) NewFramebuffer() core.Framebuffer {
-	f := &Framebuffer{0, nil, make(map[int]core.Texture)}
+	f := &Framebuffer{0, nil, -1, make(map[int]core.Texture), make(map[int]int)}
 	gl.GenFramebuffers(1, &f.fbo)
 	return f
 }
@@ -44,12 +46,33 @@ func validateCurrentFramebuffer() {
 	}
 }
 
+// attachTexture attaches a texture, or a single layer of it, to the bound draw framebuffer. A negative layer
+// attaches the whole texture, which is layered for cubemaps, arrays and 3D textures.
+func attachTexture(attachment uint32, t *Texture, layer int) {
+	switch {
+	case layer < 0:
+		gl.FramebufferTexture(gl.DRAW_FRAMEBUFFER, attachment, t.id, 0)
+	case t.target == gl.TEXTURE_CUBE_MAP:
+		gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, attachment, uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+layer), t.id, 0)
+	case t.target == gl.TEXTURE_2D_ARRAY || t.target == gl.TEXTURE_3D:
+		gl.FramebufferTextureLayer(gl.DRAW_FRAMEBUFFER, attachment, t.id, 0, int32(layer))
+	default:
+		glog.Fatalf("Cannot attach layer %d of a texture without layers", layer)
+	}
+}
+
 // SetDepthAttachment implements the core.RenderTarger interface
 func (f *Framebuffer) SetDepthAttachment(attachment core.Texture) {
+	f.SetDepthAttachmentLayer(attachment, -1)
+}
+
+// SetDepthAttachmentLayer implements the core.RenderTarger interface
+func (f *Framebuffer) SetDepthAttachmentLayer(attachment core.Texture, layer int) {
 	f.depthAttachment = attachment
+	f.depthLayer = layer
 
 	bindFramebuffer(f.fbo)
-	gl.FramebufferTexture(gl.DRAW_FRAMEBUFFER, gl.DEPTH_ATTACHMENT, f.depthAttachment.(*Texture).id, 0)
+	attachTexture(gl.DEPTH_ATTACHMENT, f.depthAttachment.(*Texture), layer)
 
 	validateCurrentFramebuffer()
 }
@@ -61,8 +84,14 @@ func (f *Framebuffer) DepthAttachment() core.Texture {
 
 // SetColorAttachment implements the core.RenderTarger interface
 func (f *Framebuffer) SetColorAttachment(index int, attachment core.Texture) {
+	f.SetColorAttachmentLayer(index, attachment, -1)
+}
+
+// SetColorAttachmentLayer implements the core.RenderTarger interface
+func (f *Framebuffer) SetColorAttachmentLayer(index int, attachment core.Texture, layer int) {
 	// set the attachment
 	f.colorAttachments[index] = attachment
+	f.colorLayers[index] = layer
 
 	bindFramebuffer(f.fbo)
 
@@ -70,7 +99,7 @@ func (f *Framebuffer) SetColorAttachment(index int, attachment core.Texture) {
 	drawBuffers := make([]uint32, len(f.colorAttachments))
 	for i := range f.colorAttachments {
 		drawBuffers[i] = uint32(gl.COLOR_ATTACHMENT0 + i)
-		gl.FramebufferTexture(gl.DRAW_FRAMEBUFFER, uint32(gl.COLOR_ATTACHMENT0+i), f.colorAttachments[i].(*Texture).id, 0)
+		attachTexture(uint32(gl.COLOR_ATTACHMENT0+i), f.colorAttachments[i].(*Texture), f.colorLayers[i])
 	}
 
 	if len(drawBuffers) > 0 {
@@ -92,6 +121,20 @@ func (f *Framebuffer) ColorAttachments() map[int]core.Texture {
 	return f.colorAttachments
 }
 
+// Delete implements the core.Framebuffer interface
+func (f *Framebuffer) Delete() {
+	if f.fbo == 0 {
+		return
+	}
+
+	if currentFBO == f.fbo {
+		bindFramebuffer(0)
+	}
+
+	gl.DeleteFramebuffers(1, &f.fbo)
+	f.fbo = 0
+}
+
 func bindFramebuffer(fbo uint32) {
 	if currentFBO == fbo {
 		return
@@ -0,0 +1,150 @@
+package opengl
+
+import (
+	"github.com/fcvarela/gosg/core"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/golang/glog"
+)
+
+// Per node uniforms are passed to instanced draws through a uniform block named nodeBlock, declared as arrays
+// indexed by gl_InstanceID:
+//
+//	layout (std140) uniform nodeBlock {
+//	    vec4 flatColors[64];
+//	};
+//
+//	flatColor = flatColors[gl_InstanceID];
+//
+// Each array is filled with the value of the node uniform of the same name, flatColors with
+// Node.SetUniform("flatColors", core.UniformVec4(color)), for every node of a batch. Batches larger than the shortest array are
+// drawn in several draw calls. Nodes without a value get zeros. The block must be bound with the program's
+// uniformBufferBindings. Uniforms outside the block are shared by the batch, nodes with different values are drawn
+// in separate batches.
+const instanceBlockName = "nodeBlock"
+
+// instanceBlock describes the layout of a program's per instance uniform block
+type instanceBlock struct {
+	binding  uint32
+	size     int
+	capacity int
+	members  map[string]instanceMember
+}
+
+// instanceMember is an array of per instance values in an instanceBlock
+type instanceMember struct {
+	typ          core.UniformType
+	offset       int
+	arrayStride  int
+	matrixStride int
+}
+
+var (
+	// instanceBuffer holds the per instance uniforms of the batch being drawn
+	instanceBuffer *UniformBuffer
+
+	// instanceData is reused to build the contents of instanceBuffer
+	instanceData []byte
+)
+
+// extractInstanceBlock builds the program's instance block from its reflected layout, leaving it nil if the
+// program has none
+func (p *Program) extractInstanceBlock() {
+	reflected, ok := p.uniformBlocks[instanceBlockName]
+	if !ok || len(reflected.Members) == 0 {
+		return
+	}
+
+	binding, ok := p.uniformBufferBindings[instanceBlockName]
+	if !ok {
+		glog.Warningf("Program %s declares %s without a uniform buffer binding, per node uniforms are ignored", p.name, instanceBlockName)
+		return
+	}
+
+	block := &instanceBlock{binding: binding, size: reflected.Size, members: make(map[string]instanceMember)}
+	for _, m := range reflected.Members {
+		if m.ArrayStride == 0 || m.Type == core.UniformTypeNone {
+			glog.Warningf("Program %s: %s.%s must be an array of floats, ints, vectors or matrices, ignoring it", p.name, instanceBlockName, m.Name)
+			continue
+		}
+
+		block.members[m.Name] = instanceMember{m.Type, m.Offset, m.ArrayStride, m.MatrixStride}
+		p.uniforms[m.Name] = core.UniformInfo{Name: m.Name, Type: m.Type, Size: m.Size, PerInstance: true}
+		if block.capacity == 0 || m.Size < block.capacity {
+			block.capacity = m.Size
+		}
+	}
+
+	if len(block.members) > 0 {
+		p.instanceBlock = block
+	}
+}
+
+// instanceCapacity returns the maximum number of nodes drawn per draw call, 0 for no limit
+func (p *Program) instanceCapacity() int {
+	if p.instanceBlock == nil {
+		return 0
+	}
+	return p.instanceBlock.capacity
+}
+
+// setInstanceUniforms uploads the per instance uniforms of nodes and binds them to the program's instance block
+func (p *Program) setInstanceUniforms(nodes []*core.Node) {
+	block := p.instanceBlock
+	if block == nil {
+		return
+	}
+
+	if cap(instanceData) < block.size {
+		instanceData = make([]byte, block.size)
+	}
+	data := instanceData[:block.size]
+	for i := range data {
+		data[i] = 0
+	}
+
+	for i, n := range nodes {
+		uniforms := n.MaterialData().Uniforms()
+		for name, member := range block.members {
+			if u, ok := uniforms[name]; ok && u.Value().Len() > 0 {
+				member.write(data, i, u.Value())
+			}
+		}
+	}
+
+	instanceBuffer.Set(core.Std140Buffer(data).Pointer(), len(data))
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, block.binding, instanceBuffer.id)
+}
+
+// write encodes the first element of a uniform value as element i of the member. Values of other types are left
+// zero, values set through core are validated against the member's type.
+func (m instanceMember) write(dst []byte, i int, v core.UniformValue) {
+	if v.Type() != m.typ {
+		return
+	}
+
+	field := core.Std140Field{Type: m.typ, Offset: m.offset + i*m.arrayStride, MatrixStride: m.matrixStride}
+	core.Std140Buffer(dst).SetValue(field, v)
+}
+
+// sharedUniformsDiffer returns whether two nodes set different values for uniforms of the program which are not
+// per instance, and so can't be drawn in one batch
+func (p *Program) sharedUniformsDiffer(a, b *core.MaterialData) bool {
+	ua, ub := a.Uniforms(), b.Uniforms()
+	if len(ua) == 0 && len(ub) == 0 {
+		return false
+	}
+
+	for name, location := range p.uniformLocations {
+		if location < 0 {
+			continue
+		}
+		va, vb := ua[name], ub[name]
+		if va == nil && vb == nil {
+			continue
+		}
+		if va == nil || vb == nil || !va.Value().Equal(vb.Value()) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package opengl
 
 import (
+	"sort"
 	"unsafe"
 
 	"github.com/fcvarela/gosg/core"
@@ -20,30 +21,141 @@ const (
 	modelMatrixBuffer
 )
 
+// vertexSize is the size of a vertex in each attribute buffer, every attribute is a vec3 of floats
+const vertexSize = 3 * 4
+
+// span is a range of elements in a shared buffer
+type span struct {
+	offset, count int
+}
+
+// allocator hands out ranges of a shared buffer. Released ranges are coalesced and reused first fit, the buffer
+// only grows when none is large enough.
+type allocator struct {
+	capacity int
+	end      int
+	free     []span
+}
+
+// alloc returns a range of count elements
+func (a *allocator) alloc(count int) span {
+	for i, f := range a.free {
+		if f.count < count {
+			continue
+		}
+		if f.count == count {
+			a.free = append(a.free[:i], a.free[i+1:]...)
+		} else {
+			a.free[i] = span{f.offset + count, f.count - count}
+		}
+		return span{f.offset, count}
+	}
+
+	s := span{a.end, count}
+	a.end += count
+	return s
+}
+
+// release returns a range to the allocator
+func (a *allocator) release(s span) {
+	if s.count == 0 {
+		return
+	}
+
+	// keep the free list sorted so neighbours can be merged
+	i := sort.Search(len(a.free), func(i int) bool { return a.free[i].offset > s.offset })
+	a.free = append(a.free, span{})
+	copy(a.free[i+1:], a.free[i:])
+	a.free[i] = s
+
+	if i+1 < len(a.free) && a.free[i].offset+a.free[i].count == a.free[i+1].offset {
+		a.free[i].count += a.free[i+1].count
+		a.free = append(a.free[:i+1], a.free[i+2:]...)
+	}
+	if i > 0 && a.free[i-1].offset+a.free[i-1].count == a.free[i].offset {
+		a.free[i-1].count += a.free[i].count
+		a.free = append(a.free[:i], a.free[i+1:]...)
+	}
+
+	// give back the tail so the next allocation past it doesn't leave a hole
+	if last := a.free[len(a.free)-1]; last.offset+last.count == a.end {
+		a.end = last.offset
+		a.free = a.free[:len(a.free)-1]
+	}
+}
+
 type buffers struct {
-	vao           uint32
-	buffers       []uint32
-	bufferOffsets []int
+	vao      uint32
+	buffers  []uint32
+	vertices allocator
+	indices  allocator
 }
 
 var (
 	currentVAO = uint32(0)
 )
 
-func (b *buffers) addData(target uint32, buffer bufferType, datalen int, buf unsafe.Pointer) {
-	if b.bufferOffsets[buffer] == 0 {
-		gl.BindBuffer(target, b.buffers[buffer])
-		gl.BufferData(target, datalen, buf, gl.STATIC_DRAW)
-	} else {
-		// cpu copy: get existing data, alloc new space for everything, add old data, new data
-		cpuBuf := make([]byte, b.bufferOffsets[buffer])
-		gl.BindBuffer(target, b.buffers[buffer])
-		gl.GetBufferSubData(target, 0, b.bufferOffsets[buffer], unsafe.Pointer(&cpuBuf[0]))
-		gl.BufferData(target, datalen+b.bufferOffsets[buffer], nil, gl.STATIC_DRAW)
-		gl.BufferSubData(target, 0, b.bufferOffsets[buffer], unsafe.Pointer(&cpuBuf[0]))
-		gl.BufferSubData(target, b.bufferOffsets[buffer], datalen, buf)
+// resize grows a buffer to size bytes, keeping the first used bytes
+func (b *buffers) resize(target uint32, buffer bufferType, used, size int) {
+	gl.BindBuffer(target, b.buffers[buffer])
+	if used == 0 {
+		gl.BufferData(target, size, nil, gl.STATIC_DRAW)
+		return
 	}
-	b.bufferOffsets[buffer] += datalen
+
+	// cpu copy: get existing data, alloc new space for everything, add old data
+	cpuBuf := make([]byte, used)
+	gl.GetBufferSubData(target, 0, used, unsafe.Pointer(&cpuBuf[0]))
+	gl.BufferData(target, size, nil, gl.STATIC_DRAW)
+	gl.BufferSubData(target, 0, used, unsafe.Pointer(&cpuBuf[0]))
+}
+
+// allocVertices returns a range of count vertices in every attribute buffer. The previous range is kept if it has
+// the same size, otherwise it is released.
+func (b *buffers) allocVertices(previous span, count int) span {
+	if previous.count == count {
+		return previous
+	}
+	b.vertices.release(previous)
+
+	s := b.vertices.alloc(count)
+	if b.vertices.end > b.vertices.capacity {
+		capacity := 2 * b.vertices.capacity
+		if capacity < b.vertices.end {
+			capacity = b.vertices.end
+		}
+		for buffer := positionBuffer; buffer <= texCoordBuffer; buffer++ {
+			b.resize(gl.ARRAY_BUFFER, buffer, b.vertices.capacity*vertexSize, capacity*vertexSize)
+		}
+		b.vertices.capacity = capacity
+	}
+	return s
+}
+
+// allocIndices returns a range of count indices in the index buffer. The previous range is kept if it has the
+// same size, otherwise it is released.
+func (b *buffers) allocIndices(previous span, count int) span {
+	if previous.count == count {
+		return previous
+	}
+	b.indices.release(previous)
+
+	s := b.indices.alloc(count)
+	if b.indices.end > b.indices.capacity {
+		capacity := 2 * b.indices.capacity
+		if capacity < b.indices.end {
+			capacity = b.indices.end
+		}
+		b.resize(gl.ELEMENT_ARRAY_BUFFER, indexBuffer, b.indices.capacity*2, capacity*2)
+		b.indices.capacity = capacity
+	}
+	return s
+}
+
+// setData copies data into a buffer at the given byte offset
+func (b *buffers) setData(target uint32, buffer bufferType, offset, datalen int, buf unsafe.Pointer) {
+	gl.BindBuffer(target, b.buffers[buffer])
+	gl.BufferSubData(target, offset, datalen, buf)
 }
 
 func newBuffers() *buffers {
@@ -54,7 +166,6 @@ func newBuffers() *buffers {
 
 	// create buffers
 	bf.buffers = make([]uint32, modelMatrixBuffer+1)
-	bf.bufferOffsets = make([]int, modelMatrixBuffer+1)
 
 	// initialize gl buffer handles
 	gl.GenBuffers(int32(len(bf.buffers)), &bf.buffers[0])
@@ -122,6 +233,8 @@ var (
 // Mesh implements the core.Mesh interface
 type Mesh struct {
 	buffers           *buffers
+	vertices          span
+	indexSpan         span
 	indexcount        int32
 	indexOffset       int32
 	indexBufferOffset int32
@@ -129,6 +242,15 @@ type Mesh struct {
 	name              string
 	bounds            *core.AABB
 	primitiveType     uint32
+	corePrimitiveType core.PrimitiveType
+
+	// cpu side copies, kept for exporters and tools
+	positions  []float32
+	normals    []float32
+	tangents   []float32
+	bitangents []float32
+	texcoords  []float32
+	indices    []uint16
 }
 
 // IMGUIMesh implements the core.IMGUIMesh interface
@@ -161,6 +283,7 @@ func bindVAO(vao uint32) {
 
 // SetPrimitiveType implements the core.Mesh interface
 func (m *Mesh) SetPrimitiveType(t core.PrimitiveType) {
+	m.corePrimitiveType = t
 	switch t {
 	case core.PrimitiveTypeTriangles:
 		m.primitiveType = gl.TRIANGLES
@@ -173,6 +296,11 @@ func (m *Mesh) SetPrimitiveType(t core.PrimitiveType) {
 	}
 }
 
+// PrimitiveType implements the core.Mesh interface
+func (m *Mesh) PrimitiveType() core.PrimitiveType {
+	return m.corePrimitiveType
+}
+
 // Bounds implements the core.Mesh interface
 func (m *Mesh) Bounds() *core.AABB {
 	return m.bounds
@@ -188,11 +316,27 @@ func (m *Mesh) Name() string {
 	return m.name
 }
 
+// setVertexData copies an attribute into the mesh's vertex range, allocating it on first use. Empty attributes,
+// such as the tangents of models without them, keep the range and are zeroed in it.
+func (m *Mesh) setVertexData(buffer bufferType, data []float32) {
+	if len(data) == 0 {
+		if m.vertices.count > 0 {
+			zeros := make([]float32, m.vertices.count*3)
+			m.buffers.setData(gl.ARRAY_BUFFER, buffer, m.vertices.offset*vertexSize, len(zeros)*4, gl.Ptr(zeros))
+		}
+		return
+	}
+
+	// the index offset is the first vertex of our range
+	m.vertices = m.buffers.allocVertices(m.vertices, len(data)/3)
+	m.indexOffset = int32(m.vertices.offset)
+	m.buffers.setData(gl.ARRAY_BUFFER, buffer, m.vertices.offset*vertexSize, len(data)*4, gl.Ptr(data))
+}
+
 // SetPositions implements the core.Mesh interface
 func (m *Mesh) SetPositions(positions []float32) {
-	// the index offset is equal to the number of primitives already in the position buffer
-	m.indexOffset = int32(m.buffers.bufferOffsets[positionBuffer]) / (4 * 3)
-	m.buffers.addData(gl.ARRAY_BUFFER, positionBuffer, len(positions)*4, gl.Ptr(positions))
+	m.positions = positions
+	m.setVertexData(positionBuffer, positions)
 
 	// grow our bounds
 	for i := 0; i < len(positions); i += 3 {
@@ -206,30 +350,78 @@ func (m *Mesh) SetPositions(positions []float32) {
 
 // SetNormals implements the core.Mesh interface
 func (m *Mesh) SetNormals(normals []float32) {
-	m.buffers.addData(gl.ARRAY_BUFFER, normalBuffer, len(normals)*4, gl.Ptr(normals))
+	m.normals = normals
+	m.setVertexData(normalBuffer, normals)
 }
 
 // SetTangents implements the core.Mesh interface
 func (m *Mesh) SetTangents(tangents []float32) {
-	m.buffers.addData(gl.ARRAY_BUFFER, tangentBuffer, len(tangents)*4, gl.Ptr(tangents))
+	m.tangents = tangents
+	m.setVertexData(tangentBuffer, tangents)
 }
 
 // SetBitangents implements the core.Mesh interface
 func (m *Mesh) SetBitangents(bitangents []float32) {
-	m.buffers.addData(gl.ARRAY_BUFFER, bitangentBuffer, len(bitangents)*4, gl.Ptr(bitangents))
+	m.bitangents = bitangents
+	m.setVertexData(bitangentBuffer, bitangents)
 }
 
 // SetTextureCoordinates implements the core.Mesh interface
 func (m *Mesh) SetTextureCoordinates(texcoords []float32) {
-	m.buffers.addData(gl.ARRAY_BUFFER, texCoordBuffer, len(texcoords)*4, gl.Ptr(texcoords))
+	m.texcoords = texcoords
+	m.setVertexData(texCoordBuffer, texcoords)
 }
 
 // SetIndices implements the core.Mesh interface
 func (m *Mesh) SetIndices(indices []uint16) {
-	m.indexBufferOffset = int32(m.buffers.bufferOffsets[indexBuffer])
+	m.indices = indices
+	m.indexSpan = m.buffers.allocIndices(m.indexSpan, len(indices))
+	m.indexBufferOffset = int32(m.indexSpan.offset * 2)
 	m.indexcount = int32(len(indices))
 
-	m.buffers.addData(gl.ELEMENT_ARRAY_BUFFER, indexBuffer, len(indices)*2, gl.Ptr(indices))
+	if len(indices) > 0 {
+		m.buffers.setData(gl.ELEMENT_ARRAY_BUFFER, indexBuffer, int(m.indexBufferOffset), len(indices)*2, gl.Ptr(indices))
+	}
+}
+
+// Delete implements the core.Mesh interface
+func (m *Mesh) Delete() {
+	m.buffers.vertices.release(m.vertices)
+	m.buffers.indices.release(m.indexSpan)
+	m.vertices, m.indexSpan = span{}, span{}
+	m.indexcount = 0
+
+	m.positions, m.normals, m.tangents, m.bitangents, m.texcoords, m.indices = nil, nil, nil, nil, nil, nil
+}
+
+// Positions implements the core.Mesh interface
+func (m *Mesh) Positions() []float32 {
+	return m.positions
+}
+
+// Normals implements the core.Mesh interface
+func (m *Mesh) Normals() []float32 {
+	return m.normals
+}
+
+// Tangents implements the core.Mesh interface
+func (m *Mesh) Tangents() []float32 {
+	return m.tangents
+}
+
+// Bitangents implements the core.Mesh interface
+func (m *Mesh) Bitangents() []float32 {
+	return m.bitangents
+}
+
+// TextureCoordinates implements the core.Mesh interface
+func (m *Mesh) TextureCoordinates() []float32 {
+	return m.texcoords
+}
+
+// Indices implements the core.Mesh interface
+func (m *Mesh) Indices() []uint16 {
+	return m.indices
 }
 
 // Draw implements the core.Mesh interface
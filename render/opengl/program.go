@@ -2,15 +2,14 @@ package opengl
 
 import (
 	"encoding/json"
-	"reflect"
+	"fmt"
 	"runtime"
+	"sort"
 	"strings"
+	"unsafe"
 
 	"github.com/fcvarela/gosg/core"
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
-	"github.com/go-gl/mathgl/mgl64"
-	"github.com/golang/glog"
 )
 
 // Program is an OpenGL program
@@ -19,26 +18,56 @@ type Program struct {
 	id                    uint32
 	shaders               map[string]*shader
 	compileLog            string
-	uniformLog            string
+	linkLog               string
 	uniformLocations      map[string]int32
+	uniforms              map[string]core.UniformInfo
+	samplerUniforms       map[string]bool
 	uniformBlockIndexes   map[string]uint32
+	uniformBlocks         map[string]core.UniformBlockInfo
+	attributes            []core.AttributeInfo
 	uniformBufferBindings map[string]uint32
 	samplerBindings       map[string]uint32
 	dirtySamplerBindings  bool
+	instanceBlock         *instanceBlock
 }
 
-// programSpec is the struct we get as bytes on calls to NewProgram
+// programSpec is the struct we get as bytes on calls to NewProgram. Keywords are the keywords variants of the
+// program may be compiled with.
 type programSpec struct {
 	Shaders               map[string]string `json:"shaders"`
+	Keywords              []string          `json:"keywords"`
 	UniformBufferBindings map[string]uint32 `json:"uniformBufferBindings"`
 	SamplerBindings       map[string]uint32 `json:"samplerBindings"`
 }
 
-func programCleanup(p *Program) {
-	glog.Infof("Finalizer called for program: %v\n", p)
-}
-
 var (
+	// uniformTypeMap maps GL uniform types to the types they are set with, samplers are set with their texture unit
+	uniformTypeMap = map[uint32]core.UniformType{
+		gl.FLOAT:             core.UniformTypeFloat,
+		gl.FLOAT_VEC2:        core.UniformTypeVec2,
+		gl.FLOAT_VEC3:        core.UniformTypeVec3,
+		gl.FLOAT_VEC4:        core.UniformTypeVec4,
+		gl.FLOAT_MAT3:        core.UniformTypeMat3,
+		gl.FLOAT_MAT4:        core.UniformTypeMat4,
+		gl.INT:               core.UniformTypeInt,
+		gl.BOOL:              core.UniformTypeInt,
+		gl.UNSIGNED_INT:      core.UniformTypeUint,
+		gl.SAMPLER_2D:        core.UniformTypeInt,
+		gl.SAMPLER_3D:        core.UniformTypeInt,
+		gl.SAMPLER_CUBE:      core.UniformTypeInt,
+		gl.SAMPLER_2D_ARRAY:  core.UniformTypeInt,
+		gl.SAMPLER_2D_SHADOW: core.UniformTypeInt,
+	}
+
+	// samplerTypes are the GL uniform types which are bound to texture units
+	samplerTypes = map[uint32]bool{
+		gl.SAMPLER_2D:        true,
+		gl.SAMPLER_3D:        true,
+		gl.SAMPLER_CUBE:      true,
+		gl.SAMPLER_2D_ARRAY:  true,
+		gl.SAMPLER_2D_SHADOW: true,
+	}
+
 	programTypeMap = map[string]uint32{
 		"compute":            gl.COMPUTE_SHADER,
 		"tesselationControl": gl.TESS_CONTROL_SHADER,
@@ -49,16 +78,32 @@ var (
 	}
 )
 
+// ProgramStages returns the shader stage keys of program specifications, sorted
+func ProgramStages() []string {
+	stages := make([]string, 0, len(programTypeMap))
+	for stage := range programTypeMap {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	return stages
+}
+
 // ProgramExtension implements the core.RenderSystem interface.
 func (r *RenderSystem) ProgramExtension() string {
 	return "gl.json"
 }
 
 // NewProgram implements the core.RenderSystem interface.
-func (r *RenderSystem) NewProgram(name string, data []byte) core.Program {
+func (r *RenderSystem) NewProgram(name string, data []byte, keywords []string) (core.Program, error) {
 	var spec programSpec
 	if err := json.Unmarshal(data, &spec); err != nil {
-		glog.Fatal("Error reading program spec: ", err)
+		return nil, fmt.Errorf("error reading program spec %s: %v", name, err)
+	}
+
+	for _, k := range keywords {
+		if !containsString(spec.Keywords, k) {
+			return nil, fmt.Errorf("program %s: keyword %s is not declared, expected one of %v", name, k, spec.Keywords)
+		}
 	}
 
 	// create program
@@ -69,16 +114,29 @@ func (r *RenderSystem) NewProgram(name string, data []byte) core.Program {
 		"",
 		"",
 		make(map[string]int32),
+		make(map[string]core.UniformInfo),
+		make(map[string]bool),
 		make(map[string]uint32),
+		make(map[string]core.UniformBlockInfo),
+		nil,
 		make(map[string]uint32),
 		make(map[string]uint32),
 		false,
+		nil,
 	}
 
 	// set shaders
 	for k, v := range spec.Shaders {
-		source := core.GetResourceManager().ProgramData(v)
-		prog.shaders[k] = newShader(k, programTypeMap[k], source)
+		stageType, ok := programTypeMap[k]
+		if !ok {
+			return nil, fmt.Errorf("program %s: unknown shader stage %s", name, k)
+		}
+
+		source, err := core.GetResourceManager().PreprocessShader(v, keywords)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %s shader of program %s: %v", k, name, err)
+		}
+		prog.shaders[k] = newShader(k, stageType, source)
 	}
 
 	// set ubo bindings
@@ -94,31 +152,35 @@ func (r *RenderSystem) NewProgram(name string, data []byte) core.Program {
 
 	for _, s := range prog.shaders {
 		if s != nil {
-			s.compile()
+			if err := s.compile(); err != nil {
+				prog.deleteShaders()
+				prog.Delete()
+				return nil, fmt.Errorf("program %s: %v", name, err)
+			}
 			gl.AttachShader(prog.id, s.id)
 		}
 	}
+	prog.compileLog = prog.shaderLogs()
 
 	gl.LinkProgram(prog.id)
-	status := int32(0)
-	gl.GetProgramiv(prog.id, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		logLength := int32(0)
-		gl.GetProgramiv(prog.id, gl.INFO_LOG_LENGTH, &logLength)
-
+	logLength := int32(0)
+	gl.GetProgramiv(prog.id, gl.INFO_LOG_LENGTH, &logLength)
+	if logLength > 0 {
 		progLog := strings.Repeat("\x00", int(logLength+1))
 		gl.GetProgramInfoLog(prog.id, logLength, nil, gl.Str(progLog))
-		prog.compileLog = progLog
-
-		glog.Fatalf("failed to link program: %v", progLog)
+		prog.linkLog = strings.TrimSpace(gl.GoStr(gl.Str(progLog)))
 	}
 
-	for _, s := range prog.shaders {
-		if s != nil {
-			gl.DeleteShader(s.id)
-		}
+	status := int32(0)
+	gl.GetProgramiv(prog.id, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		prog.deleteShaders()
+		prog.Delete()
+		return nil, fmt.Errorf("failed to link program %s: %v", name, prog.linkLog)
 	}
 
+	prog.deleteShaders()
+
 	// populate uniform name map
 	prog.extractUniformNames()
 
@@ -132,6 +194,15 @@ func (r *RenderSystem) NewProgram(name string, data []byte) core.Program {
 		}
 	}
 
+	// reflect uniform block layouts, after binding so they report their binding points
+	prog.extractUniformBlocks()
+
+	// reflect vertex inputs
+	prog.extractAttributes()
+
+	// reflect the per instance uniform block, if any
+	prog.extractInstanceBlock()
+
 	// bind samplers to textureunits, this requires the program to be active
 	for name, textureUnit := range spec.SamplerBindings {
 		prog.samplerBindings[name] = textureUnit
@@ -139,7 +210,44 @@ func (r *RenderSystem) NewProgram(name string, data []byte) core.Program {
 
 	prog.dirtySamplerBindings = true
 
-	return &prog
+	return &prog, nil
+}
+
+// containsString returns whether list contains s
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// shaderLogs returns the compile logs of the program's shaders, prefixed by their stage
+func (p *Program) shaderLogs() string {
+	stages := make([]string, 0, len(p.shaders))
+	for k, s := range p.shaders {
+		if s != nil && s.log != "" {
+			stages = append(stages, k)
+		}
+	}
+	sort.Strings(stages)
+
+	logs := make([]string, len(stages))
+	for i, k := range stages {
+		logs[i] = fmt.Sprintf("%s: %s", k, p.shaders[k].log)
+	}
+	return strings.Join(logs, "\n")
+}
+
+// deleteShaders deletes the program's shader objects, which are no longer needed once it is linked
+func (p *Program) deleteShaders() {
+	for _, s := range p.shaders {
+		if s != nil && s.id != 0 {
+			gl.DeleteShader(s.id)
+			s.id = 0
+		}
+	}
 }
 
 func (p *Program) extractUniformNames() {
@@ -158,9 +266,17 @@ func (p *Program) extractUniformNames() {
 		// extract location
 		location := gl.GetUniformLocation(p.id, gl.Str(uniformName))
 
-		// save into location map
-		goUniformname := gl.GoStr(gl.Str(uniformName))
+		// save into location map, arrays are reported by their first element
+		goUniformname := strings.TrimSuffix(gl.GoStr(gl.Str(uniformName)), "[0]")
 		p.uniformLocations[goUniformname] = location
+
+		// uniforms in blocks have no location, they are set through uniform buffers
+		if location >= 0 {
+			p.uniforms[goUniformname] = core.UniformInfo{Name: goUniformname, Type: uniformTypeMap[uniformType], Size: int(uniformSize)}
+			if samplerTypes[uniformType] {
+				p.samplerUniforms[goUniformname] = true
+			}
+		}
 	}
 }
 
@@ -182,62 +298,195 @@ func (p *Program) extractUniformBlockIndexes() {
 	}
 }
 
+// extractUniformBlocks reflects the layout of the program's uniform blocks
+func (p *Program) extractUniformBlocks() {
+	for name, index := range p.uniformBlockIndexes {
+		var binding, size, count int32
+		gl.GetActiveUniformBlockiv(p.id, index, gl.UNIFORM_BLOCK_BINDING, &binding)
+		gl.GetActiveUniformBlockiv(p.id, index, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+		gl.GetActiveUniformBlockiv(p.id, index, gl.UNIFORM_BLOCK_ACTIVE_UNIFORMS, &count)
+
+		block := core.UniformBlockInfo{Name: name, Binding: uint32(binding), Size: int(size)}
+		if count == 0 {
+			p.uniformBlocks[name] = block
+			continue
+		}
+
+		activeIndices := make([]int32, count)
+		gl.GetActiveUniformBlockiv(p.id, index, gl.UNIFORM_BLOCK_ACTIVE_UNIFORM_INDICES, &activeIndices[0])
+		indices := make([]uint32, count)
+		for i, idx := range activeIndices {
+			indices[i] = uint32(idx)
+		}
+
+		query := func(pname uint32) []int32 {
+			values := make([]int32, count)
+			gl.GetActiveUniformsiv(p.id, count, &indices[0], pname, &values[0])
+			return values
+		}
+		offsets, arrayStrides, matrixStrides := query(gl.UNIFORM_OFFSET), query(gl.UNIFORM_ARRAY_STRIDE), query(gl.UNIFORM_MATRIX_STRIDE)
+		sizes, types := query(gl.UNIFORM_SIZE), query(gl.UNIFORM_TYPE)
+
+		for i, idx := range indices {
+			nameBuf := strings.Repeat("\x00", 128+1)
+			var nameLen int32
+			gl.GetActiveUniformName(p.id, idx, 128, &nameLen, gl.Str(nameBuf))
+
+			block.Members = append(block.Members, core.UniformBlockMember{
+				Name:         strings.TrimSuffix(gl.GoStr(gl.Str(nameBuf)), "[0]"),
+				Type:         uniformTypeMap[uint32(types[i])],
+				Size:         int(sizes[i]),
+				Offset:       int(offsets[i]),
+				ArrayStride:  int(arrayStrides[i]),
+				MatrixStride: int(matrixStrides[i]),
+			})
+		}
+		sort.Slice(block.Members, func(i, j int) bool { return block.Members[i].Offset < block.Members[j].Offset })
+
+		p.uniformBlocks[name] = block
+	}
+}
+
+// extractAttributes reflects the program's vertex inputs. Built in inputs such as gl_VertexID are left out.
+func (p *Program) extractAttributes() {
+	var attributeCount int32
+	gl.GetProgramiv(p.id, gl.ACTIVE_ATTRIBUTES, &attributeCount)
+
+	for i := uint32(0); i < uint32(attributeCount); i++ {
+		attributeName := strings.Repeat("\x00", int(128)+1)
+		var attributeLen int32
+		var attributeSize int32
+		var attributeType uint32
+
+		gl.GetActiveAttrib(p.id, i, 128, &attributeLen, &attributeSize, &attributeType, gl.Str(attributeName))
+		location := gl.GetAttribLocation(p.id, gl.Str(attributeName))
+		if location < 0 {
+			continue
+		}
+
+		p.attributes = append(p.attributes, core.AttributeInfo{
+			Name:     strings.TrimSuffix(gl.GoStr(gl.Str(attributeName)), "[0]"),
+			Type:     uniformTypeMap[attributeType],
+			Size:     int(attributeSize),
+			Location: int(location),
+		})
+	}
+	sort.Slice(p.attributes, func(i, j int) bool { return p.attributes[i].Name < p.attributes[j].Name })
+}
+
+// Delete implements the core.Program interface
+func (p *Program) Delete() {
+	if p.id == 0 {
+		return
+	}
+
+	if currentProgram == p.id {
+		currentProgram = 0
+	}
+
+	gl.DeleteProgram(p.id)
+	p.id = 0
+}
+
 func (p *Program) bind() {
 	gl.UseProgram(p.id)
+	currentProgram = p.id
 
 	if p.dirtySamplerBindings {
 		for name, textureUnit := range p.samplerBindings {
-			p.setUniform(name, &Uniform{textureUnit})
+			p.setUniform(name, &Uniform{core.UniformInt(int32(textureUnit))})
 		}
 		p.dirtySamplerBindings = false
 	}
 }
 
+// setUniform sets a uniform of the default block. Values are validated when assigned through core, values set
+// without validation are passed as is and mismatches are reported by the driver.
 func (p *Program) setUniform(name string, u *Uniform) {
-	if u.value == nil {
+	v := u.Value()
+	if v.Len() == 0 {
 		return
 	}
 
 	uloc, found := p.uniformLocations[name]
-	if !found {
+	if !found || uloc < 0 {
 		return
 	}
 
-	switch uval := u.Value().(type) {
-	case mgl32.Mat4:
-		gl.UniformMatrix4fv(uloc, 1, false, &uval[0])
-	case mgl64.Mat4:
-		newval := core.Mat4DoubleToFloat(uval)
-		gl.UniformMatrix4fv(uloc, 1, false, &newval[0])
-	case mgl64.Vec4:
-		newval := core.Vec4DoubleToFloat(uval)
-		gl.Uniform4fv(uloc, 1, &newval[0])
-	case mgl64.Vec3:
-		newval := core.Vec3DoubleToFloat(uval)
-		gl.Uniform3fv(uloc, 1, &newval[0])
-	case mgl64.Vec2:
-		newval := core.Vec2DoubleToFloat(uval)
-		gl.Uniform2fv(uloc, 1, &newval[0])
-	case []float32:
-		gl.Uniform1fv(uloc, int32(len(uval)), &uval[0])
-	case []mgl32.Vec2:
-		newval := make([]float32, len(uval)*2)
-		for i := 0; i < len(uval); i++ {
-			newval[i*2+0] = uval[i].X()
-			newval[i*2+1] = uval[i].Y()
+	count := int32(v.Len())
+	switch v.Type() {
+	case core.UniformTypeFloat:
+		gl.Uniform1fv(uloc, count, &v.Floats()[0])
+	case core.UniformTypeVec2:
+		gl.Uniform2fv(uloc, count, &v.Floats()[0])
+	case core.UniformTypeVec3:
+		gl.Uniform3fv(uloc, count, &v.Floats()[0])
+	case core.UniformTypeVec4:
+		gl.Uniform4fv(uloc, count, &v.Floats()[0])
+	case core.UniformTypeMat3:
+		gl.UniformMatrix3fv(uloc, count, false, &v.Floats()[0])
+	case core.UniformTypeMat4:
+		gl.UniformMatrix4fv(uloc, count, false, &v.Floats()[0])
+	case core.UniformTypeInt:
+		gl.Uniform1iv(uloc, count, &v.Ints()[0])
+	case core.UniformTypeUint:
+		gl.Uniform1uiv(uloc, count, (*uint32)(unsafe.Pointer(&v.Ints()[0])))
+	}
+}
+
+// Uniform implements the core.Program interface
+func (p *Program) Uniform(name string) (core.UniformInfo, bool) {
+	u, ok := p.uniforms[name]
+	return u, ok
+}
+
+// Uniforms implements the core.Program interface
+func (p *Program) Uniforms() []core.UniformInfo {
+	uniforms := make([]core.UniformInfo, 0, len(p.uniforms))
+	for _, u := range p.uniforms {
+		uniforms = append(uniforms, u)
+	}
+	sort.Slice(uniforms, func(i, j int) bool { return uniforms[i].Name < uniforms[j].Name })
+	return uniforms
+}
+
+// UniformBlocks implements the core.Program interface
+func (p *Program) UniformBlocks() []core.UniformBlockInfo {
+	blocks := make([]core.UniformBlockInfo, 0, len(p.uniformBlocks))
+	for _, b := range p.uniformBlocks {
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Name < blocks[j].Name })
+	return blocks
+}
+
+// Samplers implements the core.Program interface
+func (p *Program) Samplers() []core.SamplerInfo {
+	samplers := make([]core.SamplerInfo, 0, len(p.samplerUniforms))
+	for name := range p.samplerUniforms {
+		unit := -1
+		if textureUnit, ok := p.samplerBindings[name]; ok {
+			unit = int(textureUnit)
 		}
-		gl.Uniform2fv(uloc, int32(len(uval)), &newval[0])
-	case int:
-		gl.Uniform1i(uloc, int32(uval))
-	case uint32:
-		gl.Uniform1i(uloc, int32(uval))
-	case float32:
-		gl.Uniform1f(uloc, uval)
-	case float64:
-		gl.Uniform1f(uloc, float32(uval))
-	default:
-		glog.Fatalf("UNSUPPORTED -- Uniform: %s Type: %s\n", name, reflect.TypeOf(u.Value()))
+		samplers = append(samplers, core.SamplerInfo{Name: name, Unit: unit})
 	}
+	sort.Slice(samplers, func(i, j int) bool { return samplers[i].Name < samplers[j].Name })
+	return samplers
+}
+
+// Attributes implements the core.Program interface
+func (p *Program) Attributes() []core.AttributeInfo {
+	return append([]core.AttributeInfo(nil), p.attributes...)
+}
+
+// CompileLog implements the core.Program interface
+func (p *Program) CompileLog() string {
+	return p.compileLog
+}
+
+// LinkLog implements the core.Program interface
+func (p *Program) LinkLog() string {
+	return p.linkLog
 }
 
 func (p *Program) setUniformBufferByName(name string, ub *UniformBuffer) {
@@ -256,7 +505,7 @@ func (p *Program) setTexture(name string, texture *Texture) {
 	textureUnit := p.samplerBindings[name]
 	if textureUnitBindings[textureUnit] != texture.id {
 		gl.ActiveTexture(gl.TEXTURE0 + textureUnit)
-		gl.BindTexture(gl.TEXTURE_2D, texture.id)
+		gl.BindTexture(texture.target, texture.id)
 		textureUnitBindings[textureUnit] = texture.id
 	}
 }
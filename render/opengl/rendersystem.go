@@ -8,7 +8,8 @@ import (
 
 // RenderSystem implements the core.RenderSystem interface
 type RenderSystem struct {
-	renderLog string
+	renderLog         string
+	compressedFormats map[core.TextureSizedFormat]bool
 }
 
 func init() {
@@ -38,6 +39,11 @@ func (r *RenderSystem) Start() {
 	// generate basic mesh buffers
 	sharedBuffers = newBuffers()
 	imguiBuffers = newBuffers()
+	instanceBuffer = r.NewUniformBuffer().(*UniformBuffer)
+
+	// texel rows are tightly packed, and compressed formats depend on extensions
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	r.compressedFormats = queryCompressedFormats()
 }
 
 // Stop implements the core.RenderSystem interface
@@ -86,6 +92,8 @@ type RenderBatch struct {
 
 // ExecuteRenderPlan implements the core.RenderSystem interface
 func (r *RenderSystem) ExecuteRenderPlan(p core.RenderPlan) {
+	deleteLeaked()
+
 	for _, stage := range p.Stages {
 		//r.renderLog += fmt.Sprintf("RenderStage: %s\n", stage.Name)
 		r.prepareRenderTarget(stage.Camera)
@@ -93,12 +101,13 @@ func (r *RenderSystem) ExecuteRenderPlan(p core.RenderPlan) {
 		for _, pass := range stage.Passes {
 			//r.renderLog += fmt.Sprintf("\tRenderPass: %s\n", pass.Name)
 			program := bindMaterialState(stage.Camera.Constants().UniformBuffer(), pass.State, false)
+			bindCameraTextures(program, stage.Camera.Constants())
 
 			var renderBatches []RenderBatch
 
 			var lastBatchIndex = 0
 			for i := 1; i < len(pass.Nodes); i++ {
-				if breaksBatch(pass.Nodes[i].MaterialData(), pass.Nodes[i-1].MaterialData()) {
+				if breaksBatch(program, pass.Nodes[i], pass.Nodes[i-1]) {
 					renderBatches = append(renderBatches, RenderBatch{program, pass.Nodes[lastBatchIndex:i]})
 					lastBatchIndex = i
 				}
@@ -121,22 +130,33 @@ func (r *RenderSystem) renderBatch(program *Program, nodes []*core.Node) {
 
 	//r.renderLog += fmt.Sprintf("\t\tBatch: %d nodes\n", len(nodes))
 
-	// bind the textures for this batch
+	// bind the textures, uniform buffers and shared uniforms for this batch
 	bindTextures(program, nodes[0].MaterialData())
+	bindUniformBuffers(program, nodes[0].MaterialData())
+	bindUniforms(program, nodes[0].MaterialData())
 
-	//fixme:  build uniform buffer
-	//bindUniforms(program, nodes[0].MaterialData())
-
-	// build transform attribute buffer
+	// per instance uniforms limit the number of nodes per draw call
 	mesh := nodes[0].Mesh()
-	var matrixBuckets []float32
-	for _, n := range nodes {
-		transform64 := n.WorldTransform()
-		transform32 := core.Mat4DoubleToFloat(transform64)
-		matrixBuckets = append(matrixBuckets, transform32[0:16]...)
-	}
+	for len(nodes) > 0 {
+		count := len(nodes)
+		if capacity := program.instanceCapacity(); capacity > 0 && count > capacity {
+			count = capacity
+		}
+		instances := nodes[:count]
+		nodes = nodes[count:]
 
-	mesh.SetInstanceCount(len(nodes))
-	mesh.SetModelMatrices(matrixBuckets)
-	mesh.Draw()
+		program.setInstanceUniforms(instances)
+
+		// build transform attribute buffer
+		var matrixBuckets []float32
+		for _, n := range instances {
+			transform64 := n.WorldTransform()
+			transform32 := core.Mat4DoubleToFloat(transform64)
+			matrixBuckets = append(matrixBuckets, transform32[0:16]...)
+		}
+
+		mesh.SetInstanceCount(count)
+		mesh.SetModelMatrices(matrixBuckets)
+		mesh.Draw()
+	}
 }
@@ -1,41 +1,52 @@
 package opengl
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/fcvarela/gosg/core"
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/golang/glog"
 )
 
 type shader struct {
 	name   string
 	stype  uint32
 	id     uint32
-	source []byte
+	source core.ShaderSource
+	log    string
 }
 
-func newShader(name string, shaderType uint32, data []byte) *shader {
-	s := shader{name, shaderType, 0, data}
+func newShader(name string, shaderType uint32, source core.ShaderSource) *shader {
+	s := shader{name, shaderType, 0, source, ""}
 	return &s
 }
 
-func (s *shader) compile() {
+// compile creates and compiles the shader, keeping the compile log with its locations mapped to the files they
+// refer to. On failure the shader is deleted and the error contains the compile log.
+func (s *shader) compile() error {
 	s.id = gl.CreateShader(s.stype)
 
-	sources, free := gl.Strs(string(s.source) + "\x00")
+	sources, free := gl.Strs(string(s.source.Source) + "\x00")
 	gl.ShaderSource(s.id, 1, sources, nil)
 	free()
 	gl.CompileShader(s.id)
 
+	var logLength int32
+	gl.GetShaderiv(s.id, gl.INFO_LOG_LENGTH, &logLength)
+	if logLength > 0 {
+		compileLog := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(s.id, logLength, nil, gl.Str(compileLog))
+		s.log = s.source.MapLog(strings.TrimSpace(gl.GoStr(gl.Str(compileLog))))
+	}
+
 	var status int32
 	gl.GetShaderiv(s.id, gl.COMPILE_STATUS, &status)
 	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(s.id, gl.INFO_LOG_LENGTH, &logLength)
-		compileLog := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(s.id, logLength, nil, gl.Str(compileLog))
-		glog.Fatalf("Failed to compile shader %s\n%v\n%v\n", s.name, string(s.source), compileLog)
+		gl.DeleteShader(s.id)
+		s.id = 0
+		return fmt.Errorf("failed to compile shader %s: %v", s.name, s.log)
 	}
 
-	s.source = nil
+	s.source.Source = nil
+	return nil
 }
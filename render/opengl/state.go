@@ -11,6 +11,77 @@ var (
 	clearState          *protos.State
 	currentState        *protos.State
 	textureUnitBindings = map[uint32]uint32{}
+
+	// currentProgram is compared by id rather than by state program name, programs may be deleted and
+	// reloaded under the same name
+	currentProgram uint32
+)
+
+var (
+	depthFuncMap = map[protos.State_DepthFunc]uint32{
+		protos.State_DEPTH_LESS_EQUAL:    gl.LEQUAL,
+		protos.State_DEPTH_LESS:          gl.LESS,
+		protos.State_DEPTH_EQUAL:         gl.EQUAL,
+		protos.State_DEPTH_GREATER:       gl.GREATER,
+		protos.State_DEPTH_GREATER_EQUAL: gl.GEQUAL,
+		protos.State_DEPTH_NOT_EQUAL:     gl.NOTEQUAL,
+		protos.State_DEPTH_ALWAYS:        gl.ALWAYS,
+		protos.State_DEPTH_NEVER:         gl.NEVER,
+	}
+
+	blendModeMap = map[protos.State_BlendMode]uint32{
+		protos.State_BLEND_SRC_ALPHA:                gl.SRC_ALPHA,
+		protos.State_BLEND_ONE_MINUS_SRC_ALPHA:      gl.ONE_MINUS_SRC_ALPHA,
+		protos.State_BLEND_ONE:                      gl.ONE,
+		protos.State_BLEND_ZERO:                     gl.ZERO,
+		protos.State_BLEND_SRC_COLOR:                gl.SRC_COLOR,
+		protos.State_BLEND_ONE_MINUS_SRC_COLOR:      gl.ONE_MINUS_SRC_COLOR,
+		protos.State_BLEND_DST_COLOR:                gl.DST_COLOR,
+		protos.State_BLEND_ONE_MINUS_DST_COLOR:      gl.ONE_MINUS_DST_COLOR,
+		protos.State_BLEND_DST_ALPHA:                gl.DST_ALPHA,
+		protos.State_BLEND_ONE_MINUS_DST_ALPHA:      gl.ONE_MINUS_DST_ALPHA,
+		protos.State_BLEND_CONSTANT_COLOR:           gl.CONSTANT_COLOR,
+		protos.State_BLEND_ONE_MINUS_CONSTANT_COLOR: gl.ONE_MINUS_CONSTANT_COLOR,
+		protos.State_BLEND_CONSTANT_ALPHA:           gl.CONSTANT_ALPHA,
+		protos.State_BLEND_ONE_MINUS_CONSTANT_ALPHA: gl.ONE_MINUS_CONSTANT_ALPHA,
+		protos.State_BLEND_SRC_ALPHA_SATURATE:       gl.SRC_ALPHA_SATURATE,
+	}
+
+	blendEquationMap = map[protos.State_BlendEquation]uint32{
+		protos.State_BLEND_FUNC_ADD:              gl.FUNC_ADD,
+		protos.State_BLEND_FUNC_MAX:              gl.MAX,
+		protos.State_BLEND_FUNC_MIN:              gl.MIN,
+		protos.State_BLEND_FUNC_SUBTRACT:         gl.FUNC_SUBTRACT,
+		protos.State_BLEND_FUNC_REVERSE_SUBTRACT: gl.FUNC_REVERSE_SUBTRACT,
+	}
+
+	stencilFuncMap = map[protos.State_StencilFunc]uint32{
+		protos.State_STENCIL_ALWAYS:        gl.ALWAYS,
+		protos.State_STENCIL_NEVER:         gl.NEVER,
+		protos.State_STENCIL_LESS:          gl.LESS,
+		protos.State_STENCIL_LESS_EQUAL:    gl.LEQUAL,
+		protos.State_STENCIL_EQUAL:         gl.EQUAL,
+		protos.State_STENCIL_GREATER:       gl.GREATER,
+		protos.State_STENCIL_GREATER_EQUAL: gl.GEQUAL,
+		protos.State_STENCIL_NOT_EQUAL:     gl.NOTEQUAL,
+	}
+
+	stencilOpMap = map[protos.State_StencilOp]uint32{
+		protos.State_STENCIL_KEEP:      gl.KEEP,
+		protos.State_STENCIL_ZERO:      gl.ZERO,
+		protos.State_STENCIL_REPLACE:   gl.REPLACE,
+		protos.State_STENCIL_INCR:      gl.INCR,
+		protos.State_STENCIL_INCR_WRAP: gl.INCR_WRAP,
+		protos.State_STENCIL_DECR:      gl.DECR,
+		protos.State_STENCIL_DECR_WRAP: gl.DECR_WRAP,
+		protos.State_STENCIL_INVERT:    gl.INVERT,
+	}
+
+	polygonModeMap = map[protos.State_PolygonMode]uint32{
+		protos.State_POLYGON_FILL:  gl.FILL,
+		protos.State_POLYGON_LINE:  gl.LINE,
+		protos.State_POLYGON_POINT: gl.POINT,
+	}
 )
 
 func bindMaterialState(ub core.UniformBuffer, material *protos.State, force bool) *Program {
@@ -23,14 +94,7 @@ func bindMaterialState(ub core.UniformBuffer, material *protos.State, force bool
 	}
 
 	if material.DepthFunc != currentState.DepthFunc || force {
-		switch material.DepthFunc {
-		case protos.State_DEPTH_LESS:
-			gl.DepthFunc(gl.LESS)
-		case protos.State_DEPTH_LESS_EQUAL:
-			gl.DepthFunc(gl.LEQUAL)
-		case protos.State_DEPTH_EQUAL:
-			gl.DepthFunc(gl.EQUAL)
-		}
+		gl.DepthFunc(depthFuncMap[material.DepthFunc])
 	}
 
 	if material.ScissorTest != currentState.ScissorTest || force {
@@ -49,45 +113,32 @@ func bindMaterialState(ub core.UniformBuffer, material *protos.State, force bool
 		}
 	}
 
-	if material.BlendSrcMode != currentState.BlendSrcMode || material.BlendDstMode != currentState.BlendDstMode || force {
-		srcMode := uint32(0)
-		dstMode := uint32(0)
+	srcAlpha, dstAlpha, alphaEquation := blendAlpha(material)
+	currentSrcAlpha, currentDstAlpha, currentAlphaEquation := blendAlpha(currentState)
 
-		switch material.BlendSrcMode {
-		case protos.State_BLEND_ONE:
-			srcMode = gl.ONE
-		case protos.State_BLEND_ONE_MINUS_SRC_ALPHA:
-			srcMode = gl.ONE_MINUS_SRC_ALPHA
-		case protos.State_BLEND_SRC_ALPHA:
-			srcMode = gl.SRC_ALPHA
-		}
+	if material.BlendSrcMode != currentState.BlendSrcMode || material.BlendDstMode != currentState.BlendDstMode ||
+		srcAlpha != currentSrcAlpha || dstAlpha != currentDstAlpha || force {
+		gl.BlendFuncSeparate(
+			blendModeMap[material.BlendSrcMode], blendModeMap[material.BlendDstMode],
+			blendModeMap[srcAlpha], blendModeMap[dstAlpha])
+	}
 
-		switch material.BlendDstMode {
-		case protos.State_BLEND_ONE:
-			dstMode = gl.ONE
-		case protos.State_BLEND_ONE_MINUS_SRC_ALPHA:
-			dstMode = gl.ONE_MINUS_SRC_ALPHA
-		case protos.State_BLEND_SRC_ALPHA:
-			dstMode = gl.SRC_ALPHA
-		}
-		gl.BlendFunc(srcMode, dstMode)
+	if material.BlendEquation != currentState.BlendEquation || alphaEquation != currentAlphaEquation || force {
+		gl.BlendEquationSeparate(blendEquationMap[material.BlendEquation], blendEquationMap[alphaEquation])
 	}
 
-	if material.BlendEquation != currentState.BlendEquation || force {
-		switch material.BlendEquation {
-		case protos.State_BLEND_FUNC_ADD:
-			gl.BlendEquation(gl.FUNC_ADD)
-		case protos.State_BLEND_FUNC_MAX:
-			gl.BlendEquation(gl.MAX)
-		}
+	if blendColor(material) != blendColor(currentState) || force {
+		c := blendColor(material)
+		gl.BlendColor(c[0], c[1], c[2], c[3])
 	}
 
 	if material.DepthWrite != currentState.DepthWrite || force {
 		gl.DepthMask(material.DepthWrite)
 	}
 
-	if material.ColorWrite != currentState.ColorWrite || force {
-		gl.ColorMask(material.ColorWrite, material.ColorWrite, material.ColorWrite, material.ColorWrite)
+	if colorMask(material) != colorMask(currentState) || force {
+		m := colorMask(material)
+		gl.ColorMask(m.Red, m.Green, m.Blue, m.Alpha)
 	}
 
 	if material.Culling != currentState.Culling || force {
@@ -109,9 +160,50 @@ func bindMaterialState(ub core.UniformBuffer, material *protos.State, force bool
 		}
 	}
 
-	glProgram := core.GetResourceManager().Program(material.ProgramName).(*Program)
+	if material.StencilTest != currentState.StencilTest || force {
+		if material.StencilTest {
+			gl.Enable(gl.STENCIL_TEST)
+		} else {
+			// restore the write mask so stencil clears aren't masked
+			gl.Disable(gl.STENCIL_TEST)
+			gl.StencilMask(0xff)
+		}
+	}
+
+	// stencil faces are only bound while stencil testing is enabled
+	if material.StencilTest {
+		front, back := stencilFaces(material)
+		currentFront, currentBack := stencilFaces(currentState)
+		if front != currentFront || !currentState.StencilTest || force {
+			bindStencilFace(gl.FRONT, front)
+		}
+		if back != currentBack || !currentState.StencilTest || force {
+			bindStencilFace(gl.BACK, back)
+		}
+	}
+
+	if material.PolygonOffset != currentState.PolygonOffset || force {
+		for _, mode := range []uint32{gl.POLYGON_OFFSET_FILL, gl.POLYGON_OFFSET_LINE, gl.POLYGON_OFFSET_POINT} {
+			if material.PolygonOffset {
+				gl.Enable(mode)
+			} else {
+				gl.Disable(mode)
+			}
+		}
+	}
+
+	if material.PolygonOffsetFactor != currentState.PolygonOffsetFactor ||
+		material.PolygonOffsetUnits != currentState.PolygonOffsetUnits || force {
+		gl.PolygonOffset(material.PolygonOffsetFactor, material.PolygonOffsetUnits)
+	}
+
+	if material.PolygonMode != currentState.PolygonMode || force {
+		gl.PolygonMode(gl.FRONT_AND_BACK, polygonModeMap[material.PolygonMode])
+	}
+
+	glProgram := core.GetResourceManager().Program(core.StateProgram(material)).(*Program)
 
-	if material.ProgramName != currentState.ProgramName || force {
+	if glProgram.id != currentProgram || force {
 		glProgram.bind()
 	}
 
@@ -120,29 +212,87 @@ func bindMaterialState(ub core.UniformBuffer, material *protos.State, force bool
 		glProgram.setUniformBufferByName("cameraConstants", ub.(*UniformBuffer))
 	}
 
-	currentState = material
+	// keep a copy, states may be modified in place when their resource is reloaded
+	bound := *material
+	currentState = &bound
 
 	return glProgram
 }
 
-func breaksBatch(a *core.MaterialData, b *core.MaterialData) bool {
-	for name := range b.Textures() {
-		ta, ok := a.Textures()[name]
-		if !ok {
-			return true
-		}
+// blendAlpha returns the alpha blend modes and equation, which are the rgb ones unless set separately
+func blendAlpha(s *protos.State) (protos.State_BlendMode, protos.State_BlendMode, protos.State_BlendEquation) {
+	if s.BlendSeparateAlpha {
+		return s.BlendSrcAlphaMode, s.BlendDstAlphaMode, s.BlendAlphaEquation
+	}
+	return s.BlendSrcMode, s.BlendDstMode, s.BlendEquation
+}
 
-		tb, ok := b.Textures()[name]
-		if !ok {
-			return true
-		}
+// blendColor returns the blend constant, missing components are 0
+func blendColor(s *protos.State) (c [4]float32) {
+	copy(c[:], s.BlendColor)
+	return c
+}
 
-		if ta.(*Texture).id != tb.(*Texture).id {
-			return true
-		}
+// colorMask returns the per channel color writes
+func colorMask(s *protos.State) protos.State_ColorMask {
+	if s.ColorMask != nil {
+		return *s.ColorMask
+	}
+	return protos.State_ColorMask{Red: s.ColorWrite, Green: s.ColorWrite, Blue: s.ColorWrite, Alpha: s.ColorWrite}
+}
+
+// stencilFace is a stencil face configuration with its defaults applied, masks which are not set have all bits set
+type stencilFace struct {
+	function  protos.State_StencilFunc
+	ref       uint32
+	readMask  uint32
+	writeMask uint32
+	fail      protos.State_StencilOp
+	depthFail protos.State_StencilOp
+	pass      protos.State_StencilOp
+}
+
+func newStencilFace(f *protos.State_StencilFace) stencilFace {
+	face := stencilFace{readMask: 0xff, writeMask: 0xff}
+	if f == nil {
+		return face
+	}
+
+	face.function, face.ref = f.Func, f.Ref
+	face.fail, face.depthFail, face.pass = f.Fail, f.DepthFail, f.Pass
+	if f.ReadMask != nil {
+		face.readMask = *f.ReadMask
+	}
+	if f.WriteMask != nil {
+		face.writeMask = *f.WriteMask
 	}
+	return face
+}
+
+// stencilFaces returns the front and back stencil configuration, the back face defaults to the front one
+func stencilFaces(s *protos.State) (front, back stencilFace) {
+	front = newStencilFace(s.StencilFront)
+	back = front
+	if s.StencilBack != nil {
+		back = newStencilFace(s.StencilBack)
+	}
+	return front, back
+}
 
-	return false
+func bindStencilFace(face uint32, f stencilFace) {
+	gl.StencilFuncSeparate(face, stencilFuncMap[f.function], int32(f.ref), f.readMask)
+	gl.StencilMaskSeparate(face, f.writeMask)
+	gl.StencilOpSeparate(face, stencilOpMap[f.fail], stencilOpMap[f.depthFail], stencilOpMap[f.pass])
+}
+
+// breaksBatch returns whether two nodes draw different meshes or bind different textures, uniform buffers or
+// shared uniforms. Nodes sharing a material are compared by its identity, only the textures and buffers they
+// override are compared. Per instance uniforms never break batches.
+func breaksBatch(p *Program, a *core.Node, b *core.Node) bool {
+	if a.Mesh() != b.Mesh() {
+		return true
+	}
+	return !a.MaterialData().Equal(b.MaterialData()) || p.sharedUniformsDiffer(a.MaterialData(), b.MaterialData())
 }
 
 func bindTextures(p *Program, md *core.MaterialData) {
@@ -151,16 +301,21 @@ func bindTextures(p *Program, md *core.MaterialData) {
 	}
 }
 
+// bindCameraTextures binds the camera's light, cluster and environment textures
+func bindCameraTextures(p *Program, constants *core.CameraConstants) {
+	for name, texture := range constants.Textures() {
+		p.setTexture(name, texture.(*Texture))
+	}
+}
+
 func bindUniformBuffers(p *Program, md *core.MaterialData) {
 	for name, uniformBuffer := range md.UniformBuffers() {
 		p.setUniformBufferByName(name, uniformBuffer.(*UniformBuffer))
 	}
 }
 
-/*
 func bindUniforms(p *Program, md *core.MaterialData) {
 	for name, uniform := range md.Uniforms() {
 		p.setUniform(name, uniform.(*Uniform))
 	}
 }
-*/
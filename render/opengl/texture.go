@@ -1,11 +1,6 @@
 package opengl
 
 import (
-	"bytes"
-	"image"
-	"image/draw"
-	_ "image/jpeg" // registers jpeg handler
-	_ "image/png"  // registers png handler
 	"math"
 	"runtime"
 	"unsafe"
@@ -13,13 +8,20 @@ import (
 	"github.com/fcvarela/gosg/core"
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/golang/glog"
-	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/bmp" // registers bmp handler for core.DecodeImage
 )
 
 // Texture is an OpenGL texture container
 type Texture struct {
 	id         uint32
 	descriptor core.TextureDescriptor
+	imageData  []byte
+
+	// gl enums resolved from the descriptor
+	target         uint32
+	internalFormat uint32
+	format         uint32
+	componentType  uint32
 }
 
 func (t *Texture) Descriptor() core.TextureDescriptor {
@@ -31,6 +33,37 @@ func (t *Texture) Handle() unsafe.Pointer {
 	return unsafe.Pointer(t)
 }
 
+// ImageData implements the core.Texture interface
+func (t *Texture) ImageData() []byte {
+	return t.imageData
+}
+
+// Delete implements the core.Texture interface
+func (t *Texture) Delete() {
+	if t.id == 0 {
+		return
+	}
+
+	for unit, id := range textureUnitBindings {
+		if id == t.id {
+			delete(textureUnitBindings, unit)
+		}
+	}
+
+	gl.DeleteTextures(1, &t.id)
+	t.id = 0
+}
+
+// SetImage implements the core.Texture interface
+func (t *Texture) SetImage(img *core.DecodedImage) {
+	nt := newTextureFromImage(img)
+	t.Delete()
+	*t = *nt
+
+	// the storage now belongs to t, don't let the finalizer report it
+	nt.id = 0
+}
+
 // Lt implements the core.Texture interface
 func (t *Texture) Lt(other core.Texture) bool {
 	if ot, ok := other.(*Texture); ok {
@@ -47,52 +80,206 @@ func (t *Texture) Gt(other core.Texture) bool {
 	return false
 }
 
-func textureCleanup(t *Texture) {
-	glog.Info("Deleting texture: ", t.id)
+// NewTextureFromImageData implements the core.RenderSystem interface
+func (rs *RenderSystem) NewTextureFromImageData(data []byte, descriptor core.TextureDescriptor) core.Texture {
+	img, err := core.DecodeImage(data, descriptor)
+	if err != nil {
+		glog.Fatal("Cannot decode texture image: ", err)
+	}
+
+	return rs.NewTextureFromImage(img)
+}
+
+// NewTextureFromImage implements the core.RenderSystem interface
+func (rs *RenderSystem) NewTextureFromImage(img *core.DecodedImage) core.Texture {
+	return newTextureFromImage(img)
+}
+
+func newTextureFromImage(img *core.DecodedImage) *Texture {
+	d := img.Descriptor
+
+	// provided mip chains are uploaded as is, a single level gets generated mipmaps unless compressed
+	levels := len(img.Levels)
+	generate := levels == 1 && d.Mipmaps && !d.SizedFormat.Compressed()
+	if generate {
+		levels = mipmapLevels(d)
+	}
+
+	t := newTexture(d, levels)
+	for level, texels := range img.Levels {
+		uploadTexture(t, level, 0, d.Layers(level), texels)
+	}
+	if generate {
+		gl.GenerateMipmap(t.target)
+	}
+
+	t.imageData = img.Source
+	return t
 }
 
 // NewTexture implements the core.RenderSystem interface
-func (rs *RenderSystem) NewTextureFromImageData(data []byte, descriptor core.TextureDescriptor) core.Texture {
-	if data == nil {
-		glog.Fatal("Cannot read texture...")
+func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.Texture {
+	levels := 1
+	if d.Mipmaps && !d.SizedFormat.Compressed() {
+		levels = mipmapLevels(d)
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		glog.Warning("Cannot decode texture image: ", err)
+	t := newTexture(d, levels)
+
+	// this texture's storage has already been allocated, just copy the data
+	if data != nil {
+		uploadTexture(t, 0, 0, d.Layers(0), data)
+		if levels > 1 {
+			gl.GenerateMipmap(t.target)
+		}
 	}
 
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		panic("Unsupported stride")
+	return t
+}
+
+// SupportsTextureFormat implements the core.RenderSystem interface
+func (rs *RenderSystem) SupportsTextureFormat(f core.TextureSizedFormat) bool {
+	return !f.Compressed() || rs.compressedFormats[f]
+}
+
+// compressed formats not exposed by the core profile bindings
+const (
+	compressedRGBAS3TCDXT1         = 0x83f1
+	compressedRGBAS3TCDXT3         = 0x83f2
+	compressedRGBAS3TCDXT5         = 0x83f3
+	compressedRGBABPTCUnorm        = 0x8e8c
+	compressedRGBBPTCUnsignedFloat = 0x8e8f
+)
+
+// queryCompressedFormats returns the compressed formats supported by the current context. RGTC is core since
+// OpenGL 3.0, S3TC and BPTC come from extensions.
+func queryCompressedFormats() map[core.TextureSizedFormat]bool {
+	formats := map[core.TextureSizedFormat]bool{
+		core.TextureSizedFormatBC4: true,
+		core.TextureSizedFormatBC5: true,
 	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
 
-	descriptor.Width = uint32(rgba.Rect.Size().X)
-	descriptor.Height = uint32(rgba.Rect.Size().Y)
-	descriptor.Target = core.TextureTarget2D
-	descriptor.Format = core.TextureFormatRGBA
-	descriptor.SizedFormat = core.TextureSizedFormatRGBA8
-	descriptor.ComponentType = core.TextureComponentTypeUNSIGNEDBYTE
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		switch gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) {
+		case "GL_EXT_texture_compression_s3tc":
+			formats[core.TextureSizedFormatBC1] = true
+			formats[core.TextureSizedFormatBC2] = true
+			formats[core.TextureSizedFormatBC3] = true
+		case "GL_ARB_texture_compression_bptc":
+			formats[core.TextureSizedFormatBC6H] = true
+			formats[core.TextureSizedFormatBC7] = true
+		}
+	}
 
-	return rs.NewTexture(descriptor, rgba.Pix)
+	return formats
 }
 
-// NewRawTexture implements the core.RenderSystem interface
-func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.Texture {
+// mipmapLevels returns the number of levels of a full mip chain for the descriptor, down to the smallest dimension
+func mipmapLevels(d core.TextureDescriptor) int {
+	levels := int(math.Log2(float64(d.Width)))
+	if d.Height < d.Width {
+		levels = int(math.Log2(float64(d.Height)))
+	}
+	return levels + 1
+}
+
+// SetLayer implements the core.Texture interface
+func (t *Texture) SetLayer(level, layer int, data []byte) {
+	if layer < 0 || layer >= t.descriptor.Layers(level) {
+		glog.Fatalf("Texture layer %d out of range for level %d", layer, level)
+	}
+	uploadTexture(t, level, layer, 1, data)
+}
+
+// GenerateMipmaps implements the core.Texture interface
+func (t *Texture) GenerateMipmaps() {
+	bindForUpdate(t.target, t.id)
+	gl.GenerateMipmap(t.target)
+}
+
+// uploadTexture copies texels for consecutive layers of a mip level into the texture's storage. Cubemap
+// faces are uploaded one by one, array layers and 3D slices in one go.
+func uploadTexture(t *Texture, level, firstLayer, layers int, data []byte) {
+	if len(data) == 0 || layers == 0 {
+		return
+	}
+
+	width, height := int32(t.descriptor.Width>>uint(level)), int32(t.descriptor.Height>>uint(level))
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	bindForUpdate(t.target, t.id)
+	compressed := t.descriptor.SizedFormat.Compressed()
+
+	switch t.target {
+	case gl.TEXTURE_CUBE_MAP:
+		size := len(data) / layers
+		for i := 0; i < layers; i++ {
+			face := uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X + firstLayer + i)
+			texels := data[i*size : (i+1)*size]
+			if compressed {
+				gl.CompressedTexSubImage2D(face, int32(level), 0, 0, width, height, t.internalFormat, int32(size), gl.Ptr(texels))
+			} else {
+				gl.TexSubImage2D(face, int32(level), 0, 0, width, height, t.format, t.componentType, gl.Ptr(texels))
+			}
+		}
+	case gl.TEXTURE_2D_ARRAY, gl.TEXTURE_3D:
+		if compressed {
+			gl.CompressedTexSubImage3D(t.target, int32(level), 0, 0, int32(firstLayer), width, height, int32(layers),
+				t.internalFormat, int32(len(data)), gl.Ptr(data))
+		} else {
+			gl.TexSubImage3D(t.target, int32(level), 0, 0, int32(firstLayer), width, height, int32(layers),
+				t.format, t.componentType, gl.Ptr(data))
+		}
+	default:
+		if compressed {
+			gl.CompressedTexSubImage2D(t.target, int32(level), 0, 0, width, height, t.internalFormat, int32(len(data)), gl.Ptr(data))
+		} else {
+			gl.TexSubImage2D(t.target, int32(level), 0, 0, width, height, t.format, t.componentType, gl.Ptr(data))
+		}
+	}
+}
+
+// updateTextureUnit is the texture unit textures are bound to while they are created or updated, which programs
+// don't sample, so textures updated between draws don't replace the bindings cached in textureUnitBindings
+const updateTextureUnit = 31
+
+// bindForUpdate binds a texture to updateTextureUnit
+func bindForUpdate(target, id uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + updateTextureUnit)
+	gl.BindTexture(target, id)
+}
+
+// newTexture creates a texture and allocates storage for the given number of mip levels
+func newTexture(d core.TextureDescriptor, levels int) *Texture {
 	var target uint32
 	switch d.Target {
 	case core.TextureTarget2D:
 		target = gl.TEXTURE_2D
+	case core.TextureTarget2DArray:
+		target = gl.TEXTURE_2D_ARRAY
+	case core.TextureTargetCubemap:
+		target = gl.TEXTURE_CUBE_MAP
+	case core.TextureTarget3D:
+		target = gl.TEXTURE_3D
 	default:
 		glog.Fatalf("Texture target %v not implemented: ", d.Target)
 	}
 
-	// sampling filter
+	// sampling filter, without mip levels a mipmapped filter would leave the texture incomplete
 	var minFilter, magFilter int32
 	switch d.Filter {
 	case core.TextureFilterMipmapLinear:
 		minFilter = gl.LINEAR_MIPMAP_LINEAR
+		if levels == 1 {
+			minFilter = gl.LINEAR
+		}
 		magFilter = gl.LINEAR
 	case core.TextureFilterLinear:
 		minFilter = gl.LINEAR
@@ -133,7 +320,7 @@ func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.T
 	case core.TextureSizedFormatRG32F:
 		internalFormat = gl.RG32F
 	case core.TextureSizedFormatRGB8:
-		internalFormat = gl.RGB
+		internalFormat = gl.RGB8
 	case core.TextureSizedFormatRGB16F:
 		internalFormat = gl.RGB16F
 	case core.TextureSizedFormatRGB32F:
@@ -146,6 +333,20 @@ func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.T
 		internalFormat = gl.RGBA32F
 	case core.TextureSizedFormatDEPTH32F:
 		internalFormat = gl.DEPTH_COMPONENT32F
+	case core.TextureSizedFormatBC1:
+		internalFormat = compressedRGBAS3TCDXT1
+	case core.TextureSizedFormatBC2:
+		internalFormat = compressedRGBAS3TCDXT3
+	case core.TextureSizedFormatBC3:
+		internalFormat = compressedRGBAS3TCDXT5
+	case core.TextureSizedFormatBC4:
+		internalFormat = gl.COMPRESSED_RED_RGTC1
+	case core.TextureSizedFormatBC5:
+		internalFormat = gl.COMPRESSED_RG_RGTC2
+	case core.TextureSizedFormatBC6H:
+		internalFormat = compressedRGBBPTCUnsignedFloat
+	case core.TextureSizedFormatBC7:
+		internalFormat = compressedRGBABPTCUnorm
 	default:
 		glog.Fatalf("Texture sized format %v not implemented: ", d.SizedFormat)
 	}
@@ -162,7 +363,7 @@ func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.T
 	case core.TextureFormatRGBA:
 		format = gl.RGBA
 	case core.TextureFormatDEPTH:
-		format = gl.DEPTH
+		format = gl.DEPTH_COMPONENT
 	default:
 		glog.Fatalf("Texture format %v not implemented: ", d.Format)
 	}
@@ -174,24 +375,16 @@ func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.T
 		componentType = gl.UNSIGNED_BYTE
 	case core.TextureComponentTypeFLOAT:
 		componentType = gl.FLOAT
+	case core.TextureComponentTypeHALFFLOAT:
+		componentType = gl.HALF_FLOAT
 	default:
-		glog.Fatalf("Component type %v not implemented: ", d.Format)
+		glog.Fatalf("Component type %v not implemented: ", d.ComponentType)
 	}
 
-	// mipmaps
-	var mipmapCount = 0
-	if d.Mipmaps {
-		mipmapCount = int(math.Log2(float64(d.Width)))
-		if d.Height < d.Width {
-			mipmapCount = int(math.Log2(float64(d.Height)))
-		}
-	}
-	mipmapCount += 1
-
 	// bind the target type
 	texture := uint32(0)
 	gl.GenTextures(1, &texture)
-	gl.BindTexture(target, texture)
+	bindForUpdate(target, texture)
 
 	// set filtering
 	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, minFilter)
@@ -200,30 +393,24 @@ func (rs *RenderSystem) NewTexture(d core.TextureDescriptor, data []byte) core.T
 	// set wrap mode
 	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, wrapMode)
 	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, wrapMode)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_R, wrapMode)
 
-	// allocate memory
-	gl.TexStorage2D(target, int32(mipmapCount), internalFormat, int32(d.Width), int32(d.Height))
-
-	// set data
-	// this texture's storage has already been allocated, just copy the data
-	if data != nil {
-		gl.TexSubImage2D(
-			target,
-			0,
-			0,
-			0,
-			int32(d.Width),
-			int32(d.Height),
-			format,
-			componentType,
-			gl.Ptr(data))
-
-		if d.Mipmaps {
-			gl.GenerateMipmap(target)
-		}
+	// allocate memory, cubemap faces are allocated together like 2D levels
+	switch target {
+	case gl.TEXTURE_2D_ARRAY, gl.TEXTURE_3D:
+		gl.TexStorage3D(target, int32(levels), internalFormat, int32(d.Width), int32(d.Height), int32(d.Depth))
+	default:
+		gl.TexStorage2D(target, int32(levels), internalFormat, int32(d.Width), int32(d.Height))
 	}
 
-	t := &Texture{texture, d}
+	t := &Texture{
+		id:             texture,
+		descriptor:     d,
+		target:         target,
+		internalFormat: internalFormat,
+		format:         format,
+		componentType:  componentType,
+	}
 	runtime.SetFinalizer(t, textureCleanup)
 	return t
 }
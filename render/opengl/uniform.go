@@ -1,20 +1,16 @@
 package opengl
 
 import (
-	"reflect"
 	"unsafe"
 
 	"github.com/fcvarela/gosg/core"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
-	"github.com/go-gl/mathgl/mgl64"
-	"github.com/golang/glog"
 )
 
 // Uniform implements the core.Uniform interface
 type Uniform struct {
-	value interface{}
+	value core.UniformValue
 }
 
 // UniformBuffer implements the core.UniformBuffer interface
@@ -24,7 +20,7 @@ type UniformBuffer struct {
 
 // NewUniform implements the core.RenderSystem interface
 func (r *RenderSystem) NewUniform() core.Uniform {
-	return &Uniform{nil}
+	return &Uniform{}
 }
 
 // NewUniformBuffer implements the core.RenderSystem interface
@@ -35,43 +31,18 @@ func (r *RenderSystem) NewUniformBuffer() core.UniformBuffer {
 }
 
 // Set implements the core.Uniform interface
-func (u *Uniform) Set(value interface{}) {
+func (u *Uniform) Set(value core.UniformValue) {
 	u.value = value
 }
 
 // Value implements the core.Uniform interface
-func (u *Uniform) Value() interface{} {
+func (u *Uniform) Value() core.UniformValue {
 	return u.value
 }
 
-// Copy returns a copy of the uniform.
+// Copy implements the core.Uniform interface. Values are immutable so copies share them.
 func (u *Uniform) Copy() core.Uniform {
-	switch uval := u.Value().(type) {
-	case mgl32.Mat4:
-		return &Uniform{uval}
-	case mgl64.Mat4:
-		return &Uniform{uval}
-	case mgl64.Vec4:
-		return &Uniform{uval}
-	case mgl64.Vec3:
-		return &Uniform{uval}
-	case mgl64.Vec2:
-		return &Uniform{uval}
-	case []float32:
-		return &Uniform{uval}
-	case []mgl32.Vec2:
-		return &Uniform{uval}
-	case int:
-		return &Uniform{uval}
-	case float32:
-		return &Uniform{uval}
-	case float64:
-		return &Uniform{uval}
-	default:
-		glog.Fatalf("Unsupported uniform type: %s\n", reflect.TypeOf(u.Value()))
-	}
-
-	return nil
+	return &Uniform{u.value}
 }
 
 // Set implements the core.UniformBuffer interface
@@ -80,6 +51,16 @@ func (ub *UniformBuffer) Set(data unsafe.Pointer, dataLen int) {
 	gl.BufferData(gl.UNIFORM_BUFFER, dataLen, data, gl.DYNAMIC_DRAW)
 }
 
+// Delete implements the core.UniformBuffer interface
+func (ub *UniformBuffer) Delete() {
+	if ub.id == 0 {
+		return
+	}
+
+	gl.DeleteBuffers(1, &ub.id)
+	ub.id = 0
+}
+
 // Lt implements the core.UniformBuffer interface
 func (ub *UniformBuffer) Lt(other core.UniformBuffer) bool {
 	return ub.id < other.(*UniformBuffer).id
@@ -0,0 +1,73 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// storedExtensions are file types which are already compressed, deflating them again wastes load time
+var storedExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".ktx":  true,
+	".zip":  true,
+}
+
+// DefaultCompress deflates every entry except already compressed image formats.
+func DefaultCompress(name string) bool {
+	return !storedExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// Pack writes the contents of a data directory as an archive. The compress function picks the entries which
+// are deflated, the others are stored; a nil function uses DefaultCompress. To append the archive to an
+// executable, write the executable first and pack to the same writer.
+func Pack(w io.Writer, dir string, compress func(name string) bool) error {
+	if compress == nil {
+		compress = DefaultCompress
+	}
+
+	zw := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(fullpath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, fullpath)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Store
+		if compress(header.Name) {
+			header.Method = zip.Deflate
+		}
+
+		ew, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(fullpath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(ew, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
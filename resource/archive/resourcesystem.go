@@ -0,0 +1,143 @@
+// Package archive provides a core.ResourceSystem which loads resources from a zip archive. The archive uses the
+// same programs, states, materials, models and textures layout as the filesystem implementation, at its root.
+// Entries are read in place without extracting, and may be stored or deflated individually. Archives can be read
+// from any io.ReaderAt, including an executable with the archive appended to it.
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/fsys"
+	"github.com/golang/glog"
+)
+
+// ResourceSystem implements the core.ResourceSystem interface on top of a zip archive
+type ResourceSystem struct {
+	reader *zip.Reader
+	files  map[string]*zip.File
+	closer io.Closer
+}
+
+// New returns a ResourceSystem reading the archive of the given size from r. Data before the archive, such as an
+// executable it was appended to, is skipped. Reads may happen concurrently, r must support concurrent ReadAt.
+func New(r io.ReaderAt, size int64) (*ResourceSystem, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	return &ResourceSystem{reader: zr, files: files}, nil
+}
+
+// Open returns a ResourceSystem reading the archive at the given path. The file stays open until Stop.
+func Open(filename string) (*ResourceSystem, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r, err := New(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	r.closer = f
+	return r, nil
+}
+
+// OpenExecutable returns a ResourceSystem reading an archive appended to the running executable.
+func OpenExecutable() (*ResourceSystem, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return Open(executable)
+}
+
+// Start implements the core.ResourceSystem interface
+func (r *ResourceSystem) Start() {
+	glog.Info("Starting")
+}
+
+// Stop implements the core.ResourceSystem interface
+func (r *ResourceSystem) Stop() {
+	glog.Info("Stopping")
+	if r.closer != nil {
+		r.closer.Close()
+	}
+}
+
+// Has returns whether the archive contains an entry, named by its slash separated path from the archive root.
+func (r *ResourceSystem) Has(name string) bool {
+	_, ok := r.files[name]
+	return ok
+}
+
+func (r *ResourceSystem) entry(name string) ([]byte, error) {
+	f, ok := r.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// Resources implements the core.ResourceLister interface
+func (r *ResourceSystem) Resources(t core.ResourceType) ([]string, error) {
+	return fsys.List(r.reader, fsys.DefaultLayout, t)
+}
+
+// Model implements the core.ResourceSystem interface
+func (r *ResourceSystem) Model(name string) ([]byte, error) {
+	return r.entry(path.Join("models", name))
+}
+
+// Texture implements the core.ResourceSystem interface
+func (r *ResourceSystem) Texture(name string) ([]byte, error) {
+	return r.entry(path.Join("textures", name))
+}
+
+// State implements the core.ResourceSystem interface
+func (r *ResourceSystem) State(name string) ([]byte, error) {
+	return r.entry(path.Join("states", name+".json"))
+}
+
+// Program implements the core.ResourceSystem interface
+func (r *ResourceSystem) Program(name string) ([]byte, error) {
+	return r.entry(path.Join("programs", name) + "." + core.GetRenderSystem().ProgramExtension())
+}
+
+// ProgramData implements the core.ResourceSystem interface
+func (r *ResourceSystem) ProgramData(name string) ([]byte, error) {
+	return r.entry(path.Join("programs", name))
+}
+
+// Material implements the core.ResourceSystem interface
+func (r *ResourceSystem) Material(name string) ([]byte, error) {
+	return r.entry(path.Join("materials", name+".json"))
+}
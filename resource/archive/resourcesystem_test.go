@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/internal/resourcetest"
+)
+
+var testData = map[string]string{
+	"models/box.model":       "model",
+	"textures/box.png":       "png",
+	"states/box.json":        `{"programName": "box"}`,
+	"programs/box.gl.json":   `{"shaders": {}}`,
+	"programs/box.vs.glsl":   "void main() {}",
+	"materials/box.json":     `{"state": "box"}`,
+	"programs/common/a.glsl": "vec4 a();",
+}
+
+// packTestData packs testData after an executable prefix and returns the whole file
+func packTestData(t *testing.T, prefix []byte) []byte {
+	dir := t.TempDir()
+	for name, data := range testData {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := bytes.NewBuffer(append([]byte(nil), prefix...))
+	if err := Pack(buf, dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackAndRead(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	// an executable with the archive appended
+	prefix := bytes.Repeat([]byte{0x7f, 'E', 'L', 'F'}, 1000)
+	data := packTestData(t, prefix)
+
+	r, err := New(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		read     func(string) ([]byte, error)
+		name     string
+		expected string
+	}{
+		{r.Model, "box.model", "models/box.model"},
+		{r.Texture, "box.png", "textures/box.png"},
+		{r.State, "box", "states/box.json"},
+		{r.Program, "box", "programs/box.gl.json"},
+		{r.ProgramData, "box.vs.glsl", "programs/box.vs.glsl"},
+		{r.ProgramData, "common/a.glsl", "programs/common/a.glsl"},
+		{r.Material, "box", "materials/box.json"},
+	} {
+		got, err := c.read(c.name)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if string(got) != testData[c.expected] {
+			t.Errorf("%s: read %q, expected %q", c.name, got, testData[c.expected])
+		}
+	}
+
+	// already compressed images are stored
+	for _, f := range r.reader.File {
+		expected := zip.Deflate
+		if f.Name == "textures/box.png" {
+			expected = zip.Store
+		}
+		if f.Method != expected {
+			t.Errorf("%s has method %d, expected %d", f.Name, f.Method, expected)
+		}
+	}
+
+	names, err := r.Resources(core.ResourceTypeProgramData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"box.gl.json", "box.vs.glsl", "common/a.glsl"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("listed %v, expected %v", names, expected)
+	}
+}
+
+func TestMissingEntries(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	data := packTestData(t, nil)
+	r, err := New(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Has("states/box.json") || r.Has("states/missing.json") {
+		t.Error("Has should report the archive's entries")
+	}
+	for name, read := range map[string]func(string) ([]byte, error){
+		"missing.model": r.Model,
+		"missing.png":   r.Texture,
+		"missing":       r.State,
+		"box.model":     r.Texture,
+	} {
+		if _, err := read(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected a not found error, got %v", name, err)
+		}
+	}
+
+	if _, err := New(bytes.NewReader(data[:len(data)/2]), int64(len(data)/2)); err == nil {
+		t.Error("truncated archives should fail to open")
+	}
+}
@@ -1,8 +1,15 @@
-// Package filesystem provides a core.ResourceSystem which loads resources from the filesystem
+// Package filesystem provides a core.ResourceSystem which loads resources from the filesystem. Applications
+// create one with New and pass it to the ResourceManager:
+//
+//	r, err := filesystem.New("./data")
+//	if err != nil {
+//		glog.Fatal(err)
+//	}
+//	core.GetResourceManager().SetSystem(r)
 package filesystem
 
 import (
-	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,38 +17,32 @@ import (
 	"strings"
 
 	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/fsys"
 	"github.com/golang/glog"
 )
 
 // ResourceSystem implements the resource system interface
 type ResourceSystem struct {
-	paths map[string]string
+	paths   map[string]string
+	watcher *watcher
 }
 
-var (
-	basePath = flag.String("data", "./data", "Data directory")
-)
-
-func init() {
-	flag.Parse()
-	core.GetResourceManager().SetSystem(New(*basePath))
-}
-
-// New returns a new ResourceSystem
-func New(basePath string) *ResourceSystem {
+// New returns a new ResourceSystem, or an error if the data directory or one of its resource directories is
+// missing. The materials directory is optional.
+func New(basePath string) (*ResourceSystem, error) {
 	var bp string
 
 	if runtime.GOOS == "darwin" && strings.HasSuffix(filepath.Dir(os.Args[0]), "MacOS") {
 		glog.Info("Looking for data directory in same folder")
 		path, err := filepath.Abs(filepath.Dir(os.Args[0]))
 		if err != nil {
-			glog.Fatalf("Could not create data path from provided: %s\n", basePath)
+			return nil, fmt.Errorf("could not create data path from provided %s: %v", basePath, err)
 		}
 		bp = filepath.Join(path, basePath)
 	} else {
 		path, err := filepath.Abs(basePath)
 		if err != nil {
-			glog.Fatalf("Could not create data path from provided: %s\n", basePath)
+			return nil, fmt.Errorf("could not create data path from provided %s: %v", basePath, err)
 		}
 		bp = path
 	}
@@ -56,12 +57,13 @@ func New(basePath string) *ResourceSystem {
 	r := ResourceSystem{paths: paths}
 
 	for _, p := range paths {
-		if _, err := os.Stat(p); os.IsNotExist(err) {
-			glog.Fatalf("No such file or directory: %v\n", p)
+		if _, err := os.Stat(p); err != nil {
+			return nil, err
 		}
 	}
+	paths["materials"] = filepath.Join(bp, "materials")
 
-	return &r
+	return &r, nil
 }
 
 // Start implements the core.ResourceSystem interface
@@ -69,50 +71,53 @@ func (r *ResourceSystem) Start() {
 	glog.Info("Starting")
 }
 
-// Stop implements the core.ResourceSystem interface
+// Stop implements the core.ResourceSystem interface, it also stops watching the data directory
 func (r *ResourceSystem) Stop() {
 	glog.Info("Stopping")
+	r.unwatch()
 }
 
-func (r *ResourceSystem) resourceWithFullpath(fullpath string) []byte {
-	data, e := ioutil.ReadFile(fullpath)
-	if e != nil {
-		glog.Fatalf("Could not read file: %v\n", e)
-	}
-	return data
+func (r *ResourceSystem) resourceWithFullpath(fullpath string) ([]byte, error) {
+	return ioutil.ReadFile(fullpath)
+}
+
+// Resources implements the core.ResourceLister interface
+func (r *ResourceSystem) Resources(t core.ResourceType) ([]string, error) {
+	return fsys.List(os.DirFS(r.paths["base"]), fsys.DefaultLayout, t)
 }
 
 // Model implements the core.ResourceSystem interface
-func (r *ResourceSystem) Model(filename string) []byte {
+func (r *ResourceSystem) Model(filename string) ([]byte, error) {
 	fullpath := filepath.Join(r.paths["models"], filename)
-	res := r.resourceWithFullpath(fullpath)
-	return res
+	return r.resourceWithFullpath(fullpath)
 }
 
 // Texture implements the core.ResourceSystem interface
-func (r *ResourceSystem) Texture(filename string) []byte {
+func (r *ResourceSystem) Texture(filename string) ([]byte, error) {
 	fullpath := filepath.Join(r.paths["textures"], filename)
-	res := r.resourceWithFullpath(fullpath)
-	return res
+	return r.resourceWithFullpath(fullpath)
 }
 
 // State implements the core.ResourceSystem interface
-func (r *ResourceSystem) State(name string) []byte {
+func (r *ResourceSystem) State(name string) ([]byte, error) {
 	fullpath := filepath.Join(r.paths["states"], name+".json")
-	res := r.resourceWithFullpath(fullpath)
-	return res
+	return r.resourceWithFullpath(fullpath)
 }
 
 // Program implements the core.ResourceSystem interface
-func (r *ResourceSystem) Program(name string) []byte {
+func (r *ResourceSystem) Program(name string) ([]byte, error) {
 	fullpath := filepath.Join(r.paths["programs"], name) + "." + core.GetRenderSystem().ProgramExtension()
-	res := r.resourceWithFullpath(fullpath)
-	return res
+	return r.resourceWithFullpath(fullpath)
 }
 
 // ProgramData implements the core.ResourceSystem interface
-func (r *ResourceSystem) ProgramData(name string) []byte {
+func (r *ResourceSystem) ProgramData(name string) ([]byte, error) {
 	fullpath := filepath.Join(r.paths["programs"], name)
-	res := r.resourceWithFullpath(fullpath)
-	return res
+	return r.resourceWithFullpath(fullpath)
+}
+
+// Material implements the core.ResourceSystem interface
+func (r *ResourceSystem) Material(name string) ([]byte, error) {
+	fullpath := filepath.Join(r.paths["materials"], name+".json")
+	return r.resourceWithFullpath(fullpath)
 }
@@ -0,0 +1,146 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/golang/glog"
+)
+
+// watcher polls the data directory for modified files and keeps the resulting changes until they are collected
+type watcher struct {
+	mutex    sync.Mutex
+	modTimes map[string]time.Time
+	changes  []core.ResourceChange
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// Watch starts polling the data directory for modified files at the given interval. Changes are reported to the
+// ResourceManager, which reloads them in place. This is meant for development, polling large data directories
+// is not free. Polling ends when the resource system is stopped.
+func (r *ResourceSystem) Watch(interval time.Duration) {
+	if r.watcher != nil {
+		return
+	}
+
+	w := &watcher{
+		modTimes: make(map[string]time.Time),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	r.watcher = w
+	r.scan(w, false)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer func() {
+			ticker.Stop()
+			close(w.stopped)
+		}()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.scan(w, true)
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// unwatch stops polling and waits for a scan in progress to finish
+func (r *ResourceSystem) unwatch() {
+	if r.watcher == nil {
+		return
+	}
+
+	close(r.watcher.done)
+	<-r.watcher.stopped
+	r.watcher = nil
+}
+
+// Changes implements the core.ResourceWatcher interface
+func (r *ResourceSystem) Changes() []core.ResourceChange {
+	if r.watcher == nil {
+		return nil
+	}
+
+	r.watcher.mutex.Lock()
+	defer r.watcher.mutex.Unlock()
+
+	changes := r.watcher.changes
+	r.watcher.changes = nil
+	return changes
+}
+
+// scan walks the data directory, recording modification times and, if report is set, queueing a change for
+// every file which is new or was modified since the last scan
+func (r *ResourceSystem) scan(w *watcher, report bool) {
+	var changes []core.ResourceChange
+
+	err := filepath.Walk(r.paths["base"], func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		modTime, seen := w.modTimes[path]
+		if seen && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		w.modTimes[path] = info.ModTime()
+
+		if change, ok := r.resourceChange(path); ok && report {
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		glog.Warning("Cannot scan data directory: ", err)
+	}
+
+	if len(changes) > 0 {
+		w.mutex.Lock()
+		w.changes = append(w.changes, changes...)
+		w.mutex.Unlock()
+	}
+}
+
+// resourceChange maps a file to the resource it is loaded as
+func (r *ResourceSystem) resourceChange(path string) (core.ResourceChange, bool) {
+	for _, dir := range []string{"states", "materials", "programs", "models", "textures"} {
+		rel, err := filepath.Rel(r.paths[dir], path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch dir {
+		case "states":
+			if strings.HasSuffix(rel, ".json") {
+				return core.ResourceChange{Type: core.ResourceTypeState, Name: strings.TrimSuffix(rel, ".json")}, true
+			}
+		case "materials":
+			if strings.HasSuffix(rel, ".json") {
+				return core.ResourceChange{Type: core.ResourceTypeMaterial, Name: strings.TrimSuffix(rel, ".json")}, true
+			}
+		case "programs":
+			extension := "." + core.GetRenderSystem().ProgramExtension()
+			if strings.HasSuffix(rel, extension) {
+				return core.ResourceChange{Type: core.ResourceTypeProgram, Name: strings.TrimSuffix(rel, extension)}, true
+			}
+			return core.ResourceChange{Type: core.ResourceTypeProgramData, Name: rel}, true
+		case "models":
+			return core.ResourceChange{Type: core.ResourceTypeModel, Name: rel}, true
+		case "textures":
+			return core.ResourceChange{Type: core.ResourceTypeTexture, Name: rel}, true
+		}
+	}
+
+	return core.ResourceChange{}, false
+}
@@ -0,0 +1,83 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fcvarela/gosg/core"
+)
+
+// newTestSystem returns a resource system over an empty data directory
+func newTestSystem(t *testing.T) (*ResourceSystem, string) {
+	dir := t.TempDir()
+	for _, d := range []string{"programs", "states", "models", "textures", "materials"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, dir
+}
+
+// touch writes a file with a modification time which differs from every earlier one
+func touch(t *testing.T, path string, modTime time.Time) {
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	r, dir := newTestSystem(t)
+	touch(t, filepath.Join(dir, "states", "old.json"), time.Unix(1000, 0))
+
+	r.Start()
+	r.Watch(time.Millisecond)
+	w := r.watcher
+
+	// files added after watching starts are reported, old.json was already there and is not
+	touch(t, filepath.Join(dir, "states", "new.json"), time.Unix(2000, 0))
+	touch(t, filepath.Join(dir, "textures", "rock.png"), time.Unix(2000, 0))
+
+	var changes []core.ResourceChange
+	deadline := time.Now().Add(5 * time.Second)
+	for len(changes) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for changes, got %v", changes)
+		}
+		changes = append(changes, r.Changes()...)
+		time.Sleep(time.Millisecond)
+	}
+
+	expected := map[core.ResourceChange]bool{
+		{Type: core.ResourceTypeState, Name: "new"}:        true,
+		{Type: core.ResourceTypeTexture, Name: "rock.png"}: true,
+	}
+	for _, c := range changes {
+		if !expected[c] {
+			t.Errorf("unexpected change %v", c)
+		}
+	}
+
+	// stopping ends polling, later modifications are not seen
+	r.Stop()
+	if r.watcher != nil {
+		t.Error("stopped resource systems should not keep their watcher")
+	}
+	touch(t, filepath.Join(dir, "states", "old.json"), time.Unix(3000, 0))
+	time.Sleep(20 * time.Millisecond)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.changes) != 0 || r.Changes() != nil {
+		t.Errorf("scanned after stopping: %v", w.changes)
+	}
+}
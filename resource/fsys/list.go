@@ -0,0 +1,64 @@
+package fsys
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fcvarela/gosg/core"
+)
+
+// Resources implements the core.ResourceLister interface
+func (r *ResourceSystem) Resources(t core.ResourceType) ([]string, error) {
+	return List(r.fsys, r.layout, t)
+}
+
+// List returns the names of the resources of the given type found in fsys with the given layout, sorted. Missing
+// directories hold no resources.
+func List(fsys fs.FS, layout Layout, t core.ResourceType) ([]string, error) {
+	var dir, suffix string
+	switch t {
+	case core.ResourceTypeModel:
+		dir = layout.Models
+	case core.ResourceTypeTexture:
+		dir = layout.Textures
+	case core.ResourceTypeState:
+		dir, suffix = layout.States, ".json"
+	case core.ResourceTypeProgram:
+		dir, suffix = layout.Programs, "."+core.GetRenderSystem().ProgramExtension()
+	case core.ResourceTypeProgramData:
+		dir = layout.Programs
+	case core.ResourceTypeMaterial:
+		dir, suffix = layout.Materials, ".json"
+	default:
+		return nil, fmt.Errorf("cannot list resources of type %s", t)
+	}
+
+	dir = path.Clean("./" + dir)
+	var names []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		name := p
+		if dir != "." {
+			name = strings.TrimPrefix(p, dir+"/")
+		}
+		if !strings.HasSuffix(name, suffix) {
+			return nil
+		}
+
+		names = append(names, strings.TrimSuffix(name, suffix))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	sort.Strings(names)
+	return names, err
+}
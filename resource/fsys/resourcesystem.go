@@ -0,0 +1,96 @@
+// Package fsys provides a core.ResourceSystem which loads resources from an fs.FS, such as an embed.FS, an
+// fstest.MapFS or an os.DirFS. Use fs.Sub to serve a subdirectory, for example the data directory of an embed.FS.
+package fsys
+
+import (
+	"io/fs"
+	"path"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/golang/glog"
+)
+
+// Layout names the directories holding each resource type, as slash separated paths relative to the root of the
+// file system. An empty path or "." is the root itself.
+type Layout struct {
+	Programs  string
+	States    string
+	Models    string
+	Textures  string
+	Materials string
+}
+
+// DefaultLayout is the layout of the filesystem and archive implementations
+var DefaultLayout = Layout{
+	Programs:  "programs",
+	States:    "states",
+	Models:    "models",
+	Textures:  "textures",
+	Materials: "materials",
+}
+
+// ResourceSystem implements the core.ResourceSystem interface on top of an fs.FS
+type ResourceSystem struct {
+	fsys   fs.FS
+	layout Layout
+}
+
+// New returns a ResourceSystem reading resources from fsys with the given layout. Missing directories are not an
+// error, resources requested from them fail to load.
+func New(fsys fs.FS, layout Layout) *ResourceSystem {
+	return &ResourceSystem{fsys: fsys, layout: layout}
+}
+
+// FS returns the file system resources are read from
+func (r *ResourceSystem) FS() fs.FS {
+	return r.fsys
+}
+
+// Layout returns the directory layout resources are read with
+func (r *ResourceSystem) Layout() Layout {
+	return r.layout
+}
+
+// Start implements the core.ResourceSystem interface
+func (r *ResourceSystem) Start() {
+	glog.Info("Starting")
+}
+
+// Stop implements the core.ResourceSystem interface
+func (r *ResourceSystem) Stop() {
+	glog.Info("Stopping")
+}
+
+func (r *ResourceSystem) read(dir, name string) ([]byte, error) {
+	return fs.ReadFile(r.fsys, path.Join(dir, name))
+}
+
+// Model implements the core.ResourceSystem interface
+func (r *ResourceSystem) Model(name string) ([]byte, error) {
+	return r.read(r.layout.Models, name)
+}
+
+// Texture implements the core.ResourceSystem interface
+func (r *ResourceSystem) Texture(name string) ([]byte, error) {
+	return r.read(r.layout.Textures, name)
+}
+
+// State implements the core.ResourceSystem interface
+func (r *ResourceSystem) State(name string) ([]byte, error) {
+	return r.read(r.layout.States, name+".json")
+}
+
+// Program implements the core.ResourceSystem interface
+func (r *ResourceSystem) Program(name string) ([]byte, error) {
+	return r.read(r.layout.Programs, name+"."+core.GetRenderSystem().ProgramExtension())
+}
+
+// ProgramData implements the core.ResourceSystem interface
+func (r *ResourceSystem) ProgramData(name string) ([]byte, error) {
+	return r.read(r.layout.Programs, name)
+}
+
+// Material implements the core.ResourceSystem interface
+func (r *ResourceSystem) Material(name string) ([]byte, error) {
+	return r.read(r.layout.Materials, name+".json")
+}
@@ -0,0 +1,89 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/internal/resourcetest"
+)
+
+func TestDefaultLayout(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	r := New(fstest.MapFS{
+		"programs/flat.gl.json": {Data: []byte("program")},
+		"programs/flat.vs.glsl": {Data: []byte("shader")},
+		"states/flat.json":      {Data: []byte("state")},
+		"materials/white.json":  {Data: []byte("material")},
+		"models/box.model":      {Data: []byte("model")},
+		"textures/white.png":    {Data: []byte("texture")},
+	}, DefaultLayout)
+
+	for expected, read := range map[string]func() ([]byte, error){
+		"program":  func() ([]byte, error) { return r.Program("flat") },
+		"shader":   func() ([]byte, error) { return r.ProgramData("flat.vs.glsl") },
+		"state":    func() ([]byte, error) { return r.State("flat") },
+		"material": func() ([]byte, error) { return r.Material("white") },
+		"model":    func() ([]byte, error) { return r.Model("box.model") },
+		"texture":  func() ([]byte, error) { return r.Texture("white.png") },
+	} {
+		if data, err := read(); err != nil || string(data) != expected {
+			t.Errorf("read %q, %v, expected %q", data, err, expected)
+		}
+	}
+}
+
+func TestCustomLayout(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	// nested directories, a trailing slash and textures at the root
+	r := New(fstest.MapFS{
+		"shaders/flat.gl.json":        {Data: []byte("program")},
+		"render/states/flat.json":     {Data: []byte("state")},
+		"render/states/nested/a.json": {Data: []byte("nested state")},
+		"white.png":                   {Data: []byte("texture")},
+		"assets/meshes/a.model":       {Data: []byte("model")},
+	}, Layout{Programs: "shaders", States: "render/states/", Models: "assets/meshes", Textures: "."})
+
+	if data, err := r.Program("flat"); err != nil || string(data) != "program" {
+		t.Errorf("program: %q, %v", data, err)
+	}
+	if data, err := r.State("nested/a"); err != nil || string(data) != "nested state" {
+		t.Errorf("state: %q, %v", data, err)
+	}
+	if data, err := r.Texture("white.png"); err != nil || string(data) != "texture" {
+		t.Errorf("texture: %q, %v", data, err)
+	}
+	if data, err := r.Model("a.model"); err != nil || string(data) != "model" {
+		t.Errorf("model: %q, %v", data, err)
+	}
+
+	states, err := r.Resources(core.ResourceTypeState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"flat", "nested/a"}; !reflect.DeepEqual(states, expected) {
+		t.Errorf("listed states %v, expected %v", states, expected)
+	}
+}
+
+func TestMissingFiles(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	r := New(fstest.MapFS{"states/flat.json": {Data: []byte("state")}}, DefaultLayout)
+	if _, err := r.State("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if _, err := r.Model("box.model"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("files in missing directories should not be found, got %v", err)
+	}
+
+	models, err := r.Resources(core.ResourceTypeModel)
+	if err != nil || len(models) != 0 {
+		t.Errorf("missing directories should list no resources, got %v, %v", models, err)
+	}
+}
@@ -0,0 +1,103 @@
+// Package resourcetest holds helpers shared by the resource system tests.
+package resourcetest
+
+import (
+	"github.com/fcvarela/gosg/core"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// ProgramExtension is the program extension of the render system installed by SetRenderSystem
+const ProgramExtension = "gl.json"
+
+// RenderSystem is a core.RenderSystem for resource system tests. Resource systems only ask the render system for
+// its program extension, every method which would create GPU resources panics.
+type RenderSystem struct{}
+
+// SetRenderSystem installs a RenderSystem as the core render system
+func SetRenderSystem() {
+	core.SetRenderSystem(RenderSystem{})
+}
+
+func unsupported(method string) {
+	panic("resourcetest: RenderSystem." + method + " is not supported")
+}
+
+// Start implements the core.RenderSystem interface
+func (RenderSystem) Start() {}
+
+// Stop implements the core.RenderSystem interface
+func (RenderSystem) Stop() {}
+
+// ProgramExtension implements the core.RenderSystem interface
+func (RenderSystem) ProgramExtension() string { return ProgramExtension }
+
+// SupportsTextureFormat implements the core.RenderSystem interface
+func (RenderSystem) SupportsTextureFormat(core.TextureSizedFormat) bool { return false }
+
+// RenderLog implements the core.RenderSystem interface
+func (RenderSystem) RenderLog() string { return "" }
+
+// MakeWindow implements the core.RenderSystem interface
+func (RenderSystem) MakeWindow(core.WindowConfig) *glfw.Window {
+	unsupported("MakeWindow")
+	return nil
+}
+
+// NewMesh implements the core.RenderSystem interface
+func (RenderSystem) NewMesh() core.Mesh {
+	unsupported("NewMesh")
+	return nil
+}
+
+// NewIMGUIMesh implements the core.RenderSystem interface
+func (RenderSystem) NewIMGUIMesh() core.IMGUIMesh {
+	unsupported("NewIMGUIMesh")
+	return nil
+}
+
+// NewProgram implements the core.RenderSystem interface
+func (RenderSystem) NewProgram(string, []byte, []string) (core.Program, error) {
+	unsupported("NewProgram")
+	return nil, nil
+}
+
+// NewTextureFromImageData implements the core.RenderSystem interface
+func (RenderSystem) NewTextureFromImageData([]byte, core.TextureDescriptor) core.Texture {
+	unsupported("NewTextureFromImageData")
+	return nil
+}
+
+// NewTextureFromImage implements the core.RenderSystem interface
+func (RenderSystem) NewTextureFromImage(*core.DecodedImage) core.Texture {
+	unsupported("NewTextureFromImage")
+	return nil
+}
+
+// NewUniform implements the core.RenderSystem interface
+func (RenderSystem) NewUniform() core.Uniform {
+	unsupported("NewUniform")
+	return nil
+}
+
+// NewUniformBuffer implements the core.RenderSystem interface
+func (RenderSystem) NewUniformBuffer() core.UniformBuffer {
+	unsupported("NewUniformBuffer")
+	return nil
+}
+
+// NewTexture implements the core.RenderSystem interface
+func (RenderSystem) NewTexture(core.TextureDescriptor, []byte) core.Texture {
+	unsupported("NewTexture")
+	return nil
+}
+
+// NewFramebuffer implements the core.RenderSystem interface
+func (RenderSystem) NewFramebuffer() core.Framebuffer {
+	unsupported("NewFramebuffer")
+	return nil
+}
+
+// ExecuteRenderPlan implements the core.RenderSystem interface
+func (RenderSystem) ExecuteRenderPlan(core.RenderPlan) {
+	unsupported("ExecuteRenderPlan")
+}
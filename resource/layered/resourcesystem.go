@@ -0,0 +1,306 @@
+// Package layered provides a core.ResourceSystem which stacks other resource systems, such as a base archive, DLC
+// archives, a patch directory and user mods. Each resource is read from the highest priority layer which has it.
+//
+// Layers can be added and removed while running. Cached resources provided by the changed layer are then
+// reloaded by the ResourceManager, which polls the system for changes like any other core.ResourceWatcher.
+package layered
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/golang/glog"
+)
+
+// Layer is a resource system mounted with a name and a priority. Higher priorities take precedence, layers with
+// equal priorities are searched in the order they were added.
+type Layer struct {
+	Name     string
+	Priority int
+	System   core.ResourceSystem
+}
+
+// ResourceSystem implements the core.ResourceSystem interface on top of a stack of layers. It is safe for
+// concurrent use.
+type ResourceSystem struct {
+	mutex   sync.RWMutex
+	layers  []Layer
+	started bool
+	changes []core.ResourceChange
+	served  map[core.ResourceChange]string
+
+	// reads is held for reading while reading from a snapshot of the layers, removed layers are stopped once the
+	// reads which may still use them are done
+	reads sync.RWMutex
+}
+
+// New returns a ResourceSystem with the given layers
+func New(layers ...Layer) (*ResourceSystem, error) {
+	r := &ResourceSystem{served: make(map[core.ResourceChange]string)}
+	for _, l := range layers {
+		if err := r.Add(l); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add mounts a layer. Layers added after Start are started, and cached resources they provide are reloaded.
+func (r *ResourceSystem) Add(l Layer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.index(l.Name) >= 0 {
+		return fmt.Errorf("layer %s already mounted", l.Name)
+	}
+
+	// readers iterate the slice without holding the lock, so it is replaced rather than modified
+	i := sort.Search(len(r.layers), func(i int) bool { return r.layers[i].Priority < l.Priority })
+	layers := make([]Layer, 0, len(r.layers)+1)
+	layers = append(layers, r.layers[:i]...)
+	layers = append(layers, l)
+	r.layers = append(layers, r.layers[i:]...)
+
+	if r.started {
+		l.System.Start()
+		r.invalidate(l)
+	}
+	glog.Infof("Mounted layer %s with priority %d", l.Name, l.Priority)
+	return nil
+}
+
+// Remove unmounts a layer. Layers removed after Start are stopped, and cached resources they provided are reloaded
+// from the remaining layers. Reads which started before the layer was removed may still read from it, Remove
+// waits for them to finish before stopping it.
+func (r *ResourceSystem) Remove(name string) error {
+	r.mutex.Lock()
+	i := r.index(name)
+	if i < 0 {
+		r.mutex.Unlock()
+		return fmt.Errorf("layer %s not mounted", name)
+	}
+
+	l := r.layers[i]
+	layers := make([]Layer, 0, len(r.layers)-1)
+	layers = append(layers, r.layers[:i]...)
+	r.layers = append(layers, r.layers[i+1:]...)
+
+	started := r.started
+	if started {
+		r.invalidate(l)
+	}
+	r.mutex.Unlock()
+	glog.Infof("Unmounted layer %s", name)
+
+	if started {
+		r.reads.Lock()
+		r.reads.Unlock()
+		l.System.Stop()
+	}
+	return nil
+}
+
+// Layers returns the mounted layers, highest priority first
+func (r *ResourceSystem) Layers() []Layer {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]Layer(nil), r.layers...)
+}
+
+// Served returns the name of the layer which served the last successful read of a resource, for debugging
+// overrides. Programs and states are named as they are requested from the ResourceManager.
+func (r *ResourceSystem) Served(t core.ResourceType, name string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	layer, ok := r.served[core.ResourceChange{Type: t, Name: name}]
+	return layer, ok
+}
+
+// Resolve returns the name of the layer a resource would be read from, or false if no layer lists it. Layers
+// which can't list their resources are skipped.
+func (r *ResourceSystem) Resolve(t core.ResourceType, name string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, l := range r.layers {
+		for _, n := range r.list(l, t) {
+			if n == name {
+				return l.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Resources implements the core.ResourceLister interface, listing the resources of every layer once. Layers
+// which can't list their resources are skipped.
+func (r *ResourceSystem) Resources(t core.ResourceType) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, l := range r.layers {
+		for _, n := range r.list(l, t) {
+			if !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Changes implements the core.ResourceWatcher interface, reporting resources of added and removed layers and the
+// changes reported by layers which are watchers themselves.
+func (r *ResourceSystem) Changes() []core.ResourceChange {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	changes := r.changes
+	r.changes = nil
+
+	for _, l := range r.layers {
+		if watcher, ok := l.System.(core.ResourceWatcher); ok {
+			changes = append(changes, watcher.Changes()...)
+		}
+	}
+	return changes
+}
+
+// Start implements the core.ResourceSystem interface
+func (r *ResourceSystem) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	glog.Info("Starting")
+	for _, l := range r.layers {
+		l.System.Start()
+	}
+	r.started = true
+}
+
+// Stop implements the core.ResourceSystem interface
+func (r *ResourceSystem) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	glog.Info("Stopping")
+	for _, l := range r.layers {
+		l.System.Stop()
+	}
+	r.started = false
+}
+
+// Model implements the core.ResourceSystem interface
+func (r *ResourceSystem) Model(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeModel, name, core.ResourceSystem.Model)
+}
+
+// Texture implements the core.ResourceSystem interface
+func (r *ResourceSystem) Texture(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeTexture, name, core.ResourceSystem.Texture)
+}
+
+// State implements the core.ResourceSystem interface
+func (r *ResourceSystem) State(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeState, name, core.ResourceSystem.State)
+}
+
+// Program implements the core.ResourceSystem interface
+func (r *ResourceSystem) Program(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeProgram, name, core.ResourceSystem.Program)
+}
+
+// ProgramData implements the core.ResourceSystem interface
+func (r *ResourceSystem) ProgramData(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeProgramData, name, core.ResourceSystem.ProgramData)
+}
+
+// Material implements the core.ResourceSystem interface
+func (r *ResourceSystem) Material(name string) ([]byte, error) {
+	return r.read(core.ResourceTypeMaterial, name, core.ResourceSystem.Material)
+}
+
+// read returns a resource from the highest priority layer which has it. Layers missing the resource are skipped,
+// other errors are returned so broken overrides don't silently fall through to lower layers.
+func (r *ResourceSystem) read(t core.ResourceType, name string, get func(core.ResourceSystem, string) ([]byte, error)) ([]byte, error) {
+	r.reads.RLock()
+	defer r.reads.RUnlock()
+
+	r.mutex.RLock()
+	layers := r.layers
+	r.mutex.RUnlock()
+
+	for _, l := range layers {
+		data, err := get(l.System, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %v", l.Name, err)
+		}
+
+		r.mutex.Lock()
+		r.served[core.ResourceChange{Type: t, Name: name}] = l.Name
+		r.mutex.Unlock()
+		glog.V(1).Infof("%s %s served by layer %s", t, name, l.Name)
+		return data, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (r *ResourceSystem) index(name string) int {
+	for i, l := range r.layers {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *ResourceSystem) list(l Layer, t core.ResourceType) []string {
+	lister, ok := l.System.(core.ResourceLister)
+	if !ok {
+		return nil
+	}
+
+	names, err := lister.Resources(t)
+	if err != nil {
+		glog.Warningf("Cannot list %s resources of layer %s: %v", t, l.Name, err)
+	}
+	return names
+}
+
+// invalidate queues a change for every resource the layer provides, the ResourceManager reloads the cached ones.
+// Layers which can't list their resources can't be invalidated selectively, call ResourceManager.ReloadAll
+// after changing them.
+func (r *ResourceSystem) invalidate(l Layer) {
+	if _, ok := l.System.(core.ResourceLister); !ok {
+		glog.Warningf("Layer %s can't list its resources, cached resources are not reloaded", l.Name)
+		return
+	}
+
+	types := []core.ResourceType{
+		core.ResourceTypeState,
+		core.ResourceTypeProgram,
+		core.ResourceTypeProgramData,
+		core.ResourceTypeTexture,
+		core.ResourceTypeModel,
+		core.ResourceTypeMaterial,
+	}
+	for _, t := range types {
+		for _, name := range r.list(l, t) {
+			r.changes = append(r.changes, core.ResourceChange{Type: t, Name: name})
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package layered
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/fsys"
+	"github.com/fcvarela/gosg/resource/internal/resourcetest"
+)
+
+// testLayer serves states from memory and records whether it is running. The state named "broken" fails to
+// read, reads of "slow" signal reading and wait until release is closed.
+type testLayer struct {
+	*fsys.ResourceSystem
+
+	mutex   sync.Mutex
+	running bool
+	reading chan struct{}
+	release chan struct{}
+}
+
+// newTestLayer returns a layer serving states with the given names and contents
+func newTestLayer(states map[string]string) *testLayer {
+	data := fstest.MapFS{}
+	for name, content := range states {
+		data["states/"+name+".json"] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return &testLayer{
+		ResourceSystem: fsys.New(data, fsys.DefaultLayout),
+		reading:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+}
+
+func (l *testLayer) Start() {
+	l.mutex.Lock()
+	l.running = true
+	l.mutex.Unlock()
+}
+
+func (l *testLayer) Stop() {
+	l.mutex.Lock()
+	l.running = false
+	l.mutex.Unlock()
+}
+
+func (l *testLayer) isRunning() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.running
+}
+
+func (l *testLayer) State(name string) ([]byte, error) {
+	switch name {
+	case "broken":
+		return nil, errors.New("corrupt")
+	case "slow":
+		close(l.reading)
+		<-l.release
+		if !l.isRunning() {
+			return nil, errors.New("read from a stopped layer")
+		}
+	}
+	return l.ResourceSystem.State(name)
+}
+
+// readState returns a state's content, which names the layer it came from in these tests
+func readState(t *testing.T, r *ResourceSystem, name string) string {
+	data, err := r.State(name)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return string(data)
+}
+
+// changedNames returns the names of the pending changes, sorted
+func changedNames(r *ResourceSystem) []string {
+	var names []string
+	for _, c := range r.Changes() {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPriority(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	r, err := New(
+		Layer{Name: "base", System: newTestLayer(map[string]string{"a": "base", "b": "base", "c": "base"})},
+		Layer{Name: "mod", Priority: 10, System: newTestLayer(map[string]string{"b": "mod"})},
+		Layer{Name: "patch", Priority: 10, System: newTestLayer(map[string]string{"a": "patch"})},
+		Layer{Name: "other", Priority: 10, System: newTestLayer(map[string]string{"b": "other"})},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, l := range r.Layers() {
+		names = append(names, l.Name)
+	}
+	if expected := []string{"mod", "patch", "other", "base"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("layers %v, expected %v", names, expected)
+	}
+
+	// higher priorities win, equal ones in the order they were added
+	for name, expected := range map[string]string{"a": "patch", "b": "mod", "c": "base"} {
+		if layer, ok := r.Resolve(core.ResourceTypeState, name); !ok || layer != expected {
+			t.Errorf("%s resolves to %s, expected %s", name, layer, expected)
+		}
+		if s := readState(t, r, name); s != expected {
+			t.Errorf("%s read from %s, expected %s", name, s, expected)
+		}
+		if layer, ok := r.Served(core.ResourceTypeState, name); !ok || layer != expected {
+			t.Errorf("%s served by %s, expected %s", name, layer, expected)
+		}
+	}
+	if _, ok := r.Served(core.ResourceTypeState, "d"); ok {
+		t.Error("unread resources should not be reported as served")
+	}
+
+	if err := r.Add(Layer{Name: "base", System: newTestLayer(nil)}); err == nil {
+		t.Error("layer names should be unique")
+	}
+}
+
+func TestFallthrough(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	r, err := New(
+		Layer{Name: "top", Priority: 1, System: newTestLayer(map[string]string{"broken": "top"})},
+		Layer{Name: "bottom", System: newTestLayer(map[string]string{"a": "bottom"})},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// missing resources fall through to lower layers
+	if s := readState(t, r, "a"); s != "bottom" {
+		t.Errorf("a read from %s, expected bottom", s)
+	}
+	if _, err := r.State("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	// other errors stop the search
+	if _, err := r.State("broken"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+
+	names, err := r.Resources(core.ResourceTypeState)
+	if err != nil || !reflect.DeepEqual(names, []string{"a", "broken"}) {
+		t.Errorf("listed %v, %v", names, err)
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	base := newTestLayer(map[string]string{"a": "base", "b": "base"})
+	r, err := New(Layer{Name: "base", System: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// layers mounted before Start are not reported
+	r.Start()
+	if changes := changedNames(r); len(changes) != 0 || !base.isRunning() {
+		t.Errorf("started with changes %v", changes)
+	}
+
+	patch := newTestLayer(map[string]string{"b": "patch", "c": "patch"})
+	if err := r.Add(Layer{Name: "patch", Priority: 1, System: patch}); err != nil {
+		t.Fatal(err)
+	}
+	if !patch.isRunning() {
+		t.Error("layers added after Start should be started")
+	}
+	if changes := changedNames(r); !reflect.DeepEqual(changes, []string{"b", "c"}) {
+		t.Errorf("adding reported %v, expected the layer's resources", changes)
+	}
+	if s := readState(t, r, "b"); s != "patch" {
+		t.Errorf("b read from %s, expected patch", s)
+	}
+
+	if err := r.Remove("patch"); err != nil {
+		t.Fatal(err)
+	}
+	if patch.isRunning() {
+		t.Error("removed layers should be stopped")
+	}
+	if changes := changedNames(r); !reflect.DeepEqual(changes, []string{"b", "c"}) {
+		t.Errorf("removing reported %v, expected the layer's resources", changes)
+	}
+	if s := readState(t, r, "b"); s != "base" {
+		t.Errorf("b read from %s after removing its layer, expected base", s)
+	}
+	if layer, _ := r.Served(core.ResourceTypeState, "b"); layer != "base" {
+		t.Errorf("b served by %s, expected base", layer)
+	}
+
+	if err := r.Remove("patch"); err == nil {
+		t.Error("removing an unmounted layer should fail")
+	}
+}
+
+func TestMaterialInvalidation(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	r, err := New(Layer{Name: "base", System: newTestLayer(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Start()
+
+	// materials overridden by a mounted layer are reloaded like every other resource
+	override := fsys.New(fstest.MapFS{"materials/bricks.json": &fstest.MapFile{Data: []byte(`{"state": "mod"}`)}}, fsys.DefaultLayout)
+	expected := []core.ResourceChange{{Type: core.ResourceTypeMaterial, Name: "bricks"}}
+	if err := r.Add(Layer{Name: "mod", Priority: 1, System: override}); err != nil {
+		t.Fatal(err)
+	}
+	if changes := r.Changes(); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("adding reported %v, expected %v", changes, expected)
+	}
+	if data, err := r.Material("bricks"); err != nil || string(data) != `{"state": "mod"}` {
+		t.Errorf("read material %s, %v", data, err)
+	}
+
+	if err := r.Remove("mod"); err != nil {
+		t.Fatal(err)
+	}
+	if changes := r.Changes(); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("removing reported %v, expected %v", changes, expected)
+	}
+}
+
+func TestRemoveWaitsForReads(t *testing.T) {
+	resourcetest.SetRenderSystem()
+
+	slow := newTestLayer(map[string]string{"slow": "slow"})
+	r, err := New(Layer{Name: "slow", System: slow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Start()
+
+	read := make(chan error)
+	go func() {
+		_, err := r.State("slow")
+		read <- err
+	}()
+	<-slow.reading
+
+	removed := make(chan error)
+	go func() { removed <- r.Remove("slow") }()
+
+	select {
+	case <-removed:
+		t.Fatal("Remove should wait for reads from the layer")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(slow.release)
+	if err := <-read; err != nil {
+		t.Errorf("in flight read failed: %v", err)
+	}
+	if err := <-removed; err != nil {
+		t.Fatal(err)
+	}
+	if slow.isRunning() {
+		t.Error("removed layers should be stopped")
+	}
+}
@@ -0,0 +1,198 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fcvarela/gosg/protos"
+	"github.com/golang/protobuf/proto"
+)
+
+// Generate returns the manifest of a data directory with the programs, states, materials, models and textures
+// layout. Program specifications are the files in programs with the given extension, such as "gl.json"; their
+// shaders, the files shaders include, the programs of states, the states and textures of materials and the states
+// of model meshes are recorded as dependencies.
+func Generate(fsys fs.FS, programExtension string) (*Manifest, error) {
+	m := &Manifest{Version: Version}
+
+	dirs := []string{"programs", "states", "materials", "models", "textures"}
+	for _, dir := range dirs {
+		if _, err := fs.Stat(fsys, dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+
+			e, err := entry(dir, strings.TrimPrefix(p, dir+"/"), programExtension, data)
+			if err != nil {
+				return fmt.Errorf("%s: %v", p, err)
+			}
+			e.Path = p
+			m.Resources = append(m.Resources, e)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.buildIndex()
+	return m, nil
+}
+
+// entry describes a file, named by its path relative to its resource directory
+func entry(dir, name, programExtension string, data []byte) (Entry, error) {
+	e := Entry{Size: int64(len(data)), SHA256: Hash(data)}
+
+	var err error
+	switch {
+	case dir == "programs" && strings.HasSuffix(name, "."+programExtension):
+		e.Ref = Ref{TypeProgram, strings.TrimSuffix(name, "."+programExtension)}
+		e.Dependencies, err = programDependencies(data)
+	case dir == "programs":
+		e.Ref = Ref{TypeProgramData, name}
+		e.Dependencies = shaderDependencies(data)
+	case dir == "states" && strings.HasSuffix(name, ".json"):
+		e.Ref = Ref{TypeState, strings.TrimSuffix(name, ".json")}
+		e.Dependencies, err = stateDependencies(data)
+	case dir == "states":
+		return e, fmt.Errorf("states must have a .json extension")
+	case dir == "materials" && strings.HasSuffix(name, ".json"):
+		e.Ref = Ref{TypeMaterial, strings.TrimSuffix(name, ".json")}
+		e.Dependencies, err = materialDependencies(data)
+	case dir == "materials":
+		return e, fmt.Errorf("materials must have a .json extension")
+	case dir == "models":
+		e.Ref = Ref{TypeModel, name}
+		e.Dependencies, err = modelDependencies(data)
+	case dir == "textures":
+		e.Ref = Ref{TypeTexture, name}
+	}
+
+	return e, err
+}
+
+// programDependencies returns the shader sources of a program specification, which are named relative to the
+// programs directory
+func programDependencies(data []byte) ([]Ref, error) {
+	var spec struct {
+		Shaders map[string]string `json:"shaders"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	var refs []Ref
+	for _, shader := range spec.Shaders {
+		refs = append(refs, Ref{TypeProgramData, shader})
+	}
+	sortRefs(refs)
+	return refs, nil
+}
+
+// shaderIncludeRegexp matches the #include directives of shader sources
+var shaderIncludeRegexp = regexp.MustCompile(`(?m)^\s*#\s*include\s+"([^"]+)"`)
+
+// shaderDependencies returns the files a shader source includes, which are named relative to the programs
+// directory. Files under gosg/ are provided by the engine and are no dependencies.
+func shaderDependencies(data []byte) []Ref {
+	var refs []Ref
+	for _, m := range shaderIncludeRegexp.FindAllSubmatch(data, -1) {
+		if strings.HasPrefix(string(m[1]), "gosg/") {
+			continue
+		}
+		refs = append(refs, Ref{TypeProgramData, string(m[1])})
+	}
+	sortRefs(refs)
+	return refs
+}
+
+// stateDependencies returns the program of a state and the states it extends and includes
+func stateDependencies(data []byte) ([]Ref, error) {
+	var state struct {
+		ProgramName string   `json:"programName"`
+		Extends     string   `json:"extends"`
+		Fragments   []string `json:"fragments"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	var refs []Ref
+	if state.ProgramName != "" {
+		refs = append(refs, Ref{TypeProgram, state.ProgramName})
+	}
+	if state.Extends != "" {
+		refs = append(refs, Ref{TypeState, state.Extends})
+	}
+	for _, fragment := range state.Fragments {
+		refs = append(refs, Ref{TypeState, fragment})
+	}
+	sortRefs(refs)
+	return refs, nil
+}
+
+// materialDependencies returns the state and textures of a material
+func materialDependencies(data []byte) ([]Ref, error) {
+	var material struct {
+		State    string            `json:"state"`
+		Textures map[string]string `json:"textures"`
+	}
+	if err := json.Unmarshal(data, &material); err != nil {
+		return nil, err
+	}
+
+	var refs []Ref
+	if material.State != "" {
+		refs = append(refs, Ref{TypeState, material.State})
+	}
+	textures := make(map[string]bool)
+	for _, texture := range material.Textures {
+		if !textures[texture] {
+			textures[texture] = true
+			refs = append(refs, Ref{TypeTexture, texture})
+		}
+	}
+	sortRefs(refs)
+	return refs, nil
+}
+
+func modelDependencies(data []byte) ([]Ref, error) {
+	var model protos.Model
+	if err := proto.Unmarshal(data, &model); err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]bool)
+	var refs []Ref
+	for _, mesh := range model.Meshes {
+		if mesh.State != "" && !states[mesh.State] {
+			states[mesh.State] = true
+			refs = append(refs, Ref{TypeState, mesh.State})
+		}
+	}
+	sortRefs(refs)
+	return refs, nil
+}
+
+func sortRefs(refs []Ref) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Type != refs[j].Type {
+			return refs[i].Type < refs[j].Type
+		}
+		return refs[i].Name < refs[j].Name
+	})
+}
@@ -0,0 +1,167 @@
+// Package manifest describes the contents of a data directory: the name, type, size and SHA-256 content hash of
+// every resource, and the resources each one depends on. Manifests are used to build patches, invalidate caches
+// and verify resources as they are read.
+//
+// The package does not depend on core so tools can generate manifests without a window or render system.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Version is the manifest format version written by this package
+const Version = 1
+
+// Type is the type of a resource, named like the corresponding core.ResourceType
+type Type string
+
+const (
+	// TypeModel is a model in the models directory
+	TypeModel Type = "model"
+
+	// TypeTexture is a texture in the textures directory
+	TypeTexture Type = "texture"
+
+	// TypeState is a raster state in the states directory
+	TypeState Type = "state"
+
+	// TypeProgram is a program specification in the programs directory
+	TypeProgram Type = "program"
+
+	// TypeProgramData is any other file in the programs directory, such as shader sources
+	TypeProgramData Type = "programData"
+
+	// TypeMaterial is a material in the materials directory
+	TypeMaterial Type = "material"
+)
+
+// Ref identifies a resource by its type and the name it is requested with
+type Ref struct {
+	Type Type   `json:"type"`
+	Name string `json:"name"`
+}
+
+// Entry describes a resource
+type Entry struct {
+	Ref
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+	Dependencies []Ref  `json:"dependencies,omitempty"`
+}
+
+// Manifest lists the resources of a data directory
+type Manifest struct {
+	Version   int     `json:"version"`
+	Resources []Entry `json:"resources"`
+
+	index map[Ref]int
+}
+
+// Hash returns the hex encoded SHA-256 hash of data, as stored in manifest entries
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Unmarshal reads a manifest from its JSON encoding
+func Unmarshal(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("cannot read manifest: %v", err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+
+	m.buildIndex()
+	return m, nil
+}
+
+// Marshal returns the JSON encoding of the manifest, with resources sorted by type and name
+func (m *Manifest) Marshal() ([]byte, error) {
+	sort.Slice(m.Resources, func(i, j int) bool {
+		a, b := m.Resources[i], m.Resources[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+	m.buildIndex()
+
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// buildIndex indexes entries by type and name, manifests are not modified after being read or generated so
+// lookups from several goroutines are safe
+func (m *Manifest) buildIndex() {
+	m.index = make(map[Ref]int, len(m.Resources))
+	for i, e := range m.Resources {
+		m.index[e.Ref] = i
+	}
+}
+
+// Lookup returns the entry of a resource
+func (m *Manifest) Lookup(t Type, name string) (Entry, bool) {
+	if m.index == nil {
+		for _, e := range m.Resources {
+			if e.Ref == (Ref{t, name}) {
+				return e, true
+			}
+		}
+		return Entry{}, false
+	}
+
+	i, ok := m.index[Ref{t, name}]
+	if !ok {
+		return Entry{}, false
+	}
+	return m.Resources[i], true
+}
+
+// Verify returns an error if a resource is not listed in the manifest or its contents don't match the listed
+// size and hash
+func (m *Manifest) Verify(t Type, name string, data []byte) error {
+	e, ok := m.Lookup(t, name)
+	if !ok {
+		return fmt.Errorf("%s %s is not listed in the manifest", t, name)
+	}
+	if int64(len(data)) != e.Size {
+		return fmt.Errorf("%s %s is %d bytes, the manifest lists %d", t, name, len(data), e.Size)
+	}
+	if hash := Hash(data); hash != e.SHA256 {
+		return fmt.Errorf("%s %s hash %s doesn't match the manifest hash %s", t, name, hash, e.SHA256)
+	}
+	return nil
+}
+
+// Dependents returns the resources which depend on the given one, directly or indirectly, for example the states
+// and models to invalidate when a shader changes
+func (m *Manifest) Dependents(t Type, name string) []Ref {
+	direct := make(map[Ref][]Ref)
+	for _, e := range m.Resources {
+		for _, d := range e.Dependencies {
+			direct[d] = append(direct[d], e.Ref)
+		}
+	}
+
+	var out []Ref
+	seen := map[Ref]bool{{t, name}: true}
+	queue := []Ref{{t, name}}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		for _, d := range direct[ref] {
+			if !seen[d] {
+				seen[d] = true
+				out = append(out, d)
+				queue = append(queue, d)
+			}
+		}
+	}
+	return out
+}
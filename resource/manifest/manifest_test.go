@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func testData() fstest.MapFS {
+	return fstest.MapFS{
+		"programs/flat.gl.json":      {Data: []byte(`{"shaders": {"vertex": "flat.vs.glsl", "fragment": "flat.fs.glsl"}}`)},
+		"programs/flat.vs.glsl":      {Data: []byte("void main() {}")},
+		"programs/flat.fs.glsl":      {Data: []byte("#include \"gosg/camera.glsl\"\n#include \"common/color.glsl\"\nvoid main() {}")},
+		"programs/common/color.glsl": {Data: []byte("vec4 color() { return vec4(1.0); }")},
+		"states/flat.json":           {Data: []byte(`{"programName": "flat", "depthTest": true}`)},
+		"materials/white.json":       {Data: []byte(`{"state": "flat", "textures": {"albedoTex": "white.png"}}`)},
+		"textures/white.png":         {Data: []byte{0x89, 'P', 'N', 'G'}},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	m, err := Generate(testData(), "gl.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Resources) != 7 {
+		t.Fatalf("expected 7 resources, got %d", len(m.Resources))
+	}
+
+	program, ok := m.Lookup(TypeProgram, "flat")
+	if !ok {
+		t.Fatal("program not listed")
+	}
+	expected := []Ref{{TypeProgramData, "flat.fs.glsl"}, {TypeProgramData, "flat.vs.glsl"}}
+	if !reflect.DeepEqual(program.Dependencies, expected) {
+		t.Errorf("program dependencies %v, expected %v", program.Dependencies, expected)
+	}
+
+	state, ok := m.Lookup(TypeState, "flat")
+	if !ok || state.Path != "states/flat.json" {
+		t.Fatalf("state not listed: %v", state)
+	}
+	if !reflect.DeepEqual(state.Dependencies, []Ref{{TypeProgram, "flat"}}) {
+		t.Errorf("state dependencies %v", state.Dependencies)
+	}
+
+	material, ok := m.Lookup(TypeMaterial, "white")
+	if !ok {
+		t.Fatal("material not listed")
+	}
+	if !reflect.DeepEqual(material.Dependencies, []Ref{{TypeState, "flat"}, {TypeTexture, "white.png"}}) {
+		t.Errorf("material dependencies %v", material.Dependencies)
+	}
+
+	shader, ok := m.Lookup(TypeProgramData, "flat.fs.glsl")
+	if !ok || !reflect.DeepEqual(shader.Dependencies, []Ref{{TypeProgramData, "common/color.glsl"}}) {
+		t.Errorf("shader dependencies %v", shader.Dependencies)
+	}
+
+	dependents := m.Dependents(TypeProgramData, "flat.vs.glsl")
+	if !reflect.DeepEqual(dependents, []Ref{{TypeProgram, "flat"}, {TypeState, "flat"}, {TypeMaterial, "white"}}) {
+		t.Errorf("shader dependents %v", dependents)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data := testData()
+	generated, err := Generate(data, "gl.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := generated.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Unmarshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Verify(TypeState, "flat", data["states/flat.json"].Data); err != nil {
+		t.Error(err)
+	}
+	if err := m.Verify(TypeState, "flat", []byte(`{"programName": "flat", "depthTest": false}`)); err == nil {
+		t.Error("modified resources should fail to verify")
+	}
+	if err := m.Verify(TypeState, "other", nil); err == nil {
+		t.Error("unlisted resources should fail to verify")
+	}
+}
@@ -1,4 +1,5 @@
-// Package resource contains subpackages which implement the core.ResourceSystem interface. You can only set one per
-// client application, you can only do it once, and if you wish to provide an implementation, your init method
-// must register the type implementing the interface by calling core.SetResourceSystem(i).
+// Package resource contains subpackages which implement the core.ResourceSystem interface. Importing them has no
+// side effects, client applications construct the system they want and register it by calling
+// core.GetResourceManager().SetSystem(s). The system can be replaced later, cached resources are then reloaded
+// from the new one.
 package resource
@@ -0,0 +1,100 @@
+// Package verified provides a core.ResourceSystem which checks the resources read from another one against a
+// manifest, rejecting resources whose content hash doesn't match.
+package verified
+
+import (
+	"fmt"
+
+	"github.com/fcvarela/gosg/core"
+	"github.com/fcvarela/gosg/resource/manifest"
+	"github.com/golang/glog"
+)
+
+// ResourceSystem implements the core.ResourceSystem interface by verifying the resources of another system
+type ResourceSystem struct {
+	system   core.ResourceSystem
+	manifest *manifest.Manifest
+	strict   bool
+}
+
+// New returns a ResourceSystem reading from system and verifying resources against m. Resources missing from the
+// manifest are rejected if strict is set and passed through otherwise, so data added during development keeps
+// loading.
+func New(system core.ResourceSystem, m *manifest.Manifest, strict bool) *ResourceSystem {
+	return &ResourceSystem{system: system, manifest: m, strict: strict}
+}
+
+// Start implements the core.ResourceSystem interface
+func (r *ResourceSystem) Start() {
+	glog.Info("Starting")
+	r.system.Start()
+}
+
+// Stop implements the core.ResourceSystem interface
+func (r *ResourceSystem) Stop() {
+	glog.Info("Stopping")
+	r.system.Stop()
+}
+
+// Changes implements the core.ResourceWatcher interface if the verified system does
+func (r *ResourceSystem) Changes() []core.ResourceChange {
+	if watcher, ok := r.system.(core.ResourceWatcher); ok {
+		return watcher.Changes()
+	}
+	return nil
+}
+
+// Resources implements the core.ResourceLister interface if the verified system does
+func (r *ResourceSystem) Resources(t core.ResourceType) ([]string, error) {
+	if lister, ok := r.system.(core.ResourceLister); ok {
+		return lister.Resources(t)
+	}
+	return nil, fmt.Errorf("resource system can't list resources")
+}
+
+// Model implements the core.ResourceSystem interface
+func (r *ResourceSystem) Model(name string) ([]byte, error) {
+	return r.verify(manifest.TypeModel, name)(r.system.Model(name))
+}
+
+// Texture implements the core.ResourceSystem interface
+func (r *ResourceSystem) Texture(name string) ([]byte, error) {
+	return r.verify(manifest.TypeTexture, name)(r.system.Texture(name))
+}
+
+// State implements the core.ResourceSystem interface
+func (r *ResourceSystem) State(name string) ([]byte, error) {
+	return r.verify(manifest.TypeState, name)(r.system.State(name))
+}
+
+// Program implements the core.ResourceSystem interface
+func (r *ResourceSystem) Program(name string) ([]byte, error) {
+	return r.verify(manifest.TypeProgram, name)(r.system.Program(name))
+}
+
+// ProgramData implements the core.ResourceSystem interface
+func (r *ResourceSystem) ProgramData(name string) ([]byte, error) {
+	return r.verify(manifest.TypeProgramData, name)(r.system.ProgramData(name))
+}
+
+// Material implements the core.ResourceSystem interface
+func (r *ResourceSystem) Material(name string) ([]byte, error) {
+	return r.verify(manifest.TypeMaterial, name)(r.system.Material(name))
+}
+
+// verify returns a function checking the result of a read against the manifest
+func (r *ResourceSystem) verify(t manifest.Type, name string) func([]byte, error) ([]byte, error) {
+	return func(data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := r.manifest.Lookup(t, name); !ok && !r.strict {
+			return data, nil
+		}
+		if err := r.manifest.Verify(t, name, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
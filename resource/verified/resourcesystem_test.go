@@ -0,0 +1,70 @@
+package verified
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fcvarela/gosg/resource/fsys"
+	"github.com/fcvarela/gosg/resource/internal/resourcetest"
+	"github.com/fcvarela/gosg/resource/manifest"
+)
+
+// testSystem returns a system serving data whose manifest was generated before the data was modified
+func testSystem(t *testing.T, strict bool) *ResourceSystem {
+	resourcetest.SetRenderSystem()
+
+	data := fstest.MapFS{
+		"states/flat.json":      {Data: []byte(`{"programName": "flat"}`)},
+		"programs/flat.gl.json": {Data: []byte(`{"shaders": {}}`)},
+		"textures/white.png":    {Data: []byte("white")},
+	}
+	m, err := manifest.Generate(data, resourcetest.ProgramExtension)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data["textures/white.png"] = &fstest.MapFile{Data: []byte("tampered")}
+	data["models/new.model"] = &fstest.MapFile{Data: []byte("unlisted")}
+	return New(fsys.New(data, fsys.DefaultLayout), m, strict)
+}
+
+func TestVerified(t *testing.T) {
+	r := testSystem(t, false)
+
+	if data, err := r.State("flat"); err != nil || string(data) != `{"programName": "flat"}` {
+		t.Errorf("listed resources should pass, got %q, %v", data, err)
+	}
+	if _, err := r.Program("flat"); err != nil {
+		t.Errorf("listed resources should pass, got %v", err)
+	}
+	if _, err := r.Texture("white.png"); err == nil {
+		t.Error("resources whose hash doesn't match should be rejected")
+	}
+
+	// unlisted resources pass through, read errors are returned as is
+	if data, err := r.Model("new.model"); err != nil || string(data) != "unlisted" {
+		t.Errorf("unlisted resources should pass through, got %q, %v", data, err)
+	}
+	if _, err := r.Material("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestVerifiedStrict(t *testing.T) {
+	r := testSystem(t, true)
+
+	if _, err := r.State("flat"); err != nil {
+		t.Errorf("listed resources should pass, got %v", err)
+	}
+	if _, err := r.Texture("white.png"); err == nil {
+		t.Error("resources whose hash doesn't match should be rejected")
+	}
+	if _, err := r.Model("new.model"); err == nil {
+		t.Error("strict systems should reject unlisted resources")
+	}
+	if _, err := r.Material("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}